@@ -0,0 +1,301 @@
+// Package alumni follows sellers past their graduation date. A daily job
+// flags accepted sellers whose graduation month has ended and emails them to
+// confirm that they graduated. Confirmed alumni get a badge on their profile
+// and, depending on the configured policy, keep selling or go back to the
+// admin queue to be re-verified.
+package alumni
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"sync"
+	"time"
+	_ "time/tzdata" // graduation dates are in Asia/Jakarta even on hosts without zoneinfo
+
+	"golang-firebase-backend/config"
+	"golang-firebase-backend/jobs"
+	"golang-firebase-backend/models"
+	"golang-firebase-backend/validate"
+)
+
+var (
+	ErrNotFound       = errors.New("seller request not found")
+	ErrNotSeller      = errors.New("only accepted sellers can confirm their alumni status")
+	ErrAlreadyAlumni  = errors.New("seller is already confirmed as alumni")
+	ErrGraduationDate = errors.New("graduation date must not have passed")
+)
+
+// Alumni statuses of a seller
+const (
+	StatusPendingConfirmation = "pending_confirmation"
+	StatusAlumni              = "alumni"
+)
+
+const (
+	timezone = "Asia/Jakarta"
+	// updateBatch bounds the paths written by one multi-path update
+	updateBatch = 500
+)
+
+var (
+	settingsMu sync.RWMutex
+	settings   = config.Defaults(config.Development).Alumni
+)
+
+// Setup replaces the policy, grace period and sweep time
+func Setup(cfg config.AlumniConfig) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	settings = cfg
+}
+
+func current() config.AlumniConfig {
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+	return settings
+}
+
+func location() *time.Location {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+func sellerPath(uid string) string {
+	return "registerSellers/" + uid
+}
+
+// graduationEnd returns when the graduation month ends. Without a month the
+// end of the graduation year is used; without a year there is no date.
+func graduationEnd(month string, year int) (time.Time, bool) {
+	if year == 0 {
+		return time.Time{}, false
+	}
+	m := time.December
+	if month != "" {
+		parsed, ok := validate.ParseMonth(month)
+		if !ok {
+			return time.Time{}, false
+		}
+		m = parsed
+	}
+	return time.Date(year, m+1, 1, 0, 0, 0, 0, location()), true
+}
+
+// Sweep flags accepted sellers whose graduation date has passed and asks them
+// to confirm that they graduated. Sellers who were flagged longer than the
+// grace period ago are taken to be alumni.
+func Sweep(ctx context.Context, now time.Time) (flagged, graduated int, err error) {
+	cfg := current()
+	client, err := config.Database(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var sellers map[string]models.RegisterSeller
+	if err := client.NewRef("registerSellers").Get(ctx, &sellers); err != nil {
+		return 0, 0, fmt.Errorf("error fetching sellers: %v", err)
+	}
+
+	updates := map[string]interface{}{}
+	flush := func() error {
+		if len(updates) == 0 {
+			return nil
+		}
+		if err := client.NewRef("").Update(ctx, updates); err != nil {
+			return fmt.Errorf("error updating alumni status: %v", err)
+		}
+		jobs.Notify()
+		updates = map[string]interface{}{}
+		return nil
+	}
+
+	for uid, seller := range sellers {
+		var email *jobs.EmailPayload
+		switch nextStatus(seller, now, cfg) {
+		case StatusPendingConfirmation:
+			updates[sellerPath(uid)+"/alumni_status"] = StatusPendingConfirmation
+			updates[sellerPath(uid)+"/alumni_flagged_at"] = now
+			email = confirmationEmail(seller, now.Add(cfg.GracePeriod), cfg)
+			flagged++
+		case StatusAlumni:
+			stageGraduated(updates, uid, now, cfg)
+			email = graduatedEmail(seller, cfg)
+			graduated++
+		default:
+			continue
+		}
+
+		if email.To, err = emailAddress(ctx, uid, seller); err != nil {
+			return flagged, graduated, err
+		}
+		if email.To != "" {
+			if _, err := jobs.Stage(updates, jobs.TypeSendEmail, *email); err != nil {
+				return flagged, graduated, err
+			}
+		}
+		if len(updates) >= updateBatch {
+			if err := flush(); err != nil {
+				return flagged, graduated, err
+			}
+		}
+	}
+	return flagged, graduated, flush()
+}
+
+// nextStatus returns the alumni status a sweep at now moves the seller to:
+// StatusPendingConfirmation once the graduation date has passed, StatusAlumni
+// once the grace period has passed without an answer, and "" otherwise.
+func nextStatus(seller models.RegisterSeller, now time.Time, cfg config.AlumniConfig) string {
+	if seller.Status != "accepted" {
+		return ""
+	}
+	switch seller.AlumniStatus {
+	case "":
+		end, ok := graduationEnd(seller.GraduationMonth, seller.GraduationYear)
+		if ok && !now.Before(end) {
+			return StatusPendingConfirmation
+		}
+	case StatusPendingConfirmation:
+		if seller.AlumniFlaggedAt != nil && now.Sub(*seller.AlumniFlaggedAt) >= cfg.GracePeriod {
+			return StatusAlumni
+		}
+	}
+	return ""
+}
+
+// Confirm records the answer of a seller to whether they graduated. Sellers
+// still studying move their graduation date; graduates become alumni.
+func Confirm(ctx context.Context, uid string, input models.AlumniConfirmationInput) (*models.RegisterSeller, error) {
+	cfg := current()
+	client, err := config.Database(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var seller models.RegisterSeller
+	if err := client.NewRef(sellerPath(uid)).Get(ctx, &seller); err != nil {
+		return nil, fmt.Errorf("error fetching seller request: %v", err)
+	}
+	if seller.UID == "" {
+		return nil, ErrNotFound
+	}
+	if seller.AlumniStatus == StatusAlumni {
+		if input.Status == "graduated" {
+			// Confirming twice is not an error
+			return &seller, nil
+		}
+		return nil, ErrAlreadyAlumni
+	}
+	if seller.Status != "accepted" {
+		return nil, ErrNotSeller
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{}
+	if input.Status == "graduated" {
+		stageGraduated(updates, uid, now, cfg)
+		seller.AlumniStatus = StatusAlumni
+		seller.AlumniSince = &now
+		if cfg.Policy == config.AlumniReverify {
+			seller.Status = "pending"
+			seller.Verified = false
+			seller.ReverificationRequired = true
+		}
+	} else {
+		end, ok := graduationEnd(input.GraduationMonth, input.GraduationYear)
+		if !ok || !now.Before(end) {
+			return nil, ErrGraduationDate
+		}
+		updates[sellerPath(uid)+"/graduation_month"] = input.GraduationMonth
+		updates[sellerPath(uid)+"/graduation_year"] = input.GraduationYear
+		updates[sellerPath(uid)+"/alumni_status"] = nil
+		updates[sellerPath(uid)+"/alumni_flagged_at"] = nil
+		updates[sellerPath(uid)+"/updated_at"] = now.Format(time.RFC3339)
+		seller.GraduationMonth = input.GraduationMonth
+		seller.GraduationYear = input.GraduationYear
+		seller.AlumniStatus = ""
+		seller.AlumniFlaggedAt = nil
+	}
+	seller.UpdatedAt = now
+
+	if err := client.NewRef("").Update(ctx, updates); err != nil {
+		return nil, fmt.Errorf("error saving alumni status: %v", err)
+	}
+	return &seller, nil
+}
+
+// stageGraduated adds the change of a seller to alumni to a multi-path
+// update. Under the reverify policy the request goes back to the admin queue.
+func stageGraduated(updates map[string]interface{}, uid string, now time.Time, cfg config.AlumniConfig) {
+	path := sellerPath(uid)
+	updates[path+"/alumni_status"] = StatusAlumni
+	updates[path+"/alumni_since"] = now
+	updates[path+"/updated_at"] = now.Format(time.RFC3339)
+	updates["users/"+uid+"/alumni"] = true
+	if cfg.Policy == config.AlumniReverify {
+		updates[path+"/status"] = "pending"
+		updates[path+"/verified"] = false
+		updates[path+"/reverification_required"] = true
+		updates["users/"+uid+"/verified"] = false
+	}
+}
+
+// emailAddress prefers the account email, since campus addresses often stop
+// working after graduation
+func emailAddress(ctx context.Context, uid string, seller models.RegisterSeller) (string, error) {
+	client, err := config.Database(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var address string
+	if err := client.NewRef("users/"+uid+"/email").Get(ctx, &address); err != nil {
+		return "", fmt.Errorf("error fetching email of %s: %v", uid, err)
+	}
+	if address == "" {
+		address = seller.Email
+	}
+	return address, nil
+}
+
+// graduationText prints the graduation date of a seller, e.g. "July 2025"
+func graduationText(seller models.RegisterSeller) string {
+	if month, ok := validate.ParseMonth(seller.GraduationMonth); ok {
+		return fmt.Sprintf("%s %d", month, seller.GraduationYear)
+	}
+	return fmt.Sprint(seller.GraduationYear)
+}
+
+func policyText(cfg config.AlumniConfig) string {
+	if cfg.Policy == config.AlumniReverify {
+		return "Alumni need to be verified again by our team before they can list new products."
+	}
+	return "Alumni keep selling on SkillX and get an alumni badge on their profile."
+}
+
+func confirmationEmail(seller models.RegisterSeller, deadline time.Time, cfg config.AlumniConfig) *jobs.EmailPayload {
+	return &jobs.EmailPayload{
+		Subject: "Did you graduate? Confirm your SkillX alumni status",
+		Body: fmt.Sprintf("<p>Hi %s,</p>"+
+			"<p>According to your seller application you graduated in %s. Congratulations!</p>"+
+			"<p>Please open SkillX and confirm your alumni status, or update your graduation date if you are still studying. %s</p>"+
+			"<p>If you do not answer by %s, we will take you to be alumni.</p>",
+			html.EscapeString(seller.Name), graduationText(seller),
+			policyText(cfg), deadline.In(location()).Format("2 January 2006")),
+	}
+}
+
+func graduatedEmail(seller models.RegisterSeller, cfg config.AlumniConfig) *jobs.EmailPayload {
+	return &jobs.EmailPayload{
+		Subject: "You are now a SkillX alumni seller",
+		Body: fmt.Sprintf("<p>Hi %s,</p>"+
+			"<p>You did not confirm your alumni status in time, so we marked you as alumni. %s</p>"+
+			"<p>If you are still studying, contact us to correct your graduation date.</p>",
+			html.EscapeString(seller.Name), policyText(cfg)),
+	}
+}
@@ -0,0 +1,170 @@
+package alumni
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"golang-firebase-backend/config"
+	"golang-firebase-backend/models"
+)
+
+func TestGraduationEnd(t *testing.T) {
+	jakarta := location()
+
+	tests := []struct {
+		name   string
+		month  string
+		year   int
+		want   time.Time
+		wantOK bool
+	}{
+		{"month name", "July", 2025, time.Date(2025, time.August, 1, 0, 0, 0, 0, jakarta), true},
+		{"month number", "7", 2025, time.Date(2025, time.August, 1, 0, 0, 0, 0, jakarta), true},
+		{"december ends the year", "December", 2025, time.Date(2026, time.January, 1, 0, 0, 0, 0, jakarta), true},
+		{"no month", "", 2025, time.Date(2026, time.January, 1, 0, 0, 0, 0, jakarta), true},
+		{"no year", "July", 0, time.Time{}, false},
+		{"invalid month", "Juli", 2025, time.Time{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := graduationEnd(tt.month, tt.year)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("graduationEnd(%q, %d) = %s, %t, want %s, %t", tt.month, tt.year, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestGraduationEndIsInJakarta(t *testing.T) {
+	end, _ := graduationEnd("July", 2025)
+	// Midnight in Jakarta is 17:00 UTC the day before
+	if want := time.Date(2025, time.July, 31, 17, 0, 0, 0, time.UTC); !end.Equal(want) {
+		t.Errorf("end = %s, want %s", end.UTC(), want)
+	}
+}
+
+func TestNextStatus(t *testing.T) {
+	cfg := config.AlumniConfig{Policy: config.AlumniKeep, GracePeriod: 30 * 24 * time.Hour}
+	now := time.Date(2025, time.August, 15, 2, 0, 0, 0, time.UTC)
+	flaggedAt := func(ago time.Duration) *time.Time {
+		at := now.Add(-ago)
+		return &at
+	}
+
+	tests := []struct {
+		name   string
+		seller models.RegisterSeller
+		want   string
+	}{
+		{
+			name:   "graduated",
+			seller: models.RegisterSeller{Status: "accepted", GraduationMonth: "July", GraduationYear: 2025},
+			want:   StatusPendingConfirmation,
+		},
+		{
+			name:   "still studying",
+			seller: models.RegisterSeller{Status: "accepted", GraduationMonth: "August", GraduationYear: 2025},
+		},
+		{
+			name:   "graduation year only",
+			seller: models.RegisterSeller{Status: "accepted", GraduationYear: 2025},
+		},
+		{
+			name:   "no graduation date",
+			seller: models.RegisterSeller{Status: "accepted"},
+		},
+		{
+			name:   "not accepted",
+			seller: models.RegisterSeller{Status: "pending", GraduationMonth: "July", GraduationYear: 2024},
+		},
+		{
+			name: "waiting for an answer",
+			seller: models.RegisterSeller{Status: "accepted", GraduationMonth: "July", GraduationYear: 2025,
+				AlumniStatus: StatusPendingConfirmation, AlumniFlaggedAt: flaggedAt(29 * 24 * time.Hour)},
+		},
+		{
+			name: "grace period over",
+			seller: models.RegisterSeller{Status: "accepted", GraduationMonth: "July", GraduationYear: 2025,
+				AlumniStatus: StatusPendingConfirmation, AlumniFlaggedAt: flaggedAt(30 * 24 * time.Hour)},
+			want: StatusAlumni,
+		},
+		{
+			name: "flagged without a date",
+			seller: models.RegisterSeller{Status: "accepted",
+				AlumniStatus: StatusPendingConfirmation},
+		},
+		{
+			name: "already alumni",
+			seller: models.RegisterSeller{Status: "accepted", GraduationMonth: "July", GraduationYear: 2024,
+				AlumniStatus: StatusAlumni},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextStatus(tt.seller, now, cfg); got != tt.want {
+				t.Errorf("nextStatus = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStageGraduated(t *testing.T) {
+	now := time.Date(2025, time.August, 15, 2, 0, 0, 0, time.UTC)
+	keep := map[string]interface{}{
+		"registerSellers/u1/alumni_status": StatusAlumni,
+		"registerSellers/u1/alumni_since":  now,
+		"registerSellers/u1/updated_at":    now.Format(time.RFC3339),
+		"users/u1/alumni":                  true,
+	}
+	reverify := map[string]interface{}{
+		"registerSellers/u1/status":                  "pending",
+		"registerSellers/u1/verified":                false,
+		"registerSellers/u1/reverification_required": true,
+		"users/u1/verified":                          false,
+	}
+	for path, value := range keep {
+		reverify[path] = value
+	}
+
+	tests := []struct {
+		policy string
+		want   map[string]interface{}
+	}{
+		{config.AlumniKeep, keep},
+		{config.AlumniReverify, reverify},
+	}
+	for _, tt := range tests {
+		t.Run(tt.policy, func(t *testing.T) {
+			updates := map[string]interface{}{}
+			stageGraduated(updates, "u1", now, config.AlumniConfig{Policy: tt.policy})
+			if !reflect.DeepEqual(updates, tt.want) {
+				t.Errorf("updates = %v, want %v", updates, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextRun(t *testing.T) {
+	jakarta := location()
+
+	tests := []struct {
+		name  string
+		now   time.Time
+		clock string
+		want  time.Time
+	}{
+		{"later today", time.Date(2025, 8, 15, 1, 0, 0, 0, jakarta), "02:00", time.Date(2025, 8, 15, 2, 0, 0, 0, jakarta)},
+		{"tomorrow", time.Date(2025, 8, 15, 3, 0, 0, 0, jakarta), "02:00", time.Date(2025, 8, 16, 2, 0, 0, 0, jakarta)},
+		{"exactly now runs tomorrow", time.Date(2025, 8, 15, 2, 0, 0, 0, jakarta), "02:00", time.Date(2025, 8, 16, 2, 0, 0, 0, jakarta)},
+		{"now in another zone", time.Date(2025, 8, 14, 18, 30, 0, 0, time.UTC), "02:00", time.Date(2025, 8, 15, 2, 0, 0, 0, jakarta)},
+		{"invalid clock runs at midnight", time.Date(2025, 8, 15, 3, 0, 0, 0, jakarta), "2am", time.Date(2025, 8, 16, 0, 0, 0, 0, jakarta)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := nextRun(tt.now, tt.clock); !got.Equal(tt.want) {
+				t.Errorf("nextRun(%s, %q) = %s, want %s", tt.now, tt.clock, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package alumni
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"golang-firebase-backend/config"
+	"golang-firebase-backend/jobs"
+	"golang-firebase-backend/logging"
+
+	"firebase.google.com/go/db"
+)
+
+const (
+	typeSweep = "alumni.sweep"
+	// schedulePath holds the run time of the sweep that is waiting, so that
+	// replicas starting at the same time enqueue it once
+	schedulePath = "alumni_sweep/next_run"
+	// staleAfter is how long a run may be overdue before it is taken to be
+	// lost, e.g. because its job was dropped from the dead letter queue
+	staleAfter = 24 * time.Hour
+)
+
+type sweepPayload struct {
+	At time.Time `json:"at"`
+}
+
+func init() {
+	jobs.Register(typeSweep, runSweep)
+}
+
+// Schedule enqueues the next daily sweep unless one is already waiting. It
+// is called at startup by every replica.
+func Schedule(ctx context.Context) error {
+	return schedule(ctx, time.Time{})
+}
+
+// schedule enqueues the sweep after the one planned for ran. It does nothing
+// when another sweep was planned meanwhile, so that retries of a run do not
+// enqueue the next one twice.
+func schedule(ctx context.Context, ran time.Time) error {
+	client, err := config.Database(ctx)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	next := nextRun(now, current().SweepTime)
+	ref := client.NewRef(schedulePath)
+
+	var enqueue bool
+	if err := ref.Transaction(ctx, func(node db.TransactionNode) (interface{}, error) {
+		var planned time.Time
+		if err := node.Unmarshal(&planned); err != nil {
+			return nil, err
+		}
+		enqueue = planned.IsZero() || planned.Equal(ran) || now.Sub(planned) > staleAfter
+		if !enqueue {
+			return planned, nil
+		}
+		return next, nil
+	}); err != nil {
+		return fmt.Errorf("error scheduling alumni sweep: %v", err)
+	}
+	if !enqueue {
+		return nil
+	}
+
+	if _, err := jobs.Enqueue(ctx, typeSweep, sweepPayload{At: next}, jobs.At(next)); err != nil {
+		ref.Delete(ctx)
+		return err
+	}
+	return nil
+}
+
+// nextRun returns the first time after now when the clock in Asia/Jakarta
+// reads clock, formatted as HH:MM
+func nextRun(now time.Time, clock string) time.Time {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		t = time.Time{}
+	}
+	local := now.In(location())
+	next := time.Date(local.Year(), local.Month(), local.Day(), t.Hour(), t.Minute(), 0, 0, location())
+	if !next.After(local) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+func runSweep(ctx context.Context, payload json.RawMessage) error {
+	var p sweepPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("invalid alumni sweep payload: %v", err)
+	}
+
+	// The next run is planned first so that a failing sweep is retried
+	// without stopping the daily schedule
+	if err := schedule(ctx, p.At); err != nil {
+		return err
+	}
+
+	flagged, graduated, err := Sweep(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+	logging.From(ctx).Info("alumni sweep finished", "flagged", flagged, "graduated", graduated)
+	return nil
+}
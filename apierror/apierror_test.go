@@ -0,0 +1,187 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFromStatus(t *testing.T) {
+	tests := []struct {
+		status int
+		want   string
+	}{
+		{http.StatusBadRequest, CodeBadRequest},
+		{http.StatusUnauthorized, CodeUnauthorized},
+		{http.StatusForbidden, CodeForbidden},
+		{http.StatusNotFound, CodeNotFound},
+		{http.StatusMethodNotAllowed, CodeMethodNotAllowed},
+		{http.StatusConflict, CodeConflict},
+		{http.StatusPreconditionFailed, CodePreconditionFailed},
+		{http.StatusRequestEntityTooLarge, CodePayloadTooLarge},
+		{http.StatusUnsupportedMediaType, CodeUnsupportedMediaType},
+		{http.StatusUnprocessableEntity, CodeValidationFailed},
+		{http.StatusTooManyRequests, CodeRateLimited},
+		{http.StatusServiceUnavailable, CodeUnavailable},
+		{http.StatusInternalServerError, CodeInternal},
+		{http.StatusBadGateway, CodeInternal},
+		{http.StatusTeapot, CodeBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			err := FromStatus(tt.status, "message")
+			if err.Status != tt.status || err.Code != tt.want {
+				t.Errorf("FromStatus(%d) = %d %s, want %d %s", tt.status, err.Status, err.Code, tt.status, tt.want)
+			}
+		})
+	}
+}
+
+// decode reads the error envelope written to w
+func decode(t *testing.T, w *httptest.ResponseRecorder) envelope {
+	t.Helper()
+	var body envelope
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("Unmarshal %q: %v", w.Body.String(), err)
+	}
+	if body.Success || body.Error == nil {
+		t.Fatalf("body = %s, want an error envelope", w.Body.String())
+	}
+	return body
+}
+
+func TestWrite(t *testing.T) {
+	tests := []struct {
+		name       string
+		err        error
+		header     string // request ID sent by the client
+		wantStatus int
+		wantCode   string
+		wantMsg    string
+		wantFields int
+		wantID     string
+	}{
+		{
+			name:       "api error",
+			err:        NotFound("Product not found"),
+			wantStatus: http.StatusNotFound,
+			wantCode:   CodeNotFound,
+			wantMsg:    "Product not found",
+		},
+		{
+			name:       "field errors",
+			err:        Validation("Invalid input").WithField("price", "must be a number"),
+			wantStatus: http.StatusUnprocessableEntity,
+			wantCode:   CodeValidationFailed,
+			wantMsg:    "Invalid input",
+			wantFields: 1,
+		},
+		{
+			name:       "wrapped api error",
+			err:        errors.Join(errors.New("context"), Conflict("Already exists")),
+			wantStatus: http.StatusConflict,
+			wantCode:   CodeConflict,
+			wantMsg:    "Already exists",
+		},
+		{
+			name:       "other errors do not leak",
+			err:        errors.New("database password rejected"),
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   CodeInternal,
+			wantMsg:    "Internal server error",
+		},
+		{
+			name:       "request ID from the request",
+			err:        BadRequest("Bad"),
+			header:     "req-1",
+			wantStatus: http.StatusBadRequest,
+			wantCode:   CodeBadRequest,
+			wantMsg:    "Bad",
+			wantID:     "req-1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			if tt.header != "" {
+				r.Header.Set(RequestIDHeader, tt.header)
+			}
+			w := httptest.NewRecorder()
+			Write(w, r, tt.err)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Content-Type"); got != "application/json" {
+				t.Errorf("Content-Type = %q", got)
+			}
+			body := decode(t, w)
+			if body.Error.Code != tt.wantCode || body.Error.Message != tt.wantMsg {
+				t.Errorf("error = %s %q, want %s %q", body.Error.Code, body.Error.Message, tt.wantCode, tt.wantMsg)
+			}
+			if len(body.Error.Fields) != tt.wantFields {
+				t.Errorf("fields = %v, want %d", body.Error.Fields, tt.wantFields)
+			}
+			if body.Error.RequestID != tt.wantID {
+				t.Errorf("request_id = %q, want %q", body.Error.RequestID, tt.wantID)
+			}
+		})
+	}
+}
+
+func TestWriteDoesNotChangeTheError(t *testing.T) {
+	err := NotFound("Product not found")
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set(RequestIDHeader, "req-1")
+	Write(httptest.NewRecorder(), r, err)
+	if err.RequestID != "" {
+		t.Errorf("RequestID = %q, want the shared error left unchanged", err.RequestID)
+	}
+}
+
+func TestRecover(t *testing.T) {
+	tests := []struct {
+		name       string
+		handler    http.HandlerFunc
+		wantStatus int
+		wantBody   bool // whether the error envelope is sent
+	}{
+		{
+			name:       "panic before the response",
+			handler:    func(w http.ResponseWriter, r *http.Request) { panic("boom") },
+			wantStatus: http.StatusInternalServerError,
+			wantBody:   true,
+		},
+		{
+			name: "panic after the response started",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusAccepted)
+				panic("boom")
+			},
+			wantStatus: http.StatusAccepted,
+		},
+		{
+			name:       "no panic",
+			handler:    func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) },
+			wantStatus: http.StatusNoContent,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			Recover(tt.handler).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantBody {
+				if body := decode(t, w); body.Error.Code != CodeInternal {
+					t.Errorf("code = %s, want %s", body.Error.Code, CodeInternal)
+				}
+			} else if w.Body.Len() != 0 {
+				t.Errorf("body = %q, want none", w.Body.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package apierror
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"runtime/debug"
+
+	"golang-firebase-backend/logging"
+	"golang-firebase-backend/response"
+)
+
+// RequestIDHeader carries the ID that correlates a request with its logs
+const RequestIDHeader = logging.RequestIDHeader
+
+type envelope struct {
+	Success bool   `json:"success"`
+	Error   *Error `json:"error"`
+}
+
+// Write sends err to the client in the standard error envelope. Errors that
+// are not *Error are reported as an internal error without leaking details.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		logging.From(r.Context()).Error("unhandled error", "error", err)
+		apiErr = Internal("Internal server error")
+	}
+
+	body := *apiErr
+	if body.RequestID == "" {
+		body.RequestID = requestID(w, r)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(body.Status)
+	json.NewEncoder(w).Encode(envelope{Success: false, Error: &body})
+}
+
+// Respond sends an error with the default code for the status
+func Respond(w http.ResponseWriter, r *http.Request, status int, message string) {
+	Write(w, r, FromStatus(status, message))
+}
+
+func requestID(w http.ResponseWriter, r *http.Request) string {
+	if id := logging.RequestID(r.Context()); id != "" {
+		return id
+	}
+	if id := w.Header().Get(RequestIDHeader); id != "" {
+		return id
+	}
+	return r.Header.Get(RequestIDHeader)
+}
+
+// Recover turns a panic in a handler into a 500 in the standard envelope
+// instead of dropping the connection
+func Recover(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rw := response.Wrap(w)
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				panic(p)
+			}
+
+			logging.From(r.Context()).Error("panic", "panic", p, "stack", string(debug.Stack()))
+			if !rw.Started() {
+				Write(w, r, Internal("Internal server error"))
+			}
+		}()
+
+		next.ServeHTTP(rw, r)
+	})
+}
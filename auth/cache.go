@@ -0,0 +1,93 @@
+package auth
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// cache is a least-recently-used cache of verified tokens. Entries are
+// dropped when their token expires.
+type cache struct {
+	mu      sync.Mutex
+	size    int
+	order   *list.List // most recently used first
+	entries map[[32]byte]*list.Element
+}
+
+type cacheEntry struct {
+	key       [32]byte
+	principal *Principal
+}
+
+func newCache(size int) *cache {
+	return &cache{size: size, order: list.New(), entries: map[[32]byte]*list.Element{}}
+}
+
+func (c *cache) get(key [32]byte, now time.Time) (*Principal, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*cacheEntry)
+	if !now.Before(entry.principal.Expires) {
+		c.order.Remove(element)
+		delete(c.entries, key)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return entry.principal, true
+}
+
+func (c *cache) add(key [32]byte, p *Principal) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[key]; ok {
+		element.Value.(*cacheEntry).principal = p
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, principal: p})
+	for c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
+
+func (c *cache) remove(key [32]byte) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.order.Remove(element)
+		delete(c.entries, key)
+	}
+}
+
+// removeUID drops every token of a user
+func (c *cache) removeUID(uid string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, element := range c.entries {
+		if element.Value.(*cacheEntry).principal.UID == uid {
+			c.order.Remove(element)
+			delete(c.entries, key)
+		}
+	}
+}
+
+// removeSession drops every token of one session of a user
+func (c *cache) removeSession(uid, sessionID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key, element := range c.entries {
+		if p := element.Value.(*cacheEntry).principal; p.UID == uid && p.SessionID == sessionID {
+			c.order.Remove(element)
+			delete(c.entries, key)
+		}
+	}
+}
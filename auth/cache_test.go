@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+var now = time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func principal(uid, sessionID string) *Principal {
+	return &Principal{UID: uid, SessionID: sessionID, Expires: now.Add(time.Hour)}
+}
+
+func TestCacheEvictsTheLeastRecentlyUsed(t *testing.T) {
+	tests := []struct {
+		name string
+		ops  func(c *cache)
+		kept []string
+		gone []string
+	}{
+		{
+			name: "oldest entry goes first",
+			ops: func(c *cache) {
+				c.add(tokenKey("a"), principal("a", "s"))
+				c.add(tokenKey("b"), principal("b", "s"))
+				c.add(tokenKey("c"), principal("c", "s"))
+			},
+			kept: []string{"b", "c"},
+			gone: []string{"a"},
+		},
+		{
+			name: "a read keeps an entry",
+			ops: func(c *cache) {
+				c.add(tokenKey("a"), principal("a", "s"))
+				c.add(tokenKey("b"), principal("b", "s"))
+				c.get(tokenKey("a"), now)
+				c.add(tokenKey("c"), principal("c", "s"))
+			},
+			kept: []string{"a", "c"},
+			gone: []string{"b"},
+		},
+		{
+			name: "adding again refreshes without growing",
+			ops: func(c *cache) {
+				c.add(tokenKey("a"), principal("a", "s"))
+				c.add(tokenKey("b"), principal("b", "s"))
+				c.add(tokenKey("a"), principal("a", "s"))
+				c.add(tokenKey("c"), principal("c", "s"))
+			},
+			kept: []string{"a", "c"},
+			gone: []string{"b"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newCache(2)
+			tt.ops(c)
+			if c.order.Len() != len(c.entries) || len(c.entries) > 2 {
+				t.Fatalf("%d entries in the list and %d in the map, want at most 2 of each", c.order.Len(), len(c.entries))
+			}
+			for _, token := range tt.kept {
+				if _, ok := c.get(tokenKey(token), now); !ok {
+					t.Errorf("%s was evicted", token)
+				}
+			}
+			for _, token := range tt.gone {
+				if _, ok := c.get(tokenKey(token), now); ok {
+					t.Errorf("%s was kept", token)
+				}
+			}
+		})
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	tests := []struct {
+		name string
+		at   time.Time
+		want bool
+	}{
+		{"before expiry", now.Add(59 * time.Minute), true},
+		{"at expiry", now.Add(time.Hour), false},
+		{"after expiry", now.Add(2 * time.Hour), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newCache(10)
+			c.add(tokenKey("a"), principal("a", "s"))
+			if _, ok := c.get(tokenKey("a"), tt.at); ok != tt.want {
+				t.Errorf("get = %t, want %t", ok, tt.want)
+			}
+			if !tt.want && len(c.entries) != 0 {
+				t.Error("the expired entry was kept")
+			}
+		})
+	}
+}
+
+func TestCacheRemove(t *testing.T) {
+	tests := []struct {
+		name   string
+		remove func(c *cache)
+		kept   []string
+	}{
+		{"token", func(c *cache) { c.remove(tokenKey("u1-phone")) }, []string{"u1-laptop", "u1-laptop-refreshed", "u2-phone"}},
+		{"user", func(c *cache) { c.removeUID("u1") }, []string{"u2-phone"}},
+		{"session", func(c *cache) { c.removeSession("u1", "laptop") }, []string{"u1-phone", "u2-phone"}},
+		{"session of another user", func(c *cache) { c.removeSession("u2", "laptop") }, []string{"u1-phone", "u1-laptop", "u1-laptop-refreshed", "u2-phone"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newCache(10)
+			tokens := map[string]*Principal{
+				"u1-phone":            principal("u1", "phone"),
+				"u1-laptop":           principal("u1", "laptop"),
+				"u1-laptop-refreshed": principal("u1", "laptop"),
+				"u2-phone":            principal("u2", "phone"),
+			}
+			for token, p := range tokens {
+				c.add(tokenKey(token), p)
+			}
+			tt.remove(c)
+
+			kept := map[string]bool{}
+			for _, token := range tt.kept {
+				kept[token] = true
+			}
+			for token := range tokens {
+				if _, ok := c.get(tokenKey(token), now); ok != kept[token] {
+					t.Errorf("%s cached = %t, want %t", token, ok, kept[token])
+				}
+			}
+			if c.order.Len() != len(c.entries) {
+				t.Errorf("%d entries in the list and %d in the map", c.order.Len(), len(c.entries))
+			}
+		})
+	}
+}
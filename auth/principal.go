@@ -0,0 +1,71 @@
+// Package auth verifies Firebase ID tokens and identifies the user behind a
+// request. The authentication middleware puts a Principal into the request
+// context; handlers read it with FromContext or UID.
+package auth
+
+import (
+	"context"
+	"time"
+
+	"golang-firebase-backend/models"
+)
+
+// Principal is the authenticated user of a request
+type Principal struct {
+	UID   string
+	Email string
+	// Role is the "role" custom claim, set through the Admin SDK with
+	// cmd/admin. It is empty for users without one.
+	Role    string
+	Claims  map[string]interface{}
+	Expires time.Time
+	// AuthTime is when the user signed in. Tokens refreshed from that
+	// sign-in keep it, so together with the uid it identifies the session.
+	AuthTime  time.Time
+	SessionID string
+}
+
+// RoleAdmin is the role claim of administrators
+const RoleAdmin = "admin"
+
+// IsAdmin reports whether the user has the admin role claim
+func (p *Principal) IsAdmin() bool {
+	return p.Role == RoleAdmin
+}
+
+type principalKey struct{}
+
+// WithPrincipal stores p in the context
+func WithPrincipal(ctx context.Context, p *Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
+
+// FromContext returns the principal of an authenticated request
+func FromContext(ctx context.Context) (*Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(*Principal)
+	return p, ok && p != nil
+}
+
+// UID returns the uid of the authenticated user
+func UID(ctx context.Context) (string, bool) {
+	p, ok := FromContext(ctx)
+	if !ok || p.UID == "" {
+		return "", false
+	}
+	return p.UID, true
+}
+
+// View returns the view of the profile of uid the user of the request may
+// see: their own, everything as an admin, or the public fields
+func View(ctx context.Context, uid string) models.View {
+	p, ok := FromContext(ctx)
+	switch {
+	case !ok:
+		return models.ViewPublic
+	case p.IsAdmin():
+		return models.ViewAdmin
+	case p.UID != "" && p.UID == uid:
+		return models.ViewSelf
+	}
+	return models.ViewPublic
+}
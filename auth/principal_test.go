@@ -0,0 +1,72 @@
+package auth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	firebaseauth "firebase.google.com/go/auth"
+)
+
+func TestPrincipalOf(t *testing.T) {
+	tests := []struct {
+		name      string
+		claims    map[string]interface{}
+		wantRole  string
+		wantAdmin bool
+	}{
+		{"admin", map[string]interface{}{"email": "budi@ui.ac.id", "role": "admin"}, "admin", true},
+		{"other role", map[string]interface{}{"email": "budi@ui.ac.id", "role": "seller"}, "seller", false},
+		{"no role", map[string]interface{}{"email": "budi@ui.ac.id"}, "", false},
+		{"role of another type", map[string]interface{}{"email": "budi@ui.ac.id", "role": true}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := principalOf(&firebaseauth.Token{UID: "u1", Expires: 1741608000, AuthTime: 1741600000, Claims: tt.claims})
+			if p.UID != "u1" || p.Email != "budi@ui.ac.id" || p.Role != tt.wantRole || p.IsAdmin() != tt.wantAdmin {
+				t.Errorf("principal = %+v, want role %q and admin %t", p, tt.wantRole, tt.wantAdmin)
+			}
+			if p.Expires.Unix() != 1741608000 || p.AuthTime.Unix() != 1741600000 {
+				t.Errorf("expires %s, auth time %s", p.Expires, p.AuthTime)
+			}
+		})
+	}
+}
+
+func TestSessionID(t *testing.T) {
+	id := sessionID("u1", 1741600000)
+	if len(id) != 20 {
+		t.Errorf("sessionID = %q, want 20 hex digits", id)
+	}
+	if sessionID("u1", 1741600000) != id {
+		t.Error("sessionID is not stable")
+	}
+	if sessionID("u1", 1741600001) == id || sessionID("u2", 1741600000) == id {
+		t.Error("sessionID is shared by another sign-in")
+	}
+}
+
+func TestBearerToken(t *testing.T) {
+	tests := []struct {
+		header  string
+		want    string
+		wantErr bool
+	}{
+		{"Bearer abc.def", "abc.def", false},
+		{"Bearer  abc.def ", "abc.def", false},
+		{"bearer abc.def", "", true},
+		{"Basic dXNlcjpwYXNz", "", true},
+		{"Bearer ", "", true},
+		{"", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.Header.Set("Authorization", tt.header)
+			got, err := BearerToken(r)
+			if got != tt.want || (err != nil) != tt.wantErr {
+				t.Errorf("BearerToken = %q, %v, want %q, error %t", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
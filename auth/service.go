@@ -0,0 +1,209 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang-firebase-backend/logging"
+
+	firebase "firebase.google.com/go"
+	firebaseauth "firebase.google.com/go/auth"
+)
+
+// DefaultCacheSize bounds the number of verified tokens kept in memory
+const DefaultCacheSize = 10000
+
+var (
+	// ErrNoToken is returned for requests without a bearer token
+	ErrNoToken = errors.New("missing bearer token")
+	// ErrSessionSignedOut is returned for tokens of a signed out session
+	ErrSessionSignedOut = errors.New("session was signed out")
+	// ErrSessionUnavailable is returned by VerifyAndCheckRevoked when the
+	// session of a valid token could not be checked
+	ErrSessionUnavailable = errors.New("session could not be checked")
+)
+
+// SessionCheck returns ErrSessionSignedOut when the session of p was
+// signed out. Other errors are logged and let the request through, except
+// with VerifyAndCheckRevoked.
+type SessionCheck func(ctx context.Context, p *Principal) error
+
+// Service verifies ID tokens with a single Firebase Auth client and caches
+// the result until the token expires
+type Service struct {
+	client       *firebaseauth.Client
+	cache        *cache
+	checkSession SessionCheck
+}
+
+// NewService creates the Auth client of app
+func NewService(ctx context.Context, app *firebase.App, cacheSize int) (*Service, error) {
+	client, err := app.Auth(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if cacheSize <= 0 {
+		cacheSize = DefaultCacheSize
+	}
+	return &Service{client: client, cache: newCache(cacheSize)}, nil
+}
+
+// Client returns the Firebase Auth client
+func (s *Service) Client() *firebaseauth.Client {
+	return s.client
+}
+
+// SetSessionCheck makes the service reject tokens of signed out sessions.
+// The check runs whenever a token is verified with Firebase, not when it
+// is answered from the cache.
+func (s *Service) SetSessionCheck(check SessionCheck) {
+	s.checkSession = check
+}
+
+// Verify returns the principal of idToken. Tokens verified before are
+// answered from the cache, so a revoked session stays usable until its
+// token expires; use VerifyAndCheckRevoked where that matters.
+func (s *Service) Verify(ctx context.Context, idToken string) (*Principal, error) {
+	key := tokenKey(idToken)
+	if p, ok := s.cache.get(key, time.Now()); ok {
+		return p, nil
+	}
+
+	token, err := s.client.VerifyIDToken(ctx, idToken)
+	if err != nil {
+		return nil, err
+	}
+	return s.accept(ctx, key, principalOf(token), false)
+}
+
+// VerifyAndCheckRevoked verifies idToken and asks Firebase whether the
+// user's sessions were revoked or the user disabled since it was issued.
+// It fails closed: a token whose session cannot be checked is rejected with
+// ErrSessionUnavailable.
+func (s *Service) VerifyAndCheckRevoked(ctx context.Context, idToken string) (*Principal, error) {
+	key := tokenKey(idToken)
+	token, err := s.client.VerifyIDTokenAndCheckRevoked(ctx, idToken)
+	if err != nil {
+		s.cache.remove(key)
+		return nil, err
+	}
+	return s.accept(ctx, key, principalOf(token), true)
+}
+
+// accept runs the session check on a verified principal and caches it. When
+// the check fails, strict rejects the principal; otherwise it is let through
+// without caching, so that the next request checks again.
+func (s *Service) accept(ctx context.Context, key [32]byte, p *Principal, strict bool) (*Principal, error) {
+	if s.checkSession != nil {
+		if err := s.checkSession(ctx, p); errors.Is(err, ErrSessionSignedOut) {
+			s.cache.remove(key)
+			return nil, err
+		} else if err != nil && strict {
+			return nil, fmt.Errorf("%w: %v", ErrSessionUnavailable, err)
+		} else if err != nil {
+			logging.From(ctx).Warn("failed to check session", "error", err)
+			return p, nil
+		}
+	}
+	s.cache.add(key, p)
+	return p, nil
+}
+
+// Revoke revokes the refresh tokens of uid and forgets its cached tokens
+func (s *Service) Revoke(ctx context.Context, uid string) error {
+	if err := s.client.RevokeRefreshTokens(ctx, uid); err != nil {
+		return err
+	}
+	s.cache.removeUID(uid)
+	return nil
+}
+
+// SetRole sets the "role" custom claim of uid, keeping its other claims; an
+// empty role removes it. The refresh tokens are revoked so that the user
+// gets a token with the new role when the current one expires.
+func (s *Service) SetRole(ctx context.Context, uid, role string) error {
+	user, err := s.client.GetUser(ctx, uid)
+	if err != nil {
+		return err
+	}
+	claims := map[string]interface{}{}
+	for name, value := range user.CustomClaims {
+		claims[name] = value
+	}
+	if role == "" {
+		delete(claims, "role")
+	} else {
+		claims["role"] = role
+	}
+	if err := s.client.SetCustomUserClaims(ctx, uid, claims); err != nil {
+		return err
+	}
+	return s.Revoke(ctx, uid)
+}
+
+// ForgetSession drops the cached tokens of a session that was signed out.
+// Other instances keep theirs until the tokens expire.
+func (s *Service) ForgetSession(uid, sessionID string) {
+	s.cache.removeSession(uid, sessionID)
+}
+
+func principalOf(token *firebaseauth.Token) *Principal {
+	p := &Principal{
+		UID:      token.UID,
+		Claims:   token.Claims,
+		Expires:  time.Unix(token.Expires, 0),
+		AuthTime: time.Unix(token.AuthTime, 0),
+	}
+	p.Email, _ = token.Claims["email"].(string)
+	p.Role, _ = token.Claims["role"].(string)
+	p.SessionID = sessionID(token.UID, token.AuthTime)
+	return p
+}
+
+// sessionID derives a stable identifier from the uid and sign-in time
+func sessionID(uid string, authTime int64) string {
+	sum := sha256.Sum256([]byte(uid + ":" + strconv.FormatInt(authTime, 10)))
+	return hex.EncodeToString(sum[:10])
+}
+
+// tokenKey keeps raw tokens out of the cache
+func tokenKey(idToken string) [32]byte {
+	return sha256.Sum256([]byte(idToken))
+}
+
+// BearerToken returns the token of the Authorization header
+func BearerToken(r *http.Request) (string, error) {
+	header := r.Header.Get("Authorization")
+	token, ok := strings.CutPrefix(header, "Bearer ")
+	if !ok || strings.TrimSpace(token) == "" {
+		return "", ErrNoToken
+	}
+	return strings.TrimSpace(token), nil
+}
+
+var (
+	defaultMu sync.RWMutex
+	service   *Service
+)
+
+// SetDefault sets the service used by the authentication middleware
+func SetDefault(s *Service) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	service = s
+}
+
+// Default returns the service set with SetDefault, or nil
+func Default() *Service {
+	defaultMu.RLock()
+	defer defaultMu.RUnlock()
+	return service
+}
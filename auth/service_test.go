@@ -0,0 +1,64 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestAccept(t *testing.T) {
+	unavailable := errors.New("database unavailable")
+
+	tests := []struct {
+		name       string
+		check      SessionCheck
+		strict     bool
+		wantErr    error
+		wantCached bool
+	}{
+		{"no session check", nil, false, nil, true},
+		{"active session", func(context.Context, *Principal) error { return nil }, false, nil, true},
+		{"active session, strict", func(context.Context, *Principal) error { return nil }, true, nil, true},
+		{"signed out", func(context.Context, *Principal) error { return ErrSessionSignedOut }, false, ErrSessionSignedOut, false},
+		{"signed out, strict", func(context.Context, *Principal) error { return ErrSessionSignedOut }, true, ErrSessionSignedOut, false},
+		// Plain routes stay up while the registry cannot be reached
+		{"check failed", func(context.Context, *Principal) error { return unavailable }, false, nil, false},
+		// Sensitive routes do not let a possibly signed out session through
+		{"check failed, strict", func(context.Context, *Principal) error { return unavailable }, true, ErrSessionUnavailable, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Service{cache: newCache(10), checkSession: tt.check}
+			key := tokenKey("token")
+			p := principal("u1", "s1")
+
+			got, err := s.accept(context.Background(), key, p, tt.strict)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("accept = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && got != p {
+				t.Errorf("accept = %+v, want the principal", got)
+			}
+			if tt.wantErr != nil && got != nil {
+				t.Errorf("accept = %+v with an error", got)
+			}
+			if _, cached := s.cache.get(key, now); cached != tt.wantCached {
+				t.Errorf("cached = %t, want %t", cached, tt.wantCached)
+			}
+		})
+	}
+}
+
+func TestAcceptForgetsSignedOutTokens(t *testing.T) {
+	s := &Service{cache: newCache(10)}
+	key := tokenKey("token")
+	s.cache.add(key, principal("u1", "s1"))
+
+	s.SetSessionCheck(func(context.Context, *Principal) error { return ErrSessionSignedOut })
+	if _, err := s.accept(context.Background(), key, principal("u1", "s1"), false); !errors.Is(err, ErrSessionSignedOut) {
+		t.Fatalf("accept = %v, want ErrSessionSignedOut", err)
+	}
+	if _, ok := s.cache.get(key, now); ok {
+		t.Error("the token of the signed out session stayed cached")
+	}
+}
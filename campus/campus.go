@@ -0,0 +1,465 @@
+// Package campus verifies that seller applicants own an email address of a
+// campus. A one-time code is emailed to an address whose domain is on the
+// configured allow-list or registered to an organization; confirming it
+// marks the address as verified. Codes expire, allow a limited number of
+// attempts, and cannot be resent too often. A user who enters too many wrong
+// codes within a day is locked out for the rest of it, however many codes
+// they request.
+package campus
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"sync"
+	"time"
+
+	"firebase.google.com/go/db"
+
+	"golang-firebase-backend/config"
+	"golang-firebase-backend/jobs"
+	"golang-firebase-backend/models"
+	"golang-firebase-backend/organizations"
+)
+
+var (
+	ErrDomainNotAllowed = errors.New("email domain is not a recognised campus")
+	ErrNoCode           = errors.New("no verification code was requested")
+	ErrCodeExpired      = errors.New("verification code has expired")
+	ErrTooManyAttempts  = errors.New("too many wrong verification codes")
+	ErrWrongCode        = errors.New("wrong verification code")
+	ErrEmailTaken       = errors.New("email is verified by another account")
+	ErrNotVerified      = errors.New("campus email is not verified")
+)
+
+// ThrottledError is returned when a new code is requested too soon
+type ThrottledError struct {
+	RetryAfter time.Duration
+}
+
+func (e *ThrottledError) Error() string {
+	return fmt.Sprintf("verification code was sent recently; retry in %s", e.RetryAfter.Round(time.Second))
+}
+
+// LockedError is returned when the user entered more wrong codes within a
+// day than allowed. It is an ErrTooManyAttempts.
+type LockedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("too many wrong verification codes today; retry in %s", e.RetryAfter.Round(time.Minute))
+}
+
+func (e *LockedError) Is(target error) bool {
+	return target == ErrTooManyAttempts
+}
+
+const (
+	codeDigits = 6
+	// failureWindow is how long wrong codes count towards MaxDailyAttempts
+	failureWindow = 24 * time.Hour
+)
+
+var (
+	settingsMu sync.RWMutex
+	settings   = defaultSettings()
+)
+
+func defaultSettings() config.CampusConfig {
+	cfg := config.Defaults(config.Development).Campus
+	cfg.Domains = config.DefaultCampusDomains()
+	return cfg
+}
+
+// Setup replaces the allowed domains and the code settings
+func Setup(cfg config.CampusConfig) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	settings = cfg
+}
+
+func current() config.CampusConfig {
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+	return settings
+}
+
+func verificationPath(uid string) string {
+	return "campusVerifications/" + uid
+}
+
+// emailPath indexes verified addresses so that one address cannot verify
+// several accounts
+func emailPath(email string) string {
+	sum := sha256.Sum256([]byte(email))
+	return "campusEmails/" + hex.EncodeToString(sum[:])
+}
+
+// record is the stored state of a verification. Attempts counts the wrong
+// guesses of the current code, Failures those of the user since
+// FailuresSince across codes. PreviousEmail is an address the user verified
+// before, whose claim is released once the new address is verified.
+type record struct {
+	Email          string     `json:"email"`
+	Organization   string     `json:"organization,omitempty"`
+	OrganizationID string     `json:"organization_id,omitempty"`
+	CodeHash       string     `json:"code_hash,omitempty"`
+	ExpiresAt      time.Time  `json:"expires_at"`
+	Attempts       int        `json:"attempts"`
+	Failures       int        `json:"failures,omitempty"`
+	FailuresSince  time.Time  `json:"failures_since"`
+	SentAt         time.Time  `json:"sent_at"`
+	VerifiedAt     *time.Time `json:"verified_at,omitempty"`
+	PreviousEmail  string     `json:"previous_email,omitempty"`
+}
+
+// failures returns the wrong codes entered within the window ending at now
+func (r *record) failures(now time.Time) int {
+	if now.Sub(r.FailuresSince) >= failureWindow {
+		return 0
+	}
+	return r.Failures
+}
+
+// fail counts a wrong code, starting a new window once the last one is over
+func (r *record) fail(now time.Time) {
+	r.Attempts++
+	if now.Sub(r.FailuresSince) >= failureWindow {
+		r.Failures, r.FailuresSince = 0, now
+	}
+	r.Failures++
+}
+
+// locked returns a *LockedError while the user may not try codes
+func (r *record) locked(cfg config.CampusConfig, now time.Time) error {
+	if r.failures(now) < cfg.MaxDailyAttempts {
+		return nil
+	}
+	return &LockedError{RetryAfter: r.FailuresSince.Add(failureWindow).Sub(now)}
+}
+
+func (r *record) view(cfg config.CampusConfig, now time.Time) *models.CampusVerification {
+	v := &models.CampusVerification{
+		Email:          r.Email,
+		Organization:   r.Organization,
+		OrganizationID: r.OrganizationID,
+		Verified:       r.VerifiedAt != nil,
+		VerifiedAt:     r.VerifiedAt,
+	}
+	if r.CodeHash != "" {
+		expiresAt := r.ExpiresAt
+		resendAfter := r.SentAt.Add(cfg.ResendInterval)
+		v.ExpiresAt = &expiresAt
+		v.ResendAfter = &resendAfter
+		v.AttemptsLeft = max(min(cfg.MaxAttempts-r.Attempts, cfg.MaxDailyAttempts-r.failures(now)), 0)
+	}
+	return v
+}
+
+// Organization returns the organization the domain of email is mapped to.
+// The most specific matching domain wins; ok is false when no domain of the
+// allow-list matches.
+func Organization(email string) (organization string, ok bool) {
+	_, domain, found := strings.Cut(normalize(email), "@")
+	if !found || domain == "" {
+		return "", false
+	}
+	domains := current().Domains
+	if organization, ok := domains[domain]; ok {
+		return organization, true
+	}
+	for rest := domain; strings.Contains(rest, "."); {
+		_, rest, _ = strings.Cut(rest, ".")
+		if organization, ok := domains["*."+rest]; ok {
+			return organization, true
+		}
+	}
+	return "", false
+}
+
+func normalize(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// SendCode emails a new verification code to a campus address of the user,
+// replacing any earlier verification. Wrong codes entered before still count
+// towards the daily limit.
+func SendCode(ctx context.Context, uid, email string) (*models.CampusVerification, error) {
+	cfg := current()
+	email = normalize(email)
+	organization, allowed := Organization(email)
+
+	// Domains registered to an organization are allowed as well
+	var organizationID string
+	if org, ok, err := organizations.ByEmail(ctx, email); err != nil {
+		return nil, err
+	} else if ok {
+		allowed = true
+		if organization == "" || organization == org.Name {
+			organization, organizationID = org.Name, org.ID
+		}
+	}
+	if !allowed {
+		return nil, ErrDomainNotAllowed
+	}
+
+	client, err := config.Database(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var owner string
+	if err := client.NewRef(emailPath(email)).Get(ctx, &owner); err != nil {
+		return nil, fmt.Errorf("error fetching campus email: %v", err)
+	}
+	if owner != "" && owner != uid {
+		return nil, ErrEmailTaken
+	}
+
+	code, err := newCode()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var saved record
+	err = client.NewRef(verificationPath(uid)).Transaction(ctx, func(node db.TransactionNode) (interface{}, error) {
+		var existing record
+		if err := node.Unmarshal(&existing); err != nil {
+			return nil, err
+		}
+		if err := existing.locked(cfg, now); err != nil {
+			return nil, err
+		}
+		if wait := existing.SentAt.Add(cfg.ResendInterval).Sub(now); wait > 0 {
+			return nil, &ThrottledError{RetryAfter: wait}
+		}
+		saved = record{
+			Email:          email,
+			Organization:   organization,
+			OrganizationID: organizationID,
+			CodeHash:       hashCode(uid, code),
+			ExpiresAt:      now.Add(cfg.CodeTTL),
+			Failures:       existing.Failures,
+			FailuresSince:  existing.FailuresSince,
+			SentAt:         now,
+			PreviousEmail:  existing.PreviousEmail,
+		}
+		if existing.VerifiedAt != nil {
+			saved.PreviousEmail = existing.Email
+		}
+		return saved, nil
+	})
+	if err != nil {
+		var (
+			throttled *ThrottledError
+			locked    *LockedError
+		)
+		if errors.As(err, &throttled) {
+			return nil, throttled
+		}
+		if errors.As(err, &locked) {
+			return nil, locked
+		}
+		return nil, fmt.Errorf("error saving verification code: %v", err)
+	}
+
+	_, err = jobs.Enqueue(ctx, jobs.TypeSendEmail, jobs.EmailPayload{
+		To:      email,
+		Subject: "Your SkillX verification code",
+		Body: fmt.Sprintf("<p>Your verification code is <strong>%s</strong>.</p>"+
+			"<p>It expires in %d minutes. If you did not apply to sell on SkillX, ignore this email.</p>",
+			code, int(cfg.CodeTTL.Minutes())),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error sending verification code: %v", err)
+	}
+	return saved.view(cfg, now), nil
+}
+
+// Confirm checks a verification code of the user. Every wrong code counts
+// as an attempt; a correct one verifies the email and releases the address
+// the user verified before.
+func Confirm(ctx context.Context, uid, code string) (*models.CampusVerification, error) {
+	cfg := current()
+	client, err := config.Database(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var (
+		saved   record
+		outcome error
+	)
+	err = client.NewRef(verificationPath(uid)).Transaction(ctx, func(node db.TransactionNode) (interface{}, error) {
+		outcome = nil
+		var r record
+		if err := node.Unmarshal(&r); err != nil {
+			return nil, err
+		}
+		switch {
+		case r.VerifiedAt != nil:
+			// Confirming twice is not an error
+		case r.CodeHash == "":
+			return nil, ErrNoCode
+		case r.locked(cfg, now) != nil:
+			return nil, r.locked(cfg, now)
+		case r.Attempts >= cfg.MaxAttempts:
+			return nil, ErrTooManyAttempts
+		case now.After(r.ExpiresAt):
+			return nil, ErrCodeExpired
+		case subtle.ConstantTimeCompare([]byte(r.CodeHash), []byte(hashCode(uid, code))) != 1:
+			r.fail(now)
+			outcome = ErrWrongCode
+		default:
+			r.CodeHash = ""
+			r.VerifiedAt = &now
+		}
+		saved = r
+		return r, nil
+	})
+	if err != nil {
+		var locked *LockedError
+		if errors.As(err, &locked) {
+			return nil, locked
+		}
+		for _, known := range []error{ErrNoCode, ErrTooManyAttempts, ErrCodeExpired} {
+			if errors.Is(err, known) {
+				return nil, known
+			}
+		}
+		return nil, fmt.Errorf("error checking verification code: %v", err)
+	}
+	if outcome != nil {
+		if err := saved.locked(cfg, now); err != nil {
+			return saved.view(cfg, now), err
+		}
+		if saved.Attempts >= cfg.MaxAttempts {
+			return saved.view(cfg, now), ErrTooManyAttempts
+		}
+		return saved.view(cfg, now), outcome
+	}
+
+	if err := claim(ctx, client, uid, saved.Email); err != nil {
+		if errors.Is(err, ErrEmailTaken) {
+			// Another account verified the address in the meantime
+			client.NewRef(verificationPath(uid)).Delete(ctx)
+		}
+		return nil, err
+	}
+	if saved.PreviousEmail != "" {
+		if saved.PreviousEmail != saved.Email {
+			if err := release(ctx, client, uid, saved.PreviousEmail); err != nil {
+				return nil, err
+			}
+		}
+		if err := client.NewRef(verificationPath(uid) + "/previous_email").Delete(ctx); err != nil {
+			return nil, fmt.Errorf("error saving campus verification: %v", err)
+		}
+	}
+	return saved.view(cfg, now), nil
+}
+
+// claim records uid as the owner of a verified email
+func claim(ctx context.Context, client *db.Client, uid, email string) error {
+	err := client.NewRef(emailPath(email)).Transaction(ctx, func(node db.TransactionNode) (interface{}, error) {
+		var owner string
+		if err := node.Unmarshal(&owner); err != nil {
+			return nil, err
+		}
+		if owner != "" && owner != uid {
+			return nil, ErrEmailTaken
+		}
+		return uid, nil
+	})
+	if errors.Is(err, ErrEmailTaken) {
+		return ErrEmailTaken
+	}
+	if err != nil {
+		return fmt.Errorf("error saving campus email: %v", err)
+	}
+	return nil
+}
+
+// release gives up the claim of uid on email, so that another account can
+// verify it. A claim of another account is left alone.
+func release(ctx context.Context, client *db.Client, uid, email string) error {
+	err := client.NewRef(emailPath(email)).Transaction(ctx, func(node db.TransactionNode) (interface{}, error) {
+		var owner string
+		if err := node.Unmarshal(&owner); err != nil {
+			return nil, err
+		}
+		if owner != uid {
+			return owner, nil
+		}
+		return nil, nil
+	})
+	if err != nil {
+		return fmt.Errorf("error releasing campus email: %v", err)
+	}
+	return nil
+}
+
+// Status returns the verification of the user, or nil when none was started
+func Status(ctx context.Context, uid string) (*models.CampusVerification, error) {
+	r, err := load(ctx, uid)
+	if err != nil || r == nil {
+		return nil, err
+	}
+	return r.view(current(), time.Now()), nil
+}
+
+// Verified returns the verified campus email of the user, or ErrNotVerified
+func Verified(ctx context.Context, uid string) (*models.CampusVerification, error) {
+	r, err := load(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil || r.VerifiedAt == nil {
+		return nil, ErrNotVerified
+	}
+	return r.view(current(), time.Now()), nil
+}
+
+func load(ctx context.Context, uid string) (*record, error) {
+	client, err := config.Database(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var r record
+	if err := client.NewRef(verificationPath(uid)).Get(ctx, &r); err != nil {
+		return nil, fmt.Errorf("error fetching campus verification: %v", err)
+	}
+	if r.Email == "" {
+		return nil, nil
+	}
+	return &r, nil
+}
+
+// newCode returns a random numeric code
+func newCode() (string, error) {
+	limit := big.NewInt(1)
+	for i := 0; i < codeDigits; i++ {
+		limit.Mul(limit, big.NewInt(10))
+	}
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return "", fmt.Errorf("error generating verification code: %v", err)
+	}
+	return fmt.Sprintf("%0*d", codeDigits, n), nil
+}
+
+// hashCode keeps codes out of the database; the uid ties a code to its user
+func hashCode(uid, code string) string {
+	sum := sha256.Sum256([]byte(uid + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
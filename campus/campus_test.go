@@ -0,0 +1,130 @@
+package campus
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"golang-firebase-backend/config"
+)
+
+var testSettings = config.CampusConfig{
+	Domains:          map[string]string{"ui.ac.id": "Universitas Indonesia", "*.ac.id": ""},
+	CodeTTL:          10 * time.Minute,
+	MaxAttempts:      5,
+	ResendInterval:   time.Minute,
+	MaxDailyAttempts: 8,
+}
+
+func TestFail(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name         string
+		stored       record
+		wantFailures int
+		wantSince    time.Time
+	}{
+		{"first failure starts the window", record{}, 1, now},
+		{"failures within the window add up", record{Failures: 3, FailuresSince: now.Add(-time.Hour)}, 4, now.Add(-time.Hour)},
+		{"a new window after a day", record{Failures: 7, FailuresSince: now.Add(-failureWindow)}, 1, now},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := tt.stored
+			r.fail(now)
+			if r.Attempts != tt.stored.Attempts+1 {
+				t.Errorf("Attempts = %d, want %d", r.Attempts, tt.stored.Attempts+1)
+			}
+			if r.Failures != tt.wantFailures || !r.FailuresSince.Equal(tt.wantSince) {
+				t.Errorf("failures = %d since %s, want %d since %s", r.Failures, r.FailuresSince, tt.wantFailures, tt.wantSince)
+			}
+		})
+	}
+}
+
+func TestLocked(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		stored    record
+		wantRetry time.Duration // 0 when not locked
+	}{
+		{"no failures", record{}, 0},
+		{"below the cap", record{Failures: 7, FailuresSince: now.Add(-time.Hour)}, 0},
+		{"at the cap", record{Failures: 8, FailuresSince: now.Add(-time.Hour)}, 23 * time.Hour},
+		{"a new code does not unlock", record{Failures: 8, FailuresSince: now.Add(-time.Hour), CodeHash: "h", Attempts: 0}, 23 * time.Hour},
+		{"unlocked after a day", record{Failures: 8, FailuresSince: now.Add(-failureWindow)}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.stored.locked(testSettings, now)
+			if tt.wantRetry == 0 {
+				if err != nil {
+					t.Errorf("locked = %v, want nil", err)
+				}
+				return
+			}
+			var locked *LockedError
+			if !errors.As(err, &locked) || locked.RetryAfter != tt.wantRetry {
+				t.Fatalf("locked = %v, want retry in %s", err, tt.wantRetry)
+			}
+			if !errors.Is(err, ErrTooManyAttempts) {
+				t.Error("LockedError is not ErrTooManyAttempts")
+			}
+		})
+	}
+}
+
+func TestViewAttemptsLeft(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name   string
+		stored record
+		want   int
+	}{
+		{"no code", record{}, 0},
+		{"fresh code", record{CodeHash: "h"}, 5},
+		{"wrong guesses of the code", record{CodeHash: "h", Attempts: 2, Failures: 2, FailuresSince: now}, 3},
+		{"limited by the daily cap", record{CodeHash: "h", Failures: 6, FailuresSince: now}, 2},
+		{"daily cap reached", record{CodeHash: "h", Failures: 9, FailuresSince: now}, 0},
+		{"old failures do not count", record{CodeHash: "h", Failures: 8, FailuresSince: now.Add(-failureWindow)}, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.stored.view(testSettings, now).AttemptsLeft; got != tt.want {
+				t.Errorf("AttemptsLeft = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestOrganization(t *testing.T) {
+	Setup(testSettings)
+	defer Setup(defaultSettings())
+
+	tests := []struct {
+		email  string
+		want   string
+		wantOK bool
+	}{
+		{"budi@ui.ac.id", "Universitas Indonesia", true},
+		{" Budi@UI.ac.id ", "Universitas Indonesia", true},
+		{"budi@cs.ui.ac.id", "", true},
+		{"budi@itb.ac.id", "", true},
+		{"budi@ac.id", "", false},
+		{"budi@gmail.com", "", false},
+		{"budi", "", false},
+		{"budi@", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			got, ok := Organization(tt.email)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Organization(%q) = %q, %t, want %q, %t", tt.email, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"golang-firebase-backend/mergepatch"
+	"golang-firebase-backend/models"
+)
+
+type SkillsService struct{ c *Client }
+
+func (s *SkillsService) List(ctx context.Context) ([]models.Skill, error) {
+	var skills []models.Skill
+	err := s.c.get(ctx, "/api/v1/skills", nil, &skills)
+	return skills, err
+}
+
+func (s *SkillsService) Get(ctx context.Context, skillID string) (*models.Skill, error) {
+	var skill models.Skill
+	if err := s.c.get(ctx, "/api/v1/skills/"+id(skillID), nil, &skill); err != nil {
+		return nil, err
+	}
+	return &skill, nil
+}
+
+func (s *SkillsService) Create(ctx context.Context, title string) (models.Skill, error) {
+	return sendData[models.Skill](ctx, s.c, http.MethodPost, "/api/v1/skills", models.Skill{TitleSkills: title})
+}
+
+func (s *SkillsService) Update(ctx context.Context, skillID, title string) error {
+	return s.c.do(ctx, http.MethodPut, "/api/v1/skills/"+id(skillID), nil, models.Skill{TitleSkills: title}, nil)
+}
+
+func (s *SkillsService) Delete(ctx context.Context, skillID string) error {
+	return s.c.do(ctx, http.MethodDelete, "/api/v1/skills/"+id(skillID), nil, nil, nil)
+}
+
+// AddToMe adds a skill to the signed-in user
+func (s *SkillsService) AddToMe(ctx context.Context, uid, skillID string) error {
+	return s.c.do(ctx, http.MethodPost, "/api/v1/users/me/skills", nil, models.UserSkill{UserId: uid, IdSkill: skillID}, nil)
+}
+
+type MajorsService struct{ c *Client }
+
+// MajorDetail is a major with its categories
+type MajorDetail struct {
+	Major      models.Major             `json:"major"`
+	Categories []map[string]interface{} `json:"categories"`
+}
+
+func (s *MajorsService) List(ctx context.Context) ([]models.Major, error) {
+	var majors []models.Major
+	err := s.c.get(ctx, "/api/v1/majors", nil, &majors)
+	return majors, err
+}
+
+func (s *MajorsService) Get(ctx context.Context, majorID string) (*MajorDetail, error) {
+	var detail MajorDetail
+	if err := s.c.get(ctx, "/api/v1/majors/"+id(majorID), nil, &detail); err != nil {
+		return nil, err
+	}
+	return &detail, nil
+}
+
+func (s *MajorsService) Create(ctx context.Context, major models.Major) (models.Major, error) {
+	return sendData[models.Major](ctx, s.c, http.MethodPost, "/api/v1/majors", major)
+}
+
+func (s *MajorsService) Update(ctx context.Context, majorID string, update models.MajorUpdateInput) (map[string]interface{}, error) {
+	return sendData[map[string]interface{}](ctx, s.c, http.MethodPut, "/api/v1/majors/"+id(majorID), update)
+}
+
+func (s *MajorsService) Delete(ctx context.Context, majorID string) error {
+	return s.c.do(ctx, http.MethodDelete, "/api/v1/majors/"+id(majorID), nil, nil, nil)
+}
+
+type OrganizationsService struct{ c *Client }
+
+func (s *OrganizationsService) List(ctx context.Context) ([]models.Organization, error) {
+	return getData[[]models.Organization](ctx, s.c, "/api/v1/organizations", nil)
+}
+
+// Match returns the organizations a typed name may refer to, best first
+func (s *OrganizationsService) Match(ctx context.Context, query string, limit int) ([]models.OrganizationMatch, error) {
+	params := url.Values{"query": {query}}
+	if limit > 0 {
+		params.Set("limit", strconv.Itoa(limit))
+	}
+	return getData[[]models.OrganizationMatch](ctx, s.c, "/api/v1/organizations/match", params)
+}
+
+func (s *OrganizationsService) Get(ctx context.Context, organizationID string) (*models.Organization, error) {
+	org, err := getData[models.Organization](ctx, s.c, "/api/v1/organizations/"+id(organizationID), nil)
+	if err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
+// Create adds an organization to the registry (admin)
+func (s *OrganizationsService) Create(ctx context.Context, input models.OrganizationInput) (models.Organization, error) {
+	return sendData[models.Organization](ctx, s.c, http.MethodPost, "/api/v1/organizations", input)
+}
+
+// Update replaces an organization (admin)
+func (s *OrganizationsService) Update(ctx context.Context, organizationID string, input models.OrganizationInput) (models.Organization, error) {
+	return sendData[models.Organization](ctx, s.c, http.MethodPut, "/api/v1/organizations/"+id(organizationID), input)
+}
+
+// Delete removes an organization from the registry (admin)
+func (s *OrganizationsService) Delete(ctx context.Context, organizationID string) error {
+	return s.c.do(ctx, http.MethodDelete, "/api/v1/organizations/"+id(organizationID), nil, nil, nil)
+}
+
+// Migrate maps free-text organizations onto the registry (admin)
+func (s *OrganizationsService) Migrate(ctx context.Context, input models.OrganizationMigrationInput) (models.OrganizationMigrationReport, error) {
+	return sendData[models.OrganizationMigrationReport](ctx, s.c, http.MethodPost, "/api/v1/admin/organizations/migrate", input)
+}
+
+type ServicesService struct{ c *Client }
+
+func (s *ServicesService) List(ctx context.Context) ([]models.Service, error) {
+	var services []models.Service
+	err := s.c.get(ctx, "/api/v1/services", nil, &services)
+	return services, err
+}
+
+func (s *ServicesService) Get(ctx context.Context, serviceID string) (*models.Service, error) {
+	var service models.Service
+	if err := s.c.get(ctx, "/api/v1/services/"+id(serviceID), nil, &service); err != nil {
+		return nil, err
+	}
+	return &service, nil
+}
+
+func (s *ServicesService) Create(ctx context.Context, service models.ServiceInput) (models.Service, error) {
+	return sendData[models.Service](ctx, s.c, http.MethodPost, "/api/v1/services", service)
+}
+
+func (s *ServicesService) Update(ctx context.Context, serviceID string, update models.ServiceUpdateInput) (map[string]interface{}, error) {
+	return sendData[map[string]interface{}](ctx, s.c, http.MethodPut, "/api/v1/services/"+id(serviceID), update)
+}
+
+func (s *ServicesService) Delete(ctx context.Context, serviceID string) error {
+	return s.c.do(ctx, http.MethodDelete, "/api/v1/services/"+id(serviceID), nil, nil, nil)
+}
+
+type CategoriesService struct{ c *Client }
+
+func (s *CategoriesService) List(ctx context.Context) ([]models.Category, error) {
+	var categories []models.Category
+	err := s.c.get(ctx, "/api/v1/categories", nil, &categories)
+	return categories, err
+}
+
+func (s *CategoriesService) Get(ctx context.Context, categoryID string) (*models.Category, error) {
+	var category models.Category
+	if err := s.c.get(ctx, "/api/v1/categories/"+id(categoryID), nil, &category); err != nil {
+		return nil, err
+	}
+	return &category, nil
+}
+
+func (s *CategoriesService) Create(ctx context.Context, category models.CategoryInput) (models.Category, error) {
+	return sendData[models.Category](ctx, s.c, http.MethodPost, "/api/v1/categories", category)
+}
+
+func (s *CategoriesService) Update(ctx context.Context, categoryID string, update models.CategoryUpdateInput) (models.Category, error) {
+	return sendData[models.Category](ctx, s.c, http.MethodPut, "/api/v1/categories/"+id(categoryID), update)
+}
+
+// Patch applies a JSON merge patch to a category
+func (s *CategoriesService) Patch(ctx context.Context, categoryID string, patch mergepatch.Patch) (models.Category, error) {
+	return sendData[models.Category](ctx, s.c, http.MethodPatch, "/api/v1/categories/"+id(categoryID), patch)
+}
+
+func (s *CategoriesService) Delete(ctx context.Context, categoryID string) error {
+	return s.c.do(ctx, http.MethodDelete, "/api/v1/categories/"+id(categoryID), nil, nil, nil)
+}
@@ -0,0 +1,299 @@
+// Package client is a typed Go client for the SkillX API, used by admin
+// scripts and load generators instead of hand-written requests.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/mergepatch"
+)
+
+// Error is returned for every response in the error envelope. Compare Code
+// with the apierror.Code* constants.
+type Error = apierror.Error
+
+// TokenSource returns the Firebase ID token sent with each request
+type TokenSource func(ctx context.Context) (string, error)
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithHTTPClient replaces http.DefaultClient
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *Client) { c.httpClient = httpClient }
+}
+
+// WithToken sends a fixed Firebase ID token
+func WithToken(token string) Option {
+	return WithTokenSource(func(context.Context) (string, error) { return token, nil })
+}
+
+// WithTokenSource fetches the Firebase ID token before each request, so
+// long-running tools can refresh it
+func WithTokenSource(source TokenSource) Option {
+	return func(c *Client) { c.tokenSource = source }
+}
+
+// WithRetries sets how many times a request failing with a 5xx or 429 status
+// or a network error is retried, and the delay before the first retry. The
+// delay doubles with every retry and is at least the Retry-After the server
+// asks for.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.backoff = backoff
+	}
+}
+
+type (
+	ifMatchKey struct{}
+	etagKey    struct{}
+)
+
+// IfMatch makes writes sent with the returned context conditional: they fail
+// with a 412 *Error when the resource no longer has the given ETag
+func IfMatch(ctx context.Context, etag string) context.Context {
+	return context.WithValue(ctx, ifMatchKey{}, etag)
+}
+
+// CaptureETag stores the ETag of responses to requests sent with the
+// returned context in dst
+func CaptureETag(ctx context.Context, dst *string) context.Context {
+	return context.WithValue(ctx, etagKey{}, dst)
+}
+
+// Client calls the /api/v1 endpoints
+type Client struct {
+	baseURL     string
+	httpClient  *http.Client
+	tokenSource TokenSource
+	retries     int
+	backoff     time.Duration
+
+	Auth          *AuthService
+	Users         *UsersService
+	Sessions      *SessionsService
+	Sellers       *SellersService
+	Campus        *CampusService
+	IDCards       *IDCardsService
+	Alumni        *AlumniService
+	Search        *SearchService
+	Messages      *MessagesService
+	Notifications *NotificationsService
+	Skills        *SkillsService
+	Portfolios    *PortfoliosService
+	Majors        *MajorsService
+	Organizations *OrganizationsService
+	Services      *ServicesService
+	Categories    *CategoriesService
+	Products      *ProductsService
+	Transactions  *TransactionsService
+	Jobs          *JobsService
+}
+
+// New creates a client for the server at baseURL, e.g. "http://localhost:8080"
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		retries:    2,
+		backoff:    200 * time.Millisecond,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	c.Auth = &AuthService{c}
+	c.Users = &UsersService{c}
+	c.Sessions = &SessionsService{c}
+	c.Sellers = &SellersService{c}
+	c.Campus = &CampusService{c}
+	c.IDCards = &IDCardsService{c}
+	c.Alumni = &AlumniService{c}
+	c.Search = &SearchService{c}
+	c.Messages = &MessagesService{c}
+	c.Notifications = &NotificationsService{c}
+	c.Skills = &SkillsService{c}
+	c.Portfolios = &PortfoliosService{c}
+	c.Majors = &MajorsService{c}
+	c.Organizations = &OrganizationsService{c}
+	c.Services = &ServicesService{c}
+	c.Categories = &CategoriesService{c}
+	c.Products = &ProductsService{c}
+	c.Transactions = &TransactionsService{c}
+	c.Jobs = &JobsService{c}
+	return c
+}
+
+// envelope is the {"success", "data", "message"} shape most endpoints answer with
+type envelope[T any] struct {
+	Data    T      `json:"data"`
+	Message string `json:"message"`
+}
+
+// rawBody is sent as it is instead of as JSON, e.g. an uploaded photo
+type rawBody struct {
+	contentType string
+	data        []byte
+}
+
+// do sends a request and decodes a successful response into out, which may be nil
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body, out interface{}) error {
+	var payload []byte
+	contentType := "application/json"
+	if _, ok := body.(mergepatch.Patch); ok {
+		contentType = mergepatch.ContentType
+	}
+	if raw, ok := body.(rawBody); ok {
+		payload, contentType = raw.data, raw.contentType
+	} else if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("error encoding request body: %v", err)
+		}
+	}
+
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	delay := c.backoff
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, method, target, contentType, payload)
+		if err == nil && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			if dst, ok := ctx.Value(etagKey{}).(*string); ok {
+				*dst = resp.Header.Get("ETag")
+			}
+			return decode(resp, out)
+		}
+		if attempt >= c.retries || !retryable(method, resp) {
+			if err != nil {
+				return err
+			}
+			return decode(resp, out)
+		}
+		wait := delay
+		if resp != nil {
+			if retryAfter := retryAfter(resp); retryAfter > wait {
+				wait = retryAfter
+			}
+			resp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, target, contentType string, payload []byte) (*http.Response, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if etag, ok := ctx.Value(ifMatchKey{}).(string); ok && etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
+
+	if c.tokenSource != nil {
+		token, err := c.tokenSource(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("error fetching ID token: %v", err)
+		}
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+	}
+
+	return c.httpClient.Do(req)
+}
+
+// retryable reports whether a failed attempt may be repeated. POSTs are only
+// retried when the server says it did not handle them.
+func retryable(method string, resp *http.Response) bool {
+	switch method {
+	case http.MethodGet, http.MethodPut, http.MethodDelete, http.MethodPatch:
+		return true
+	}
+	return resp != nil && (resp.StatusCode == http.StatusServiceUnavailable || resp.StatusCode == http.StatusTooManyRequests)
+}
+
+// retryAfter returns the delay the Retry-After header of resp asks for, in
+// seconds as the server sends it
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func decode(resp *http.Response, out interface{}) error {
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		var failure struct {
+			Error *Error `json:"error"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&failure); err != nil || failure.Error == nil {
+			failure.Error = apierror.FromStatus(resp.StatusCode, http.StatusText(resp.StatusCode))
+		}
+		failure.Error.Status = resp.StatusCode
+		if failure.Error.RequestID == "" {
+			failure.Error.RequestID = resp.Header.Get(apierror.RequestIDHeader)
+		}
+		return failure.Error
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("error decoding response: %v", err)
+	}
+	return nil
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values, out interface{}) error {
+	return c.do(ctx, http.MethodGet, path, query, nil, out)
+}
+
+// getData fetches a response in the standard envelope and returns its data
+func getData[T any](ctx context.Context, c *Client, path string, query url.Values) (T, error) {
+	var resp envelope[T]
+	err := c.get(ctx, path, query, &resp)
+	return resp.Data, err
+}
+
+// sendData sends body and returns the data of the enveloped response
+func sendData[T any](ctx context.Context, c *Client, method, path string, body interface{}) (T, error) {
+	var resp envelope[T]
+	err := c.do(ctx, method, path, nil, body, &resp)
+	return resp.Data, err
+}
+
+func id(value string) string {
+	return url.PathEscape(value)
+}
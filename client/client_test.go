@@ -0,0 +1,285 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/logging"
+	"golang-firebase-backend/routes"
+)
+
+// server runs the route table behind a handler that answers the first
+// requests with the queued faults and records every request it sees
+type server struct {
+	*httptest.Server
+
+	mu       sync.Mutex
+	faults   []http.HandlerFunc
+	requests []*http.Request
+}
+
+func newServer(t *testing.T, faults ...http.HandlerFunc) *server {
+	t.Helper()
+	s := &server{faults: faults}
+	api := logging.Middleware(apierror.Recover(routes.New(routes.Dependencies{})))
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r)
+		var fault http.HandlerFunc
+		if len(s.faults) > 0 {
+			fault, s.faults = s.faults[0], s.faults[1:]
+		}
+		s.mu.Unlock()
+
+		if fault != nil {
+			fault(w, r)
+			return
+		}
+		api.ServeHTTP(w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *server) seen() []*http.Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*http.Request(nil), s.requests...)
+}
+
+// fail answers with an error in the standard envelope
+func fail(err *apierror.Error) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) { apierror.Write(w, r, err) }
+}
+
+func status(code int) http.HandlerFunc {
+	return fail(apierror.FromStatus(code, http.StatusText(code)))
+}
+
+func asError(t *testing.T, err error) *Error {
+	t.Helper()
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("err = %v (%T), want *Error", err, err)
+	}
+	return apiErr
+}
+
+func TestTokenSourceIsCalledForEveryAttempt(t *testing.T) {
+	s := newServer(t, status(http.StatusBadGateway))
+	calls := 0
+	c := New(s.URL, WithRetries(1, time.Millisecond), WithTokenSource(func(context.Context) (string, error) {
+		calls++
+		return fmt.Sprintf("token-%d", calls), nil
+	}))
+
+	// Without Firebase the auth middleware fails and Recover answers 500
+	_, err := c.Notifications.UnreadCount(context.Background())
+	if apiErr := asError(t, err); apiErr.Status != http.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", apiErr.Status)
+	}
+
+	requests := s.seen()
+	if len(requests) != 2 {
+		t.Fatalf("sent %d requests, want 2", len(requests))
+	}
+	for i, r := range requests {
+		if got, want := r.Header.Get("Authorization"), fmt.Sprintf("Bearer token-%d", i+1); got != want {
+			t.Errorf("request %d: Authorization = %q, want %q", i, got, want)
+		}
+	}
+}
+
+func TestRequestsWithoutTokenSourceAreAnonymous(t *testing.T) {
+	s := newServer(t)
+	c := New(s.URL)
+
+	_, err := c.Notifications.UnreadCount(context.Background())
+	apiErr := asError(t, err)
+	if apiErr.Status != http.StatusUnauthorized || apiErr.Code != apierror.CodeUnauthorized {
+		t.Errorf("err = %v, want 401 %s", apiErr, apierror.CodeUnauthorized)
+	}
+
+	requests := s.seen()
+	if len(requests) != 1 {
+		t.Fatalf("sent %d requests, want 1: a 401 is not retried", len(requests))
+	}
+	if got := requests[0].Header.Get("Authorization"); got != "" {
+		t.Errorf("Authorization = %q, want none", got)
+	}
+}
+
+func TestTokenSourceErrorsStopTheRequest(t *testing.T) {
+	s := newServer(t)
+	c := New(s.URL, WithTokenSource(func(context.Context) (string, error) {
+		return "", errors.New("refresh token expired")
+	}))
+
+	if _, err := c.Notifications.UnreadCount(context.Background()); err == nil {
+		t.Fatal("UnreadCount succeeded, want the token source error")
+	}
+	if requests := s.seen(); len(requests) != 0 {
+		t.Errorf("sent %d requests, want none", len(requests))
+	}
+}
+
+func TestRetries(t *testing.T) {
+	const backoff = 20 * time.Millisecond
+	unreadCount := func(c *Client) error { _, err := c.Notifications.UnreadCount(context.Background()); return err }
+	markAllRead := func(c *Client) error { _, err := c.Notifications.MarkAllRead(context.Background()); return err }
+
+	tests := []struct {
+		name         string
+		call         func(*Client) error
+		faults       []http.HandlerFunc
+		wantRequests int
+		wantStatus   int
+		minElapsed   time.Duration
+	}{
+		{
+			name:         "GET retries 5xx and 429 until the route answers",
+			call:         unreadCount,
+			faults:       []http.HandlerFunc{status(http.StatusServiceUnavailable), status(http.StatusTooManyRequests)},
+			wantRequests: 3,
+			wantStatus:   http.StatusUnauthorized,
+			minElapsed:   backoff + 2*backoff,
+		},
+		{
+			name:         "GET gives up after the last retry",
+			call:         unreadCount,
+			faults:       []http.HandlerFunc{status(http.StatusBadGateway), status(http.StatusBadGateway), status(http.StatusGatewayTimeout)},
+			wantRequests: 3,
+			wantStatus:   http.StatusGatewayTimeout,
+			minElapsed:   backoff + 2*backoff,
+		},
+		{
+			name:         "POST is not retried after a 500",
+			call:         markAllRead,
+			faults:       []http.HandlerFunc{status(http.StatusInternalServerError)},
+			wantRequests: 1,
+			wantStatus:   http.StatusInternalServerError,
+		},
+		{
+			name:         "POST is retried after a 429",
+			call:         markAllRead,
+			faults:       []http.HandlerFunc{status(http.StatusTooManyRequests)},
+			wantRequests: 2,
+			wantStatus:   http.StatusUnauthorized,
+			minElapsed:   backoff,
+		},
+		{
+			name:         "POST is retried after a 503",
+			call:         markAllRead,
+			faults:       []http.HandlerFunc{status(http.StatusServiceUnavailable)},
+			wantRequests: 2,
+			wantStatus:   http.StatusUnauthorized,
+			minElapsed:   backoff,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newServer(t, tt.faults...)
+			c := New(s.URL, WithRetries(2, backoff))
+
+			start := time.Now()
+			err := tt.call(c)
+			elapsed := time.Since(start)
+
+			if apiErr := asError(t, err); apiErr.Status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", apiErr.Status, tt.wantStatus)
+			}
+			if requests := s.seen(); len(requests) != tt.wantRequests {
+				t.Errorf("sent %d requests, want %d", len(requests), tt.wantRequests)
+			}
+			if elapsed < tt.minElapsed {
+				t.Errorf("took %s, want a backoff of at least %s", elapsed, tt.minElapsed)
+			}
+		})
+	}
+}
+
+func TestRetryWaitsForRetryAfter(t *testing.T) {
+	s := newServer(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Retry-After", "1")
+		apierror.Write(w, r, apierror.FromStatus(http.StatusTooManyRequests, "Slow down"))
+	})
+	c := New(s.URL, WithRetries(1, time.Millisecond))
+
+	start := time.Now()
+	c.Notifications.UnreadCount(context.Background())
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Errorf("retried after %s, want the second asked for by Retry-After", elapsed)
+	}
+}
+
+func TestRetryStopsWhenTheContextIsDone(t *testing.T) {
+	s := newServer(t, status(http.StatusServiceUnavailable))
+	c := New(s.URL, WithRetries(1, time.Minute))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := c.Notifications.UnreadCount(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("err = %v, want the context deadline", err)
+	}
+}
+
+func TestErrorEnvelope(t *testing.T) {
+	tests := []struct {
+		name  string
+		fault http.HandlerFunc
+		want  Error
+	}{
+		{
+			name: "route error",
+			want: Error{Status: http.StatusUnauthorized, Code: apierror.CodeUnauthorized, Message: "Missing or invalid token"},
+		},
+		{
+			name: "validation error with fields",
+			fault: fail(apierror.Validation("Invalid request body").
+				WithField("email", "must be a valid email address")),
+			want: Error{
+				Status:  http.StatusUnprocessableEntity,
+				Code:    apierror.CodeValidationFailed,
+				Message: "Invalid request body",
+				Fields:  []apierror.FieldError{{Field: "email", Message: "must be a valid email address"}},
+			},
+		},
+		{
+			name:  "explicit code",
+			fault: fail(apierror.New(http.StatusConflict, "already_verified", "The campus email is already verified")),
+			want:  Error{Status: http.StatusConflict, Code: "already_verified", Message: "The campus email is already verified"},
+		},
+		{
+			name: "body outside the envelope",
+			fault: func(w http.ResponseWriter, r *http.Request) {
+				http.Error(w, "upstream went away", http.StatusBadGateway)
+			},
+			want: Error{Status: http.StatusBadGateway, Code: apierror.CodeInternal, Message: http.StatusText(http.StatusBadGateway)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newServer(t, tt.fault) // a nil fault lets the route answer
+			c := New(s.URL, WithRetries(0, 0))
+
+			_, err := c.Messages.Send(context.Background(), "seller-1", "Halo")
+			got := asError(t, err)
+			if got.Status != tt.want.Status || got.Code != tt.want.Code || got.Message != tt.want.Message {
+				t.Errorf("err = %+v, want %+v", got, tt.want)
+			}
+			if fmt.Sprint(got.Fields) != fmt.Sprint(tt.want.Fields) {
+				t.Errorf("fields = %+v, want %+v", got.Fields, tt.want.Fields)
+			}
+			if tt.fault == nil && got.RequestID == "" {
+				t.Error("request_id is empty, want the ID assigned by the server")
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"golang-firebase-backend/models"
+)
+
+type MessagesService struct{ c *Client }
+
+// Conversations returns the conversations of the signed-in user
+func (s *MessagesService) Conversations(ctx context.Context) ([]map[string]interface{}, error) {
+	return getData[[]map[string]interface{}](ctx, s.c, "/api/v1/conversations", nil)
+}
+
+// List returns the messages of a conversation
+func (s *MessagesService) List(ctx context.Context, conversationID string) ([]models.Message, error) {
+	return getData[[]models.Message](ctx, s.c, "/api/v1/conversations/"+id(conversationID)+"/messages", nil)
+}
+
+// OpenChatRoom starts a conversation with another user and returns its ID
+func (s *MessagesService) OpenChatRoom(ctx context.Context, participantID string) (string, error) {
+	data, err := sendData[struct {
+		ConversationID string `json:"conversationID"`
+	}](ctx, s.c, http.MethodPost, "/api/v1/conversations", models.ChatRoomInput{ParticipantID: participantID})
+	return data.ConversationID, err
+}
+
+// Send sends a message to receiverID and returns the ID of the new message
+func (s *MessagesService) Send(ctx context.Context, receiverID, content string) (string, error) {
+	data, err := sendData[struct {
+		ID string `json:"id"`
+	}](ctx, s.c, http.MethodPost, "/api/v1/messages", models.Message{ReceiverID: receiverID, MessageContent: content})
+	return data.ID, err
+}
+
+type NotificationsService struct{ c *Client }
+
+// NotificationPage is one page of the inbox; pass NextCursor to List for the
+// next page, it is empty on the last one
+type NotificationPage struct {
+	Notifications []models.Notification `json:"data"`
+	NextCursor    string                `json:"next_cursor"`
+}
+
+// List returns a page of the signed-in user's inbox, newest first
+func (s *NotificationsService) List(ctx context.Context, cursor string, limit int) (*NotificationPage, error) {
+	query := url.Values{}
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+	if limit > 0 {
+		query.Set("limit", strconv.Itoa(limit))
+	}
+
+	var page NotificationPage
+	if err := s.c.get(ctx, "/api/v1/notifications", query, &page); err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// UnreadCount returns the number of unread notifications
+func (s *NotificationsService) UnreadCount(ctx context.Context) (int, error) {
+	data, err := getData[struct {
+		Unread int `json:"unread"`
+	}](ctx, s.c, "/api/v1/notifications/unread-count", nil)
+	return data.Unread, err
+}
+
+// MarkRead marks a notification as read
+func (s *NotificationsService) MarkRead(ctx context.Context, notificationID string) error {
+	return s.c.do(ctx, http.MethodPost, "/api/v1/notifications/"+id(notificationID)+"/read", nil, nil, nil)
+}
+
+// MarkAllRead marks every notification as read and returns how many changed
+func (s *NotificationsService) MarkAllRead(ctx context.Context) (int, error) {
+	data, err := sendData[struct {
+		Updated int `json:"updated"`
+	}](ctx, s.c, http.MethodPost, "/api/v1/notifications/read-all", nil)
+	return data.Updated, err
+}
+
+// Settings returns the signed-in user's notification preferences
+func (s *NotificationsService) Settings(ctx context.Context) (models.NotificationSettings, error) {
+	return getData[models.NotificationSettings](ctx, s.c, "/api/v1/users/me/notification-settings", nil)
+}
+
+// UpdateSettings replaces the notification preferences
+func (s *NotificationsService) UpdateSettings(ctx context.Context, settings models.NotificationSettings) (models.NotificationSettings, error) {
+	return sendData[models.NotificationSettings](ctx, s.c, http.MethodPut, "/api/v1/users/me/notification-settings", settings)
+}
+
+// RegisterDevice enables push notifications to a device
+func (s *NotificationsService) RegisterDevice(ctx context.Context, device models.DeviceToken) (models.DeviceToken, error) {
+	return sendData[models.DeviceToken](ctx, s.c, http.MethodPost, "/api/v1/users/me/devices", device)
+}
+
+// UnregisterDevice stops push notifications to a device
+func (s *NotificationsService) UnregisterDevice(ctx context.Context, token string) error {
+	return s.c.do(ctx, http.MethodDelete, "/api/v1/users/me/devices/"+id(token), nil, nil, nil)
+}
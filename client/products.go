@@ -0,0 +1,149 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"golang-firebase-backend/mergepatch"
+	"golang-firebase-backend/models"
+)
+
+type ProductsService struct{ c *Client }
+
+// Search finds products by name or by the name of their seller
+func (s *ProductsService) Search(ctx context.Context, query string) ([]models.Product, error) {
+	return getData[[]models.Product](ctx, s.c, "/api/v1/products/search", url.Values{"query": {query}})
+}
+
+// Lookup finds a product by the name of its seller and its own name
+func (s *ProductsService) Lookup(ctx context.Context, sellerName, productName string) (models.Product, error) {
+	return getData[models.Product](ctx, s.c, "/api/v1/products/lookup", url.Values{"name": {sellerName}, "product_name": {productName}})
+}
+
+// Get returns a product. ownerID is optional and saves the server a lookup.
+func (s *ProductsService) Get(ctx context.Context, productID, ownerID string) (models.Product, error) {
+	query := url.Values{}
+	if ownerID != "" {
+		query.Set("user_id", ownerID)
+	}
+	return getData[models.Product](ctx, s.c, "/api/v1/products/"+id(productID), query)
+}
+
+// Mine returns the products of the signed-in seller
+func (s *ProductsService) Mine(ctx context.Context) ([]models.Product, error) {
+	return getData[[]models.Product](ctx, s.c, "/api/v1/users/me/products", nil)
+}
+
+// ForUser returns the products of a seller
+func (s *ProductsService) ForUser(ctx context.Context, uid string) ([]models.Product, error) {
+	return getData[[]models.Product](ctx, s.c, "/api/v1/users/"+id(uid)+"/products", nil)
+}
+
+// Create adds a product for the signed-in seller
+func (s *ProductsService) Create(ctx context.Context, product models.ProductInput) (models.Product, error) {
+	return sendData[models.Product](ctx, s.c, http.MethodPost, "/api/v1/products", product)
+}
+
+// Update changes the non-empty fields of a product of the signed-in seller
+func (s *ProductsService) Update(ctx context.Context, productID string, update models.ProductUpdateInput) error {
+	return s.c.do(ctx, http.MethodPut, "/api/v1/products/"+id(productID), nil, update, nil)
+}
+
+// Patch applies a JSON merge patch to a product of the signed-in seller; a nil
+// value clears the field
+func (s *ProductsService) Patch(ctx context.Context, productID string, patch mergepatch.Patch) (models.Product, error) {
+	return sendData[models.Product](ctx, s.c, http.MethodPatch, "/api/v1/products/"+id(productID), patch)
+}
+
+// Delete removes a product of the signed-in seller
+func (s *ProductsService) Delete(ctx context.Context, productID string) error {
+	return s.c.do(ctx, http.MethodDelete, "/api/v1/products/"+id(productID), nil, nil, nil)
+}
+
+type PortfoliosService struct{ c *Client }
+
+// Mine returns the portfolios of the signed-in user
+func (s *PortfoliosService) Mine(ctx context.Context) ([]models.Portfolio, error) {
+	var portfolios []models.Portfolio
+	err := s.c.get(ctx, "/api/v1/users/me/portfolios", nil, &portfolios)
+	return portfolios, err
+}
+
+// ForUser returns the portfolios of a user
+func (s *PortfoliosService) ForUser(ctx context.Context, uid string) ([]models.Portfolio, error) {
+	var portfolios []models.Portfolio
+	err := s.c.get(ctx, "/api/v1/users/"+id(uid)+"/portfolios", nil, &portfolios)
+	return portfolios, err
+}
+
+// Get returns a portfolio of the signed-in user
+func (s *PortfoliosService) Get(ctx context.Context, portfolioID string) (*models.Portfolio, error) {
+	var portfolio models.Portfolio
+	if err := s.c.get(ctx, "/api/v1/users/me/portfolios/"+id(portfolioID), nil, &portfolio); err != nil {
+		return nil, err
+	}
+	return &portfolio, nil
+}
+
+func (s *PortfoliosService) Create(ctx context.Context, portfolio models.PortfolioInput) (*models.Portfolio, error) {
+	var created models.Portfolio
+	if err := s.c.do(ctx, http.MethodPost, "/api/v1/users/me/portfolios", nil, portfolio, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+func (s *PortfoliosService) Update(ctx context.Context, portfolioID string, portfolio models.Portfolio) (*models.Portfolio, error) {
+	var updated models.Portfolio
+	if err := s.c.do(ctx, http.MethodPut, "/api/v1/users/me/portfolios/"+id(portfolioID), nil, portfolio, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// Patch applies a JSON merge patch to a portfolio of the signed-in user
+func (s *PortfoliosService) Patch(ctx context.Context, portfolioID string, patch mergepatch.Patch) (*models.Portfolio, error) {
+	var updated models.Portfolio
+	if err := s.c.do(ctx, http.MethodPatch, "/api/v1/users/me/portfolios/"+id(portfolioID), nil, patch, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+func (s *PortfoliosService) Delete(ctx context.Context, portfolioID string) error {
+	return s.c.do(ctx, http.MethodDelete, "/api/v1/users/me/portfolios/"+id(portfolioID), nil, nil, nil)
+}
+
+type TransactionsService struct{ c *Client }
+
+// Checkout is a started payment; the buyer completes it at URL
+type Checkout struct {
+	Transaction models.Transaction `json:"transaction"`
+	URL         string             `json:"url"`
+}
+
+// Create starts a Midtrans payment for a product
+func (s *TransactionsService) Create(ctx context.Context, input models.TransactionInput) (*Checkout, error) {
+	checkout, err := sendData[Checkout](ctx, s.c, http.MethodPost, "/api/v1/transactions", input)
+	if err != nil {
+		return nil, err
+	}
+	return &checkout, nil
+}
+
+type JobsService struct{ c *Client }
+
+// List returns background jobs with the given status, "pending" or "dead"
+func (s *JobsService) List(ctx context.Context, status string) ([]models.Job, error) {
+	query := url.Values{}
+	if status != "" {
+		query.Set("status", status)
+	}
+	return getData[[]models.Job](ctx, s.c, "/api/v1/admin/jobs", query)
+}
+
+// Retry requeues a dead job
+func (s *JobsService) Retry(ctx context.Context, jobID string) (models.Job, error) {
+	return sendData[models.Job](ctx, s.c, http.MethodPost, "/api/v1/admin/jobs/"+id(jobID)+"/retry", nil)
+}
@@ -0,0 +1,37 @@
+package client
+
+import (
+	"context"
+	"net/url"
+
+	"golang-firebase-backend/models"
+)
+
+type SearchService struct{ c *Client }
+
+// SearchUser is a user found by Search
+type SearchUser struct {
+	ID string `json:"id"`
+	models.UserProfile
+}
+
+// SearchResult holds the users and products found by Search
+type SearchResult struct {
+	Users    []SearchUser     `json:"users"`
+	Products []models.Product `json:"products"`
+}
+
+// Search finds users by name and the products of matching sellers. An
+// empty organizationID searches every organization.
+func (s *SearchService) Search(ctx context.Context, query, organizationID string) (*SearchResult, error) {
+	params := url.Values{"query": {query}}
+	if organizationID != "" {
+		params.Set("organization", organizationID)
+	}
+
+	var result SearchResult
+	if err := s.c.get(ctx, "/api/v1/search", params, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
@@ -0,0 +1,219 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"golang-firebase-backend/mergepatch"
+	"golang-firebase-backend/models"
+)
+
+type AuthService struct{ c *Client }
+
+// LoginResult is returned by Login
+type LoginResult struct {
+	Message   string `json:"message"`
+	LoginTime string `json:"loginTime"`
+	Token     string `json:"token"`
+	User      struct {
+		UID          string `json:"uid"`
+		Name         string `json:"name"`
+		Email        string `json:"email"`
+		PhotoURL     string `json:"photoURL"`
+		Organization string `json:"organization"`
+		Major        string `json:"major"`
+		Language     string `json:"language"`
+		Role         string `json:"role"`
+	} `json:"user"`
+	Session *models.Session `json:"session"`
+}
+
+// Login signs in with the client's ID token, creating the user on first login
+func (s *AuthService) Login(ctx context.Context) (*LoginResult, error) {
+	var result LoginResult
+	if err := s.c.do(ctx, http.MethodPost, "/api/v1/auth/login", nil, nil, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// LoginFrom signs in like Login and names the device in the session list
+func (s *AuthService) LoginFrom(ctx context.Context, device models.LoginInput) (*LoginResult, error) {
+	var result LoginResult
+	if err := s.c.do(ctx, http.MethodPost, "/api/v1/auth/login", nil, device, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// Logout signs out the current session
+func (s *AuthService) Logout(ctx context.Context) error {
+	return s.c.do(ctx, http.MethodPost, "/api/v1/auth/logout", nil, nil, nil)
+}
+
+// LogoutAll revokes the refresh tokens of the signed-in user, signing out
+// every device
+func (s *AuthService) LogoutAll(ctx context.Context) error {
+	return s.c.do(ctx, http.MethodPost, "/api/v1/auth/logout", url.Values{"scope": {"all"}}, nil, nil)
+}
+
+type SessionsService struct{ c *Client }
+
+// List returns the active sessions of the signed-in user
+func (s *SessionsService) List(ctx context.Context) ([]models.Session, error) {
+	return getData[[]models.Session](ctx, s.c, "/api/v1/users/me/sessions", nil)
+}
+
+// SignOut signs out one session of the signed-in user
+func (s *SessionsService) SignOut(ctx context.Context, sessionID string) error {
+	return s.c.do(ctx, http.MethodDelete, "/api/v1/users/me/sessions/"+id(sessionID), nil, nil, nil)
+}
+
+// SignOutOthers signs out every session except the current one and returns
+// how many were signed out
+func (s *SessionsService) SignOutOthers(ctx context.Context) (int, error) {
+	var resp struct {
+		SignedOut int `json:"signed_out"`
+	}
+	err := s.c.do(ctx, http.MethodPost, "/api/v1/users/me/sessions/sign-out-others", nil, nil, &resp)
+	return resp.SignedOut, err
+}
+
+type UsersService struct{ c *Client }
+
+// UserMatch is a user found by a name search
+type UserMatch struct {
+	UID      string `json:"uid"`
+	Name     string `json:"name"`
+	PhotoURL string `json:"photo_url"`
+}
+
+// UserUpdate holds the profile fields to change; empty fields are left as they are
+type UserUpdate struct {
+	Name         string `json:"name,omitempty"`
+	Organization string `json:"organization,omitempty"`
+	Language     string `json:"language,omitempty"`
+	Major        string `json:"major,omitempty"` // title of the major
+}
+
+// UserUpdateResult is returned by UpdateMe
+type UserUpdateResult struct {
+	Message  string   `json:"message"`
+	UID      string   `json:"uid"`
+	Warnings []string `json:"warnings"`
+}
+
+// Get returns the profile of a user; email, language and last sign-in are
+// only filled in for the signed-in user and admins
+func (s *UsersService) Get(ctx context.Context, uid string) (*models.UserProfile, error) {
+	var user models.UserProfile
+	if err := s.c.get(ctx, "/api/v1/users/"+id(uid), nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// Search finds users whose name contains query
+func (s *UsersService) Search(ctx context.Context, query string) ([]UserMatch, error) {
+	var resp struct {
+		Users []UserMatch `json:"users"`
+	}
+	err := s.c.get(ctx, "/api/v1/users", url.Values{"query": {query}}, &resp)
+	return resp.Users, err
+}
+
+// UpdateMe updates the signed-in user's profile
+func (s *UsersService) UpdateMe(ctx context.Context, update UserUpdate) (*UserUpdateResult, error) {
+	var result UserUpdateResult
+	if err := s.c.do(ctx, http.MethodPatch, "/api/v1/users/me", nil, update, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// PatchMe applies a JSON merge patch to the signed-in user's profile; a nil
+// value clears the field
+func (s *UsersService) PatchMe(ctx context.Context, patch mergepatch.Patch) (*UserUpdateResult, error) {
+	var result UserUpdateResult
+	if err := s.c.do(ctx, http.MethodPatch, "/api/v1/users/me", nil, patch, &result); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
+// ChangeRole switches the signed-in user between "buyer" and "seller"
+func (s *UsersService) ChangeRole(ctx context.Context, role string) error {
+	return s.c.do(ctx, http.MethodPut, "/api/v1/users/me/role", nil, models.RoleChangeInput{Role: role}, nil)
+}
+
+type SellersService struct{ c *Client }
+
+// SellerProfile is a user together with their seller application
+type SellerProfile struct {
+	User           *models.UserProfile   `json:"user"`
+	RegisterSeller *models.SellerProfile `json:"registerSeller"`
+}
+
+type sellerResult struct {
+	RegisterSeller models.SellerProfile `json:"register_seller"`
+}
+
+// Apply submits a request to become a seller
+func (s *SellersService) Apply(ctx context.Context, request models.SellerRequestInput) (*models.SellerProfile, error) {
+	var result sellerResult
+	if err := s.c.do(ctx, http.MethodPost, "/api/v1/seller-requests", nil, request, &result); err != nil {
+		return nil, err
+	}
+	return &result.RegisterSeller, nil
+}
+
+// Status returns the status of the signed-in user's application
+func (s *SellersService) Status(ctx context.Context) (string, error) {
+	var resp struct {
+		Status string `json:"status"`
+	}
+	err := s.c.get(ctx, "/api/v1/seller-requests/me", nil, &resp)
+	return resp.Status, err
+}
+
+// Me returns the signed-in user and their seller application
+func (s *SellersService) Me(ctx context.Context) (*SellerProfile, error) {
+	var profile SellerProfile
+	if err := s.c.get(ctx, "/api/v1/users/me/seller", nil, &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// Get returns a seller and their application
+func (s *SellersService) Get(ctx context.Context, uid string) (*SellerProfile, error) {
+	var profile SellerProfile
+	if err := s.c.get(ctx, "/api/v1/sellers/"+id(uid), nil, &profile); err != nil {
+		return nil, err
+	}
+	return &profile, nil
+}
+
+// UpdateAboutMe changes the signed-in seller's about me text
+func (s *SellersService) UpdateAboutMe(ctx context.Context, aboutMe string) error {
+	return s.c.do(ctx, http.MethodPut, "/api/v1/users/me/about", nil, models.AboutMeInput{AboutMe: aboutMe}, nil)
+}
+
+// List returns every seller application (admin)
+func (s *SellersService) List(ctx context.Context) ([]models.SellerProfile, error) {
+	var sellers []models.SellerProfile
+	err := s.c.get(ctx, "/api/v1/admin/seller-requests", nil, &sellers)
+	return sellers, err
+}
+
+// Verify accepts or denies a seller application (admin). status is
+// "accepted" or "denied".
+func (s *SellersService) Verify(ctx context.Context, uid, status string) (*models.SellerProfile, error) {
+	var result sellerResult
+	path := "/api/v1/admin/seller-requests/" + id(uid) + "/decision"
+	if err := s.c.do(ctx, http.MethodPost, path, nil, models.SellerDecisionInput{UID: uid, Status: status}, &result); err != nil {
+		return nil, err
+	}
+	return &result.RegisterSeller, nil
+}
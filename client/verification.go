@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"golang-firebase-backend/models"
+)
+
+type CampusService struct{ c *Client }
+
+// Status returns the verification of the signed-in user's campus email. It
+// fails with a 404 *Error when no code was requested yet.
+func (s *CampusService) Status(ctx context.Context) (*models.CampusVerification, error) {
+	verification, err := getData[models.CampusVerification](ctx, s.c, "/api/v1/seller-requests/me/email-verification", nil)
+	if err != nil {
+		return nil, err
+	}
+	return &verification, nil
+}
+
+// SendCode emails a verification code to a campus address, which must be
+// verified before applying to become a seller
+func (s *CampusService) SendCode(ctx context.Context, email string) (*models.CampusVerification, error) {
+	verification, err := sendData[models.CampusVerification](ctx, s.c, http.MethodPost, "/api/v1/seller-requests/me/email-verification", models.CampusEmailInput{Email: email})
+	if err != nil {
+		return nil, err
+	}
+	return &verification, nil
+}
+
+// Confirm verifies the campus email with the code that was sent
+func (s *CampusService) Confirm(ctx context.Context, code string) (*models.CampusVerification, error) {
+	verification, err := sendData[models.CampusVerification](ctx, s.c, http.MethodPost, "/api/v1/seller-requests/me/email-verification/confirm", models.CampusCodeInput{Code: code})
+	if err != nil {
+		return nil, err
+	}
+	return &verification, nil
+}
+
+type IDCardsService struct{ c *Client }
+
+// Upload uploads the photo of the signed-in user's student ID card, which is
+// required before applying. contentType is image/jpeg, image/png or
+// image/webp.
+func (s *IDCardsService) Upload(ctx context.Context, contentType string, photo []byte) (*models.IDCard, error) {
+	card, err := sendData[models.IDCard](ctx, s.c, http.MethodPut, "/api/v1/seller-requests/me/id-card", rawBody{contentType: contentType, data: photo})
+	if err != nil {
+		return nil, err
+	}
+	return &card, nil
+}
+
+// Get returns the signed-in user's student ID card with a URL that is valid
+// for a few minutes
+func (s *IDCardsService) Get(ctx context.Context) (*models.IDCard, error) {
+	card, err := getData[models.IDCard](ctx, s.c, "/api/v1/seller-requests/me/id-card", nil)
+	if err != nil {
+		return nil, err
+	}
+	return &card, nil
+}
+
+// Applicant returns the student ID card of an applicant (admin)
+func (s *IDCardsService) Applicant(ctx context.Context, uid string) (*models.IDCard, error) {
+	card, err := getData[models.IDCard](ctx, s.c, "/api/v1/admin/seller-requests/"+id(uid)+"/id-card", nil)
+	if err != nil {
+		return nil, err
+	}
+	return &card, nil
+}
+
+type AlumniService struct{ c *Client }
+
+// Confirm answers whether the signed-in seller graduated. Sellers still
+// studying pass "studying" and their new graduation date.
+func (s *AlumniService) Confirm(ctx context.Context, request models.AlumniConfirmationInput) (*models.SellerProfile, error) {
+	var result sellerResult
+	if err := s.c.do(ctx, http.MethodPost, "/api/v1/seller-requests/me/alumni", nil, request, &result); err != nil {
+		return nil, err
+	}
+	return &result.RegisterSeller, nil
+}
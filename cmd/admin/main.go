@@ -0,0 +1,73 @@
+// Command admin grants and revokes the admin role. Admin routes check the
+// "role" custom claim of the ID token, which only the Admin SDK can set, so
+// the first administrators are created with this command:
+//
+//	go run ./cmd/admin grant <uid>
+//	go run ./cmd/admin revoke <uid>
+//	go run ./cmd/admin show <uid>
+//
+// It reads the same configuration as the server (APP_ENV, CONFIG_FILE and
+// the environment). Users get the new role with their next ID token; the
+// refresh tokens are revoked so that this happens within the hour at most.
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"golang-firebase-backend/auth"
+	"golang-firebase-backend/config"
+)
+
+func main() {
+	if len(os.Args) != 3 {
+		fmt.Fprintln(os.Stderr, "usage: admin grant|revoke|show <uid>")
+		os.Exit(2)
+	}
+	command, uid := os.Args[1], os.Args[2]
+
+	if err := runCommand(context.Background(), command, uid); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func runCommand(ctx context.Context, command, uid string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	app, err := config.InitializeFirebaseApp(ctx, cfg.Firebase)
+	if err != nil {
+		return err
+	}
+	service, err := auth.NewService(ctx, app, 1)
+	if err != nil {
+		return err
+	}
+
+	switch command {
+	case "grant":
+		err = service.SetRole(ctx, uid, auth.RoleAdmin)
+	case "revoke":
+		err = service.SetRole(ctx, uid, "")
+	case "show":
+	default:
+		return fmt.Errorf("unknown command %q, want grant, revoke or show", command)
+	}
+	if err != nil {
+		return err
+	}
+
+	user, err := service.Client().GetUser(ctx, uid)
+	if err != nil {
+		return err
+	}
+	role, _ := user.CustomClaims["role"].(string)
+	if role == "" {
+		role = "(none)"
+	}
+	fmt.Printf("%s <%s>: role %s\n", uid, user.Email, role)
+	return nil
+}
@@ -0,0 +1,453 @@
+// Package config loads the configuration of the service and sets up its
+// Firebase and Midtrans clients.
+//
+// Settings come from the defaults of the selected profile, then from an
+// optional YAML file, then from environment variables, each overriding the
+// previous one. The profile is chosen by APP_ENV (dev, staging or prod; dev
+// by default). The file is read from CONFIG_FILE, or from config.yaml when
+// it exists, and may override settings per profile under "profiles"; see
+// config.example.yaml.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Profiles
+const (
+	Development = "dev"
+	Staging     = "staging"
+	Production  = "prod"
+)
+
+// Config is the complete configuration of the service
+type Config struct {
+	Env       string          `yaml:"env"`
+	Server    ServerConfig    `yaml:"server"`
+	Log       LogConfig       `yaml:"log"`
+	Firebase  FirebaseConfig  `yaml:"firebase"`
+	Midtrans  MidtransConfig  `yaml:"midtrans"`
+	SMTP      SMTPConfig      `yaml:"smtp"`
+	CORS      CORSConfig      `yaml:"cors"`
+	RateLimit RateLimitConfig `yaml:"rate_limit"`
+	Metrics   MetricsConfig   `yaml:"metrics"`
+	Campus    CampusConfig    `yaml:"campus"`
+	Alumni    AlumniConfig    `yaml:"alumni"`
+	IDCards   IDCardConfig    `yaml:"id_cards"`
+}
+
+// ServerConfig configures the HTTP server
+type ServerConfig struct {
+	Port              string        `yaml:"port" env:"PORT"`
+	ReadHeaderTimeout time.Duration `yaml:"read_header_timeout" env:"HTTP_READ_HEADER_TIMEOUT"`
+	ReadTimeout       time.Duration `yaml:"read_timeout" env:"HTTP_READ_TIMEOUT"`
+	WriteTimeout      time.Duration `yaml:"write_timeout" env:"HTTP_WRITE_TIMEOUT"`
+	IdleTimeout       time.Duration `yaml:"idle_timeout" env:"HTTP_IDLE_TIMEOUT"`
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" env:"SHUTDOWN_TIMEOUT"`
+}
+
+// LogConfig configures the logger
+type LogConfig struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL"`
+	Format string `yaml:"format" env:"LOG_FORMAT"`
+}
+
+// FirebaseConfig selects the Firebase project. Without a credentials file
+// the application default credentials are used.
+type FirebaseConfig struct {
+	DatabaseURL     string `yaml:"database_url" env:"FIREBASE_DATABASE_URL"`
+	CredentialsFile string `yaml:"credentials_file" env:"FIREBASE_CREDENTIALS_FILE"`
+	StorageBucket   string `yaml:"storage_bucket" env:"FIREBASE_STORAGE_BUCKET"`
+}
+
+// SMTPConfig is the mail server notification and verification emails are
+// sent through. From is the sender address, e.g. "SkillX <no-reply@skillx.id>".
+type SMTPConfig struct {
+	Host     string `yaml:"host" env:"SMTP_HOST"`
+	Port     int    `yaml:"port" env:"SMTP_PORT"`
+	Username string `yaml:"username" env:"SMTP_USERNAME"`
+	Password Secret `yaml:"password" env:"SMTP_PASSWORD"`
+	From     string `yaml:"from" env:"SMTP_FROM"`
+}
+
+// CORSConfig lists the origins allowed to call the API from a browser.
+// Origins may be patterns such as https://*.skillx.id. Routes overrides the
+// settings for single routes, keyed by path pattern.
+type CORSConfig struct {
+	AllowedOrigins   []string                   `yaml:"allowed_origins" env:"CORS_ALLOWED_ORIGINS"`
+	AllowCredentials bool                       `yaml:"allow_credentials" env:"CORS_ALLOW_CREDENTIALS"`
+	MaxAge           time.Duration              `yaml:"max_age" env:"CORS_MAX_AGE"`
+	Routes           map[string]CORSRouteConfig `yaml:"routes"`
+}
+
+// CORSRouteConfig overrides the CORS settings that are set
+type CORSRouteConfig struct {
+	AllowedOrigins   []string `yaml:"allowed_origins"`
+	AllowCredentials *bool    `yaml:"allow_credentials"`
+}
+
+// RateLimitConfig selects where rate limit buckets are kept: "memory" for
+// a single instance or "firebase" to share them between replicas.
+// PerMinute limits the requests of every client to the whole API, 0
+// disables it; single routes have stricter limits of their own.
+// TrustedProxies is the number of proxies appending to X-Forwarded-For. It
+// must match the deployment: with 0 behind a load balancer every client
+// shares the balancer's bucket, with too many clients can choose their
+// address. Staging and prod default to the one load balancer in front of
+// them.
+type RateLimitConfig struct {
+	Store          string `yaml:"store" env:"RATE_LIMIT_STORE"`
+	PerMinute      int    `yaml:"per_minute" env:"RATE_LIMIT_PER_MINUTE"`
+	TrustedProxies int    `yaml:"trusted_proxies" env:"RATE_LIMIT_TRUSTED_PROXIES"`
+}
+
+// Rate limit stores
+const (
+	RateLimitMemory   = "memory"
+	RateLimitFirebase = "firebase"
+)
+
+// MetricsConfig protects /metrics. Without a token it is served openly.
+type MetricsConfig struct {
+	Token Secret `yaml:"token" env:"METRICS_TOKEN"`
+}
+
+// CampusConfig controls the verification of the campus email of seller
+// applicants. Domains maps the allowed email domains, such as ui.ac.id or
+// *.ac.id for every subdomain, to the organization they belong to; an empty
+// organization keeps the one the applicant entered. MaxAttempts limits the
+// wrong guesses of one code and MaxDailyAttempts those of a user within a
+// day, however many codes they request.
+type CampusConfig struct {
+	Domains          map[string]string `yaml:"domains"`
+	CodeTTL          time.Duration     `yaml:"code_ttl" env:"CAMPUS_CODE_TTL"`
+	MaxAttempts      int               `yaml:"max_attempts" env:"CAMPUS_CODE_MAX_ATTEMPTS"`
+	ResendInterval   time.Duration     `yaml:"resend_interval" env:"CAMPUS_CODE_RESEND_INTERVAL"`
+	MaxDailyAttempts int               `yaml:"max_daily_attempts" env:"CAMPUS_CODE_MAX_DAILY_ATTEMPTS"`
+}
+
+// DefaultCampusDomains are used when no campus domains are configured.
+// They are not part of Defaults because YAML maps merge into the map they
+// are decoded into, which would make the default impossible to remove.
+func DefaultCampusDomains() map[string]string {
+	return map[string]string{"*.ac.id": ""}
+}
+
+// AlumniConfig controls what happens to sellers past their graduation date.
+// They are asked to confirm that they graduated; Policy decides whether
+// alumni keep selling ("keep") or go back to the admin queue ("reverify").
+// Sellers who do not answer within GracePeriod are taken to be alumni.
+// SweepTime is when the daily check runs, as HH:MM in Asia/Jakarta.
+type AlumniConfig struct {
+	Policy      string        `yaml:"policy" env:"ALUMNI_POLICY"`
+	GracePeriod time.Duration `yaml:"grace_period" env:"ALUMNI_GRACE_PERIOD"`
+	SweepTime   string        `yaml:"sweep_time" env:"ALUMNI_SWEEP_TIME"`
+}
+
+// Alumni policies
+const (
+	AlumniKeep     = "keep"
+	AlumniReverify = "reverify"
+)
+
+// IDCardConfig controls the student ID card photos of seller applicants,
+// which are kept in a private path of the storage bucket. URLTTL is how long
+// the signed URLs served to the owner and admins stay valid; Retention is how
+// long a photo is kept after the application was decided.
+type IDCardConfig struct {
+	URLTTL    time.Duration `yaml:"url_ttl" env:"ID_CARD_URL_TTL"`
+	Retention time.Duration `yaml:"retention" env:"ID_CARD_RETENTION"`
+}
+
+// Secret is a setting that must not appear in logs. It is redacted when
+// printed or marshalled; Value returns the actual value.
+type Secret string
+
+const redacted = "[REDACTED]"
+
+// Value returns the secret itself
+func (s Secret) Value() string {
+	return string(s)
+}
+
+func (s Secret) String() string {
+	if s == "" {
+		return ""
+	}
+	return redacted
+}
+
+func (s Secret) MarshalYAML() (interface{}, error) {
+	return s.String(), nil
+}
+
+func (s Secret) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.Quote(s.String())), nil
+}
+
+// Defaults returns the built-in settings of a profile. Only dev points at
+// the shared development project; staging and prod must be configured.
+func Defaults(env string) Config {
+	cfg := Config{
+		Env: env,
+		Server: ServerConfig{
+			Port:              "8080",
+			ReadHeaderTimeout: 10 * time.Second,
+			ReadTimeout:       30 * time.Second,
+			WriteTimeout:      60 * time.Second,
+			IdleTimeout:       120 * time.Second,
+			ShutdownTimeout:   25 * time.Second,
+		},
+		Log:       LogConfig{Level: "info", Format: "text"},
+		Midtrans:  MidtransConfig{Environment: MidtransSandbox},
+		SMTP:      SMTPConfig{Port: 587},
+		CORS:      CORSConfig{MaxAge: 10 * time.Minute},
+		RateLimit: RateLimitConfig{Store: RateLimitMemory, PerMinute: 300},
+		Campus: CampusConfig{
+			CodeTTL:          10 * time.Minute,
+			MaxAttempts:      5,
+			ResendInterval:   time.Minute,
+			MaxDailyAttempts: 15,
+		},
+		Alumni: AlumniConfig{
+			Policy:      AlumniKeep,
+			GracePeriod: 30 * 24 * time.Hour,
+			SweepTime:   "03:00",
+		},
+		IDCards: IDCardConfig{
+			URLTTL:    5 * time.Minute,
+			Retention: 30 * 24 * time.Hour,
+		},
+	}
+
+	switch env {
+	case Development:
+		cfg.Firebase = FirebaseConfig{
+			DatabaseURL:     "https://skillx-butterscoth.firebaseio.com/",
+			CredentialsFile: "skillx-butterscoth-firebase-adminsdk-eivk1-06ffcd28f7.json",
+			StorageBucket:   "skillx-butterscoth.firebasestorage.app",
+		}
+		cfg.CORS.AllowedOrigins = []string{"http://localhost:8100"}
+	case Staging:
+		cfg.Log.Format = "json"
+		cfg.RateLimit.Store = RateLimitFirebase
+		cfg.RateLimit.TrustedProxies = 1
+	case Production:
+		cfg.Log.Format = "json"
+		cfg.RateLimit.Store = RateLimitFirebase
+		cfg.RateLimit.TrustedProxies = 1
+		cfg.Midtrans.Environment = MidtransProduction
+	}
+	return cfg
+}
+
+// Validate reports every invalid setting at once
+func (c *Config) Validate() error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+	required := func(name, env, value string) bool {
+		if strings.TrimSpace(value) == "" {
+			fail("%s is required (set %s)", name, env)
+			return false
+		}
+		return true
+	}
+
+	if port, err := strconv.Atoi(c.Server.Port); err != nil || port < 1 || port > 65535 {
+		fail("server.port must be a port number, got %q", c.Server.Port)
+	}
+	for _, timeout := range []struct {
+		name  string
+		value time.Duration
+	}{
+		{"server.read_header_timeout", c.Server.ReadHeaderTimeout},
+		{"server.read_timeout", c.Server.ReadTimeout},
+		{"server.write_timeout", c.Server.WriteTimeout},
+		{"server.idle_timeout", c.Server.IdleTimeout},
+		{"server.shutdown_timeout", c.Server.ShutdownTimeout},
+	} {
+		if timeout.value <= 0 {
+			fail("%s must be positive, got %s", timeout.name, timeout.value)
+		}
+	}
+
+	switch strings.ToLower(c.Log.Level) {
+	case "debug", "info", "warn", "warning", "error":
+	default:
+		fail("log.level must be debug, info, warn or error, got %q", c.Log.Level)
+	}
+	switch strings.ToLower(c.Log.Format) {
+	case "text", "json":
+	default:
+		fail("log.format must be text or json, got %q", c.Log.Format)
+	}
+
+	if required("firebase.database_url", "FIREBASE_DATABASE_URL", c.Firebase.DatabaseURL) {
+		if u, err := url.Parse(c.Firebase.DatabaseURL); err != nil || u.Scheme != "https" || u.Host == "" {
+			fail("firebase.database_url must be an https URL, got %q", c.Firebase.DatabaseURL)
+		}
+	}
+	if c.Firebase.CredentialsFile != "" {
+		if _, err := os.Stat(c.Firebase.CredentialsFile); err != nil {
+			fail("firebase.credentials_file cannot be read: %v", err)
+		}
+	}
+	required("firebase.storage_bucket", "FIREBASE_STORAGE_BUCKET", c.Firebase.StorageBucket)
+
+	switch c.Midtrans.Environment {
+	case MidtransSandbox, MidtransProduction:
+		if c.Env == Production && c.Midtrans.Environment != MidtransProduction {
+			fail("midtrans.environment must be %q in prod, got %q", MidtransProduction, c.Midtrans.Environment)
+		}
+	default:
+		fail("midtrans.environment must be %q or %q, got %q", MidtransSandbox, MidtransProduction, c.Midtrans.Environment)
+	}
+	required("midtrans.server_key", "MIDTRANS_SERVER_KEY", c.Midtrans.ServerKey.Value())
+	required("midtrans.client_key", "MIDTRANS_CLIENT_KEY", c.Midtrans.ClientKey.Value())
+	if required("midtrans.base_url", "BASE_URL", c.Midtrans.BaseURL) {
+		if u, err := url.Parse(c.Midtrans.BaseURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			fail("midtrans.base_url must be an absolute http(s) URL, got %q", c.Midtrans.BaseURL)
+		}
+	}
+
+	// Development may run without a mail server; sending then fails
+	if c.Env != Development || c.SMTP.Host != "" {
+		required("smtp.host", "SMTP_HOST", c.SMTP.Host)
+		if required("smtp.from", "SMTP_FROM", c.SMTP.From) {
+			if _, err := mail.ParseAddress(c.SMTP.From); err != nil {
+				fail("smtp.from must be an email address, got %q", c.SMTP.From)
+			}
+		}
+	}
+	if c.SMTP.Port < 1 || c.SMTP.Port > 65535 {
+		fail("smtp.port must be a port number, got %d", c.SMTP.Port)
+	}
+
+	if len(c.CORS.AllowedOrigins) == 0 {
+		fail("cors.allowed_origins is required (set CORS_ALLOWED_ORIGINS)")
+	}
+	if c.CORS.MaxAge < 0 {
+		fail("cors.max_age must not be negative, got %s", c.CORS.MaxAge)
+	}
+	c.validateOrigins("cors", c.CORS.AllowedOrigins, c.CORS.AllowCredentials, fail)
+	paths := make([]string, 0, len(c.CORS.Routes))
+	for path := range c.CORS.Routes {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		route := c.CORS.Routes[path]
+		name := fmt.Sprintf("cors.routes[%q]", path)
+		if !strings.HasPrefix(path, "/") {
+			fail("%s must be a path pattern such as /api/v1/products/{id}", name)
+		}
+		credentials := c.CORS.AllowCredentials
+		if route.AllowCredentials != nil {
+			credentials = *route.AllowCredentials
+		}
+		c.validateOrigins(name, route.AllowedOrigins, credentials, fail)
+	}
+
+	switch c.RateLimit.Store {
+	case RateLimitMemory, RateLimitFirebase:
+	default:
+		fail("rate_limit.store must be %q or %q, got %q", RateLimitMemory, RateLimitFirebase, c.RateLimit.Store)
+	}
+	if c.RateLimit.PerMinute < 0 {
+		fail("rate_limit.per_minute must not be negative, got %d", c.RateLimit.PerMinute)
+	}
+	if c.RateLimit.TrustedProxies < 0 {
+		fail("rate_limit.trusted_proxies must not be negative, got %d", c.RateLimit.TrustedProxies)
+	}
+
+	if len(c.Campus.Domains) == 0 {
+		fail("campus.domains must list at least one email domain")
+	}
+	domains := make([]string, 0, len(c.Campus.Domains))
+	for domain := range c.Campus.Domains {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+	for _, domain := range domains {
+		host := strings.TrimPrefix(domain, "*.")
+		if host == "" || host != strings.ToLower(host) || strings.ContainsAny(host, "*@/ ") || !strings.Contains(host, ".") {
+			fail("campus.domains must contain lowercase domains such as ui.ac.id or *.ac.id, got %q", domain)
+		}
+	}
+	if c.Campus.CodeTTL <= 0 {
+		fail("campus.code_ttl must be positive, got %s", c.Campus.CodeTTL)
+	}
+	if c.Campus.MaxAttempts < 1 {
+		fail("campus.max_attempts must be at least 1, got %d", c.Campus.MaxAttempts)
+	}
+	if c.Campus.ResendInterval < 0 {
+		fail("campus.resend_interval must not be negative, got %s", c.Campus.ResendInterval)
+	}
+	if c.Campus.MaxDailyAttempts < c.Campus.MaxAttempts {
+		fail("campus.max_daily_attempts must be at least campus.max_attempts (%d), got %d", c.Campus.MaxAttempts, c.Campus.MaxDailyAttempts)
+	}
+
+	switch c.Alumni.Policy {
+	case AlumniKeep, AlumniReverify:
+	default:
+		fail("alumni.policy must be %q or %q, got %q", AlumniKeep, AlumniReverify, c.Alumni.Policy)
+	}
+	if c.Alumni.GracePeriod < 0 {
+		fail("alumni.grace_period must not be negative, got %s", c.Alumni.GracePeriod)
+	}
+	if _, err := time.Parse("15:04", c.Alumni.SweepTime); err != nil {
+		fail("alumni.sweep_time must be a time formatted as HH:MM, got %q", c.Alumni.SweepTime)
+	}
+
+	// Signed URLs cannot be valid for longer than seven days
+	if c.IDCards.URLTTL <= 0 || c.IDCards.URLTTL > 7*24*time.Hour {
+		fail("id_cards.url_ttl must be positive and at most 168h, got %s", c.IDCards.URLTTL)
+	}
+	if c.IDCards.Retention < 0 {
+		fail("id_cards.retention must not be negative, got %s", c.IDCards.Retention)
+	}
+
+	return errors.Join(errs...)
+}
+
+// validateOrigins checks the allowed_origins of a CORS section
+func (c *Config) validateOrigins(section string, origins []string, credentials bool, fail func(string, ...interface{})) {
+	for _, origin := range origins {
+		if origin == "*" {
+			if c.Env == Production {
+				fail("%s.allowed_origins must list the allowed origins in prod instead of \"*\"", section)
+			}
+			if credentials {
+				fail("%s.allowed_origins cannot contain \"*\" when credentials are allowed", section)
+			}
+			continue
+		}
+		u, err := url.Parse(origin)
+		valid := err == nil && u.Scheme != "" && u.Host != "" && (u.Path == "" || u.Path == "/") &&
+			!strings.Contains(strings.TrimPrefix(u.Host, "*."), "*")
+		if !valid {
+			fail("%s.allowed_origins must contain origins such as https://app.example.com or https://*.example.com, got %q", section, origin)
+		}
+	}
+}
+
+// String prints the configuration as YAML with secrets redacted
+func (c Config) String() string {
+	out, err := yaml.Marshal(c)
+	if err != nil {
+		return fmt.Sprintf("config: %v", err)
+	}
+	return string(out)
+}
@@ -2,45 +2,100 @@ package config
 
 import (
 	"context"
+	"errors"
 	"fmt"
 
 	firebase "firebase.google.com/go"
 	"firebase.google.com/go/db"
+	"firebase.google.com/go/storage"
+
+	"golang-firebase-backend/metrics"
+	"golang-firebase-backend/tracing"
 
 	"google.golang.org/api/option"
+	htransport "google.golang.org/api/transport/http"
 )
 
-var FirebaseApp *firebase.App
+// firebaseApp is the app InitializeFirebaseApp connected. main hands the
+// returned app to the services it sets up, and Database to handlers built
+// with their dependencies such as controllers.Payments; the other handlers
+// reach it through Database and Storage.
+var firebaseApp *firebase.App
+
+// firebaseScopes are the OAuth scopes the Firebase Admin SDK requests by default
+var firebaseScopes = []string{
+	"https://www.googleapis.com/auth/cloud-platform",
+	"https://www.googleapis.com/auth/datastore",
+	"https://www.googleapis.com/auth/devstorage.full_control",
+	"https://www.googleapis.com/auth/firebase",
+	"https://www.googleapis.com/auth/identitytoolkit",
+	"https://www.googleapis.com/auth/userinfo.email",
+}
 
-func InitializeFirebaseApp() (*firebase.App, error) {
+// InitializeFirebaseApp connects to the configured Firebase project and
+// returns the app, which Database and Storage then use
+func InitializeFirebaseApp(ctx context.Context, cfg FirebaseConfig) (*firebase.App, error) {
 	config := &firebase.Config{
-		DatabaseURL: "https://skillx-butterscoth.firebaseio.com/",
+		DatabaseURL:   cfg.DatabaseURL,
+		StorageBucket: cfg.StorageBucket,
+	}
+
+	var opts []option.ClientOption
+	if cfg.CredentialsFile != "" {
+		opts = append(opts, option.WithCredentialsFile(cfg.CredentialsFile))
 	}
 
-	opt := option.WithCredentialsFile("skillx-butterscoth-firebase-adminsdk-eivk1-06ffcd28f7.json")
-	app, err := firebase.NewApp(context.Background(), config, opt)
+	// Authorized client whose Realtime Database calls are measured and traced
+	httpClient, _, err := htransport.NewClient(ctx, append(opts, option.WithScopes(firebaseScopes...))...)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing HTTP client: %v", err)
+	}
+	httpClient.Transport = metrics.Transport(tracing.Transport(httpClient.Transport))
+
+	app, err := firebase.NewApp(ctx, config, append(opts, option.WithHTTPClient(httpClient))...)
 	if err != nil {
 		return nil, fmt.Errorf("error initializing app: %v", err)
 	}
 
-	FirebaseApp = app
+	firebaseApp = app
 
 	return app, err
 }
 
-// Database initializes and returns the Firebase Realtime Database client
+// Database returns the Firebase Realtime Database client
 func Database(ctx context.Context) (*db.Client, error) {
-	if FirebaseApp == nil {
-		_, err := InitializeFirebaseApp()
-		if err != nil {
-			return nil, fmt.Errorf("error initializing Firebase App: %v", err)
-		}
+	if firebaseApp == nil {
+		return nil, errors.New("Firebase App is not initialized")
 	}
 
-	client, err := FirebaseApp.Database(ctx)
+	client, err := firebaseApp.Database(ctx)
 	if err != nil {
 		return nil, fmt.Errorf("error initializing Firebase Database: %v", err)
 	}
 
 	return client, nil
 }
+
+// Storage returns the Firebase Storage client
+func Storage(ctx context.Context) (*storage.Client, error) {
+	if firebaseApp == nil {
+		return nil, errors.New("Firebase App is not initialized")
+	}
+
+	client, err := firebaseApp.Storage(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing Firebase Storage: %v", err)
+	}
+
+	return client, nil
+}
+
+// PingDatabase checks that the Realtime Database can be reached
+func PingDatabase(ctx context.Context) error {
+	client, err := Database(ctx)
+	if err != nil {
+		return err
+	}
+	var keys map[string]bool
+	return client.NewRef("").GetShallow(ctx, &keys)
+}
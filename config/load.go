@@ -0,0 +1,187 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// DefaultFile is read when CONFIG_FILE is not set and the file exists
+const DefaultFile = "config.yaml"
+
+// file is the layout of the YAML file: settings for every profile at the
+// top level and overrides for single profiles under "profiles"
+type file struct {
+	Config   `yaml:",inline"`
+	Profiles map[string]yaml.Node `yaml:"profiles"`
+}
+
+// Load reads and validates the configuration. Variables from a .env file in
+// the working directory are applied to the environment first.
+func Load() (*Config, error) {
+	// .env is optional; variables already set take precedence
+	_ = godotenv.Load()
+
+	path, explicit := os.LookupEnv("CONFIG_FILE")
+	if !explicit {
+		path = DefaultFile
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if explicit || !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("reading config file: %w", err)
+		}
+	}
+
+	env, err := profile(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	cfg := Defaults(env)
+
+	if data != nil {
+		if err := decodeFile(data, &cfg); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	if err := applyEnv(reflect.ValueOf(&cfg).Elem()); err != nil {
+		return nil, fmt.Errorf("invalid environment variables:\n%w", err)
+	}
+
+	if len(cfg.Campus.Domains) == 0 {
+		cfg.Campus.Domains = DefaultCampusDomains()
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %s configuration:\n%w", cfg.Env, err)
+	}
+	return &cfg, nil
+}
+
+// profile returns the profile named by APP_ENV, or by the "env" key of the
+// file, or dev
+func profile(data []byte) (string, error) {
+	env := os.Getenv("APP_ENV")
+	if env == "" && data != nil {
+		var head struct {
+			Env string `yaml:"env"`
+		}
+		if err := yaml.Unmarshal(data, &head); err != nil {
+			return "", err
+		}
+		env = head.Env
+	}
+
+	switch strings.ToLower(env) {
+	case "", Development, "development":
+		return Development, nil
+	case Staging:
+		return Staging, nil
+	case Production, "production":
+		return Production, nil
+	}
+	return "", fmt.Errorf("unknown profile %q: APP_ENV must be %s, %s or %s", env, Development, Staging, Production)
+}
+
+// decodeFile applies the top-level settings of the file and then the
+// overrides of the selected profile. Unknown keys are rejected so that typos
+// do not go unnoticed.
+func decodeFile(data []byte, cfg *Config) error {
+	env := cfg.Env
+	parsed := file{Config: *cfg}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&parsed); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	for name := range parsed.Profiles {
+		if name != Development && name != Staging && name != Production {
+			return fmt.Errorf("unknown profile %q under profiles", name)
+		}
+	}
+	if node, ok := parsed.Profiles[env]; ok {
+		override, err := yaml.Marshal(&node)
+		if err != nil {
+			return err
+		}
+		decoder := yaml.NewDecoder(bytes.NewReader(override))
+		decoder.KnownFields(true)
+		if err := decoder.Decode(&parsed.Config); err != nil {
+			return fmt.Errorf("profiles.%s: %w", env, err)
+		}
+	}
+
+	*cfg = parsed.Config
+	// The profile is fixed before the file is read
+	cfg.Env = env
+	return nil
+}
+
+// applyEnv overrides the fields tagged with env by the variables that are
+// set. Lists are separated by commas.
+func applyEnv(v reflect.Value) error {
+	var errs []error
+	for i := 0; i < v.NumField(); i++ {
+		field, value := v.Type().Field(i), v.Field(i)
+		if field.Type.Kind() == reflect.Struct {
+			if err := applyEnv(value); err != nil {
+				errs = append(errs, err)
+			}
+			continue
+		}
+
+		name := field.Tag.Get("env")
+		raw, ok := os.LookupEnv(name)
+		if name == "" || !ok || raw == "" {
+			continue
+		}
+
+		switch {
+		case field.Type == reflect.TypeOf(time.Duration(0)):
+			duration, err := time.ParseDuration(raw)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s must be a duration such as 30s, got %q", name, raw))
+				continue
+			}
+			value.SetInt(int64(duration))
+		case field.Type.Kind() == reflect.String:
+			value.SetString(raw)
+		case field.Type.Kind() == reflect.Int:
+			number, err := strconv.Atoi(raw)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s must be a whole number, got %q", name, raw))
+				continue
+			}
+			value.SetInt(int64(number))
+		case field.Type.Kind() == reflect.Bool:
+			enabled, err := strconv.ParseBool(raw)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s must be true or false, got %q", name, raw))
+				continue
+			}
+			value.SetBool(enabled)
+		case field.Type == reflect.TypeOf([]string(nil)):
+			var items []string
+			for _, item := range strings.Split(raw, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+			value.Set(reflect.ValueOf(items))
+		default:
+			errs = append(errs, fmt.Errorf("%s: unsupported setting type %s", name, field.Type))
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -1,48 +1,47 @@
 package config
 
 import (
-	"log"
-	"os"
+	"errors"
+	"log/slog"
 
-	"github.com/joho/godotenv"
 	"github.com/midtrans/midtrans-go"
 	"github.com/midtrans/midtrans-go/snap"
 )
 
+// Midtrans environments
+const (
+	MidtransSandbox    = "sandbox"
+	MidtransProduction = "production"
+)
+
+// MidtransConfig holds the Midtrans keys and, once initialized, the Snap
+// client
 type MidtransConfig struct {
-	SnapClient *snap.Client
-	ServerKey  string
-	ClientKey  string
-	BaseURL    string
-}
+	Environment string `yaml:"environment" env:"MIDTRANS_ENV"`
+	ServerKey   Secret `yaml:"server_key" env:"MIDTRANS_SERVER_KEY"`
+	ClientKey   Secret `yaml:"client_key" env:"MIDTRANS_CLIENT_KEY"`
+	BaseURL     string `yaml:"base_url" env:"BASE_URL"`
 
-var GlobalMidtransConfig *MidtransConfig
+	SnapClient *snap.Client `yaml:"-" json:"-"`
+}
 
-func LoadMidtransConfig() {
-	// Load environment variables
-	err := godotenv.Load()
-	if err != nil {
-		log.Println("Warning: .env file not found, using system environment variables")
+// InitializeMidtrans creates the Snap client for the configured environment
+// and returns the configuration holding it
+func InitializeMidtrans(cfg MidtransConfig) (*MidtransConfig, error) {
+	if cfg.ServerKey == "" || cfg.ClientKey == "" {
+		return nil, errors.New("Midtrans server and client keys are required")
 	}
 
-	serverKey := os.Getenv("MIDTRANS_SERVER_KEY")
-	clientKey := os.Getenv("MIDTRANS_CLIENT_KEY")
-	baseURL := os.Getenv("BASE_URL")
-
-	if serverKey == "" || clientKey == "" || baseURL == "" {
-		log.Fatal("Midtrans configuration missing: ensure MIDTRANS_SERVER_KEY, MIDTRANS_CLIENT_KEY, and BASE_URL are set")
+	env := midtrans.Sandbox
+	if cfg.Environment == MidtransProduction {
+		env = midtrans.Production
 	}
 
 	// Initialize Snap Client
 	snapClient := snap.Client{}
-	snapClient.New(serverKey, midtrans.Sandbox)
-
-	GlobalMidtransConfig = &MidtransConfig{
-		SnapClient: &snapClient,
-		ServerKey:  serverKey,
-		ClientKey:  clientKey,
-		BaseURL:    baseURL,
-	}
+	snapClient.New(cfg.ServerKey.Value(), env)
+	cfg.SnapClient = &snapClient
 
-	log.Println("Midtrans Snap Client initialized successfully")
+	slog.Info("Midtrans Snap client initialized", "environment", cfg.Environment)
+	return &cfg, nil
 }
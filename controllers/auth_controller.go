@@ -1,49 +1,45 @@
 package controllers
 
 import (
-	"context"
+	"errors"
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/auth"
 	"golang-firebase-backend/config"
+	"golang-firebase-backend/logging"
 	"golang-firebase-backend/models"
+	"golang-firebase-backend/ratelimit"
+	"golang-firebase-backend/sessions"
 	"golang-firebase-backend/utils"
+	"golang-firebase-backend/validate"
 	"net/http"
 	"time"
 )
 
 func LoginWithGoogle(w http.ResponseWriter, r *http.Request) {
-	idToken := r.Header.Get("Authorization")
+	ctx := r.Context()
 
-	// Extract the token part if prefixed with "Bearer "
-	if len(idToken) > 7 && idToken[:7] == "Bearer " {
-		idToken = idToken[7:]
-	}
-
-	if idToken == "" {
-		utils.RespondError(w, http.StatusBadRequest, "ID Token is required")
-		return
-	}
-
-	ctx := context.Background()
-
-	// Initialize Firebase Auth
-	authClient, err := config.FirebaseApp.Auth(ctx)
-	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Auth")
+	// The ID token was verified by the auth middleware
+	principal, ok := auth.FromContext(ctx)
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "ID Token is required")
 		return
 	}
-
-	// Verify the ID token
-	token, err := authClient.VerifyIDToken(ctx, idToken)
-	if err != nil {
-		utils.RespondError(w, http.StatusUnauthorized, "Invalid ID Token")
-		return
+	idToken, _ := auth.BearerToken(r)
+	uid := principal.UID
+
+	// Device details are optional
+	var input models.LoginInput
+	if r.ContentLength != 0 {
+		if err := validate.Decode(w, r, &input); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
 	}
 
-	uid := token.UID
-
 	// Initialize Firebase Database
-	dbClient, err := config.FirebaseApp.Database(ctx)
+	dbClient, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
@@ -53,9 +49,9 @@ func LoginWithGoogle(w http.ResponseWriter, r *http.Request) {
 	// Try to fetch the user from the database
 	if err := userRef.Get(ctx, &user); err != nil || user.Email == "" {
 		// If user data doesn't exist, fetch user data from Firebase Auth
-		authUser, err := authClient.GetUser(ctx, uid)
+		authUser, err := auth.Default().Client().GetUser(ctx, uid)
 		if err != nil {
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch user details")
+			apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch user details")
 			return
 		}
 
@@ -70,11 +66,27 @@ func LoginWithGoogle(w http.ResponseWriter, r *http.Request) {
 
 		// Save the new user data in Firebase Database
 		if err := userRef.Set(ctx, user); err != nil {
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to save user data")
+			apierror.Respond(w, r, http.StatusInternalServerError, "Failed to save user data")
 			return
 		}
 	}
 
+	session, _, err := sessions.Record(ctx, principal, sessions.Device{
+		Name:      input.DeviceName,
+		Platform:  input.Platform,
+		IP:        ratelimit.RequestIP(r),
+		UserAgent: r.UserAgent(),
+	})
+	if errors.Is(err, auth.ErrSessionSignedOut) {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Session was signed out")
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to record session")
+		return
+	}
+	session.Current = true
+
 	loginTime := time.Now().Format(time.RFC3339)
 
 	// Include Google data, token, and login time in the response
@@ -91,42 +103,38 @@ func LoginWithGoogle(w http.ResponseWriter, r *http.Request) {
 			"language":     user.Language,
 			"role":         user.Role,
 		},
-		"token": idToken,
+		"token":   idToken,
+		"session": session,
 	})
 }
 
+// Logout signs out the current session. With ?scope=all it revokes the
+// refresh tokens of the user, which signs out every device.
 func Logout(w http.ResponseWriter, r *http.Request) {
-	idToken := r.Header.Get("Authorization")
-
-	// Extract the token part if prefixed with "Bearer "
-	if len(idToken) > 7 && idToken[:7] == "Bearer " {
-		idToken = idToken[7:]
-	}
-
-	if idToken == "" {
-		utils.RespondError(w, http.StatusBadRequest, "ID Token is required")
-		return
-	}
-
-	ctx := context.Background()
-
-	// Initialize Firebase Auth
-	authClient, err := config.FirebaseApp.Auth(ctx)
-	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Auth")
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "ID Token is required")
 		return
 	}
 
-	// Verify the ID token
-	token, err := authClient.VerifyIDToken(ctx, idToken)
-	if err != nil {
-		utils.RespondError(w, http.StatusUnauthorized, "Invalid ID Token")
-		return
-	}
-
-	// Revoke the refresh tokens for the user
-	if err := authClient.RevokeRefreshTokens(ctx, token.UID); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to logout user")
+	switch r.URL.Query().Get("scope") {
+	case "", "current":
+		if err := sessions.End(r.Context(), principal); err != nil {
+			apierror.Respond(w, r, http.StatusInternalServerError, "Failed to logout user")
+			return
+		}
+		auth.Default().ForgetSession(principal.UID, principal.SessionID)
+	case "all":
+		// Revoke the refresh tokens for the user
+		if err := auth.Default().Revoke(r.Context(), principal.UID); err != nil {
+			apierror.Respond(w, r, http.StatusInternalServerError, "Failed to logout user")
+			return
+		}
+		if _, err := sessions.SignOutOthers(r.Context(), principal.UID, ""); err != nil {
+			logging.From(r.Context()).Warn("failed to sign out sessions", "error", err)
+		}
+	default:
+		apierror.Respond(w, r, http.StatusBadRequest, "scope must be current or all")
 		return
 	}
 
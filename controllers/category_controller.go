@@ -1,31 +1,32 @@
 package controllers
 
 import (
-	"context"
-	"encoding/json"
-	"fmt"
 	"net/http"
 
+	"golang-firebase-backend/apierror"
 	"golang-firebase-backend/config"
+	"golang-firebase-backend/etag"
+	"golang-firebase-backend/mergepatch"
 	"golang-firebase-backend/models"
 	"golang-firebase-backend/utils"
+	"golang-firebase-backend/validate"
 
 	"github.com/google/uuid"
 )
 
 // Fetch all categories
 func FetchCategories(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
-	client, err := config.FirebaseApp.Database(ctx)
+	ctx := r.Context()
+	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
 	var categories map[string]models.Category
 	ref := client.NewRef("categories")
 	if err := ref.Get(ctx, &categories); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch categories")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch categories")
 		return
 	}
 
@@ -43,56 +44,52 @@ func ShowCategory(w http.ResponseWriter, r *http.Request) {
 	var requestBody struct {
 		Id string `json:"id,omitempty"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, "Invalid input")
-		return
+	requestBody.Id = r.PathValue("id")
+	if requestBody.Id == "" {
+		if err := validate.Decode(w, r, &requestBody); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
 	}
 
 	if requestBody.Id == "" {
-		utils.RespondError(w, http.StatusUnprocessableEntity, "Category ID is required")
+		apierror.Respond(w, r, http.StatusUnprocessableEntity, "Category ID is required")
 		return
 	}
 
-	ctx := context.Background()
-	client, err := config.FirebaseApp.Database(ctx)
+	ctx := r.Context()
+	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
 	var category models.Category
 	ref := client.NewRef("categories/" + requestBody.Id)
-	if err := ref.Get(ctx, &category); err != nil {
-		utils.RespondError(w, http.StatusNotFound, "Category not found")
+	tag, err := etag.Get(ctx, ref, &category)
+	if err != nil {
+		apierror.Respond(w, r, http.StatusNotFound, "Category not found")
 		return
 	}
 
 	category.IdCategory = requestBody.Id
+	etag.Set(w, tag)
 	utils.RespondJSON(w, http.StatusOK, category)
 }
 
 func CreateCategory(w http.ResponseWriter, r *http.Request) {
-	var categoryInput struct {
-		Title      string `json:"title"`
-		PhotoUrl   string `json:"photo_url"`
-		TitleMajor string `json:"title_major"`
-	}
+	var categoryInput models.CategoryInput
 
-	if err := json.NewDecoder(r.Body).Decode(&categoryInput); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, "Invalid input")
+	if err := validate.Decode(w, r, &categoryInput); err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
 	// Validasi input
-	if categoryInput.Title == "" || categoryInput.PhotoUrl == "" || categoryInput.TitleMajor == "" {
-		utils.RespondError(w, http.StatusUnprocessableEntity, "Title, PhotoUrl, and TitleMajor are required")
-		return
-	}
-
-	ctx := context.Background()
-	client, err := config.FirebaseApp.Database(ctx)
+	ctx := r.Context()
+	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
@@ -100,7 +97,7 @@ func CreateCategory(w http.ResponseWriter, r *http.Request) {
 	var majors map[string]models.Major
 	refMajors := client.NewRef("majors")
 	if err := refMajors.Get(ctx, &majors); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch majors")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch majors")
 		return
 	}
 
@@ -113,7 +110,7 @@ func CreateCategory(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if idMajor == "" {
-		utils.RespondError(w, http.StatusBadRequest, "TitleMajor not found")
+		apierror.Respond(w, r, http.StatusBadRequest, "TitleMajor not found")
 		return
 	}
 
@@ -127,7 +124,7 @@ func CreateCategory(w http.ResponseWriter, r *http.Request) {
 	id := uuid.New().String()
 	ref := client.NewRef("categories/" + id)
 	if err := ref.Set(ctx, &category); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to create category")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to create category")
 		return
 	}
 
@@ -144,26 +141,29 @@ func DeleteCategory(w http.ResponseWriter, r *http.Request) {
 	var requestBody struct {
 		Id string `json:"id"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, "Invalid input")
-		return
+	requestBody.Id = r.PathValue("id")
+	if requestBody.Id == "" {
+		if err := validate.Decode(w, r, &requestBody); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
 	}
 
 	if requestBody.Id == "" {
-		utils.RespondError(w, http.StatusUnprocessableEntity, "Category ID is required")
+		apierror.Respond(w, r, http.StatusUnprocessableEntity, "Category ID is required")
 		return
 	}
 
-	ctx := context.Background()
-	client, err := config.FirebaseApp.Database(ctx)
+	ctx := r.Context()
+	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
 	ref := client.NewRef("categories/" + requestBody.Id)
 	if err := ref.Delete(ctx); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to delete category")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to delete category")
 		return
 	}
 
@@ -173,32 +173,33 @@ func DeleteCategory(w http.ResponseWriter, r *http.Request) {
 	})
 }
 func UpdateCategory(w http.ResponseWriter, r *http.Request) {
-	idCategory := r.URL.Query().Get("id_category")
+	idCategory := utils.Param(r, "id", "id_category")
 	if idCategory == "" {
-		utils.RespondError(w, http.StatusUnprocessableEntity, "Category ID is required")
+		apierror.Respond(w, r, http.StatusUnprocessableEntity, "Category ID is required")
 		return
 	}
 
-	var requestBody struct {
-		Title    string `json:"title,omitempty"`
-		PhotoUrl string `json:"photo_url,omitempty"`
-		IdMajor  string `json:"id_major,omitempty"`
+	if mergepatch.Requested(r) {
+		patchCategory(w, r, idCategory)
+		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, "Invalid input")
+	var requestBody models.CategoryUpdateInput
+
+	if err := validate.Decode(w, r, &requestBody); err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
 	if requestBody.Title == "" && requestBody.PhotoUrl == "" && requestBody.IdMajor == "" {
-		utils.RespondError(w, http.StatusUnprocessableEntity, "At least one field (Title, PhotoUrl, IdMajor) must be provided")
+		apierror.Respond(w, r, http.StatusUnprocessableEntity, "At least one field (Title, PhotoUrl, IdMajor) must be provided")
 		return
 	}
 
-	ctx := context.Background()
-	client, err := config.FirebaseApp.Database(ctx)
+	ctx := r.Context()
+	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
@@ -207,19 +208,17 @@ func UpdateCategory(w http.ResponseWriter, r *http.Request) {
 	// Fetch existing data
 	var existingCategory map[string]interface{}
 	if err := ref.Get(ctx, &existingCategory); err != nil {
-		utils.RespondError(w, http.StatusNotFound, "Category not found")
+		apierror.Respond(w, r, http.StatusNotFound, "Category not found")
 		return
 	}
 
-	// Hapus field `IdMajor` jika ada
-	if _, ok := existingCategory["IdMajor"]; ok {
-		if err := ref.Child("IdMajor").Delete(ctx); err != nil {
-			fmt.Println("Failed to delete old IdMajor field:", err)
-		}
-	}
-
 	// Prepare update data
 	updateData := make(map[string]interface{})
+
+	// Hapus field `IdMajor` lama jika ada
+	if _, ok := existingCategory["IdMajor"]; ok {
+		updateData["IdMajor"] = nil
+	}
 	if requestBody.Title != "" {
 		updateData["title"] = requestBody.Title
 	}
@@ -227,16 +226,21 @@ func UpdateCategory(w http.ResponseWriter, r *http.Request) {
 		updateData["photo_url"] = requestBody.PhotoUrl
 	}
 	if requestBody.IdMajor != "" {
+		if !majorExists(w, r, requestBody.IdMajor) {
+			return
+		}
 		updateData["id_major"] = requestBody.IdMajor
 	}
 
-	// Update Firebase
-	if err := ref.Update(ctx, updateData); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to update category")
+	// Update Firebase; If-Match dicek di dalam transaksi
+	tag, err := etag.Update(ctx, r, ref, updateData)
+	if err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
 	// Response JSON
+	etag.Set(w, tag)
 	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
 		"data": map[string]interface{}{
@@ -248,3 +252,69 @@ func UpdateCategory(w http.ResponseWriter, r *http.Request) {
 		"message": "Category updated successfully",
 	})
 }
+
+// patchCategory applies a JSON merge patch to a category
+func patchCategory(w http.ResponseWriter, r *http.Request, idCategory string) {
+	patch, err := mergepatch.Decode(w, r, "title", "photo_url", "id_major")
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	client, err := config.Database(ctx)
+	if err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		return
+	}
+
+	ref := client.NewRef("categories/" + idCategory)
+	var category models.Category
+	if err := ref.Get(ctx, &category); err != nil || category.Title == "" {
+		apierror.Respond(w, r, http.StatusNotFound, "Category not found")
+		return
+	}
+
+	if err := mergepatch.ApplyTo(&category, patch); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	if patch.Has("id_major") && !majorExists(w, r, category.IdMajor) {
+		return
+	}
+
+	tag, err := etag.Update(ctx, r, ref, mergepatch.Updates(patch))
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	category.IdCategory = idCategory
+	etag.Set(w, tag)
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    category,
+		"message": "Category updated successfully",
+	})
+}
+
+// majorExists reports whether the major a category points to exists, and
+// responds with an error when it does not
+func majorExists(w http.ResponseWriter, r *http.Request, idMajor string) bool {
+	client, err := config.Database(r.Context())
+	if err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		return false
+	}
+
+	var major models.Major
+	if err := client.NewRef("majors/"+idMajor).Get(r.Context(), &major); err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch major")
+		return false
+	}
+	if major.TitleMajor == "" {
+		apierror.Write(w, r, apierror.Validation("Invalid input").WithField("id_major", "does not match a major"))
+		return false
+	}
+	return true
+}
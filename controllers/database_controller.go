@@ -1,26 +1,33 @@
 package controllers
 
 import (
-	"context"
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/auth"
 	"golang-firebase-backend/config"
+	"golang-firebase-backend/models"
 	"golang-firebase-backend/utils"
 	"net/http"
 )
 
 func GetData(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
-	client, err := config.FirebaseApp.Database(ctx)
+	ctx := r.Context()
+	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
 	ref := client.NewRef("users")
-	var data map[string]interface{}
-	if err := ref.Get(ctx, &data); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch data")
+	var users map[string]models.User
+	if err := ref.Get(ctx, &users); err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch data")
 		return
 	}
 
+	data := make(map[string]models.UserProfile, len(users))
+	for uid, user := range users {
+		user.UID = uid
+		data[uid] = user.Profile(auth.View(ctx, uid))
+	}
 	utils.RespondJSON(w, http.StatusOK, data)
 }
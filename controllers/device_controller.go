@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"net/http"
+
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/auth"
+	"golang-firebase-backend/models"
+	"golang-firebase-backend/notify"
+	"golang-firebase-backend/utils"
+	"golang-firebase-backend/validate"
+)
+
+// RegisterDevice - POST /user/devices/register
+func RegisterDevice(w http.ResponseWriter, r *http.Request) {
+	uid, ok := auth.UID(r.Context())
+	if !ok || uid == "" {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
+		return
+	}
+
+	var device models.DeviceToken
+	if err := validate.Decode(w, r, &device); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	saved, err := notify.RegisterDevice(r.Context(), uid, device)
+	if err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to register device")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    saved,
+		"message": "Device registered successfully",
+	})
+}
+
+// UnregisterDevice - POST /user/devices/unregister
+func UnregisterDevice(w http.ResponseWriter, r *http.Request) {
+	uid, ok := auth.UID(r.Context())
+	if !ok || uid == "" {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
+		return
+	}
+
+	var requestBody struct {
+		Token string `json:"token" validate:"required"`
+	}
+	requestBody.Token = r.PathValue("token")
+	if requestBody.Token == "" {
+		if err := validate.Decode(w, r, &requestBody); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+	}
+
+	if err := notify.UnregisterDevice(r.Context(), uid, requestBody.Token); err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to unregister device")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Device unregistered successfully",
+	})
+}
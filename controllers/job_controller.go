@@ -0,0 +1,69 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/jobs"
+	"golang-firebase-backend/utils"
+	"golang-firebase-backend/validate"
+)
+
+// FetchJobs lists background jobs by status ("pending", "running" or "dead")
+func FetchJobs(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	if status == "" {
+		status = jobs.StatusDead
+	}
+	if status != jobs.StatusPending && status != jobs.StatusRunning && status != jobs.StatusDead {
+		apierror.Respond(w, r, http.StatusBadRequest, "Status must be one of pending, running or dead")
+		return
+	}
+
+	jobList, err := jobs.List(r.Context(), status)
+	if err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch jobs")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    jobList,
+	})
+}
+
+// RetryJob puts a dead-lettered job back into the outbox
+func RetryJob(w http.ResponseWriter, r *http.Request) {
+	var requestBody struct {
+		ID string `json:"id"`
+	}
+	requestBody.ID = r.PathValue("id")
+	if requestBody.ID == "" {
+		if err := validate.Decode(w, r, &requestBody); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+	}
+
+	if requestBody.ID == "" {
+		apierror.Respond(w, r, http.StatusUnprocessableEntity, "Job ID is required")
+		return
+	}
+
+	job, err := jobs.Retry(r.Context(), requestBody.ID)
+	if errors.Is(err, jobs.ErrJobNotFound) {
+		apierror.Respond(w, r, http.StatusNotFound, "Job not found")
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to retry job")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    job,
+		"message": "Job requeued successfully",
+	})
+}
@@ -1,36 +1,35 @@
 package controllers
 
 import (
-	"context"
-	"encoding/json"
-	"fmt"
 	"net/http"
 
+	"golang-firebase-backend/apierror"
 	"golang-firebase-backend/config"
+	"golang-firebase-backend/logging"
 	"golang-firebase-backend/models"
 	"golang-firebase-backend/utils"
+	"golang-firebase-backend/validate"
 
 	"github.com/google/uuid"
 )
 
 // Fetch all majors
 func FetchMajors(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
-	client, err := config.FirebaseApp.Database(ctx)
+	ctx := r.Context()
+	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
 	var majors map[string]models.Major
 	ref := client.NewRef("majors")
 	if err := ref.Get(ctx, &majors); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch majors")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch majors")
 		return
 	}
 
-	// Log majors fetched from Firebase
-	fmt.Println("Fetched majors:", majors)
+	logging.From(r.Context()).Debug("fetched majors", "count", len(majors))
 
 	var majorList []models.Major
 	for id, major := range majors {
@@ -46,15 +45,18 @@ func ShowMajor(w http.ResponseWriter, r *http.Request) {
 		TitleMajor string `json:"title_major,omitempty"`
 		IdMajor    string `json:"id_major,omitempty"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, "Invalid input")
-		return
+	requestBody.IdMajor = r.PathValue("id")
+	if requestBody.IdMajor == "" {
+		if err := validate.Decode(w, r, &requestBody); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
 	}
 
-	ctx := context.Background()
-	client, err := config.FirebaseApp.Database(ctx)
+	ctx := r.Context()
+	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
@@ -62,7 +64,7 @@ func ShowMajor(w http.ResponseWriter, r *http.Request) {
 	var majors map[string]models.Major
 	refMajors := client.NewRef("majors")
 	if err := refMajors.Get(ctx, &majors); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch majors")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch majors")
 		return
 	}
 
@@ -89,7 +91,7 @@ func ShowMajor(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if !found {
-		utils.RespondError(w, http.StatusNotFound, "Major not found")
+		apierror.Respond(w, r, http.StatusNotFound, "Major not found")
 		return
 	}
 
@@ -97,7 +99,7 @@ func ShowMajor(w http.ResponseWriter, r *http.Request) {
 	var categories map[string]models.Category
 	refCategories := client.NewRef("categories")
 	if err := refCategories.Get(ctx, &categories); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch categories")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch categories")
 		return
 	}
 
@@ -108,7 +110,7 @@ func ShowMajor(w http.ResponseWriter, r *http.Request) {
 			var services map[string]models.Service
 			refServices := client.NewRef("services")
 			if err := refServices.Get(ctx, &services); err != nil {
-				utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch services")
+				apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch services")
 				return
 			}
 
@@ -139,27 +141,22 @@ func ShowMajor(w http.ResponseWriter, r *http.Request) {
 // Create a new major
 func CreateMajor(w http.ResponseWriter, r *http.Request) {
 	var major models.Major
-	if err := json.NewDecoder(r.Body).Decode(&major); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, "Invalid input")
-		return
-	}
-
-	if major.TitleMajor == "" || major.IconUrl == "" {
-		utils.RespondError(w, http.StatusUnprocessableEntity, "TitleMajor and IconUrl are required")
+	if err := validate.Decode(w, r, &major); err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
-	ctx := context.Background()
-	client, err := config.FirebaseApp.Database(ctx)
+	ctx := r.Context()
+	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
 	id := uuid.New().String()
 	ref := client.NewRef("majors/" + id)
 	if err := ref.Set(ctx, &major); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to create major")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to create major")
 		return
 	}
 
@@ -176,26 +173,29 @@ func DeleteMajor(w http.ResponseWriter, r *http.Request) {
 	var requestBody struct {
 		Id string `json:"idMajor"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, "Invalid input")
-		return
+	requestBody.Id = r.PathValue("id")
+	if requestBody.Id == "" {
+		if err := validate.Decode(w, r, &requestBody); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
 	}
 
 	if requestBody.Id == "" {
-		utils.RespondError(w, http.StatusUnprocessableEntity, "Major ID is required")
+		apierror.Respond(w, r, http.StatusUnprocessableEntity, "Major ID is required")
 		return
 	}
 
-	ctx := context.Background()
-	client, err := config.FirebaseApp.Database(ctx)
+	ctx := r.Context()
+	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
 	ref := client.NewRef("majors/" + requestBody.Id)
 	if err := ref.Delete(ctx); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to delete major")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to delete major")
 		return
 	}
 
@@ -207,25 +207,22 @@ func DeleteMajor(w http.ResponseWriter, r *http.Request) {
 
 func UpdateMajor(w http.ResponseWriter, r *http.Request) {
 	// Get id_major from query parameters
-	idMajor := r.URL.Query().Get("id_major")
+	idMajor := utils.Param(r, "id", "id_major")
 	if idMajor == "" {
-		utils.RespondError(w, http.StatusUnprocessableEntity, "Major ID is required")
+		apierror.Respond(w, r, http.StatusUnprocessableEntity, "Major ID is required")
 		return
 	}
 
-	var requestBody struct {
-		TitleMajor string `json:"title_major,omitempty"`
-		IconUrl    string `json:"icon_url,omitempty"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, "Invalid input")
+	var requestBody models.MajorUpdateInput
+	if err := validate.Decode(w, r, &requestBody); err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
-	ctx := context.Background()
-	client, err := config.FirebaseApp.Database(ctx)
+	ctx := r.Context()
+	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
@@ -235,7 +232,7 @@ func UpdateMajor(w http.ResponseWriter, r *http.Request) {
 	// Check if the major exists
 	var existingMajor models.Major
 	if err := ref.Get(ctx, &existingMajor); err != nil {
-		utils.RespondError(w, http.StatusNotFound, "Major not found")
+		apierror.Respond(w, r, http.StatusNotFound, "Major not found")
 		return
 	}
 
@@ -249,7 +246,7 @@ func UpdateMajor(w http.ResponseWriter, r *http.Request) {
 
 	// Save updated major back to Firebase
 	if err := ref.Set(ctx, &existingMajor); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to update major")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to update major")
 		return
 	}
 
@@ -1,12 +1,14 @@
 package controllers
 
 import (
-	"context"
-	"encoding/json"
-	"fmt"
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/auth"
 	"golang-firebase-backend/config"
+	"golang-firebase-backend/jobs"
 	"golang-firebase-backend/models"
+	"golang-firebase-backend/notify"
 	"golang-firebase-backend/utils"
+	"golang-firebase-backend/validate"
 	"net/http"
 	"time"
 
@@ -23,17 +25,17 @@ func generateConversationID(user1, user2 string) string {
 
 // / FetchConversations fetches all conversations for a given user
 func FetchConversations(w http.ResponseWriter, r *http.Request) {
-	uid := r.Context().Value("uid")
-	if uid == nil {
-		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized access")
+	uid, ok := auth.UID(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
 		return
 	}
 
 	// Initialize Firebase database
-	ctx := context.Background()
+	ctx := r.Context()
 	dbClient, err := config.Database(ctx)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error initializing Firebase database: %v", err), http.StatusInternalServerError)
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
@@ -42,7 +44,7 @@ func FetchConversations(w http.ResponseWriter, r *http.Request) {
 	var allConversations map[string]map[string]interface{}
 	err = ref.Get(ctx, &allConversations)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error fetching conversations: %v", err), http.StatusInternalServerError)
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch conversations")
 		return
 	}
 
@@ -90,54 +92,42 @@ func FetchConversations(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// Return the filtered conversations
-	w.Header().Set("Content-Type", "application/json")
-	if len(userConversations) == 0 {
-		// Return an empty array if no conversations are found
-		w.WriteHeader(http.StatusOK)
-		if err := json.NewEncoder(w).Encode(map[string]interface{}{
-			"success": true,
-			"data":    []interface{}{}, // Empty array
-		}); err != nil {
-			http.Error(w, fmt.Sprintf("Error encoding response: %v", err), http.StatusInternalServerError)
-		}
-		return
+	// Return the filtered conversations, or an empty array if none are found
+	if userConversations == nil {
+		userConversations = []map[string]interface{}{}
 	}
 
-	if err := json.NewEncoder(w).Encode(map[string]interface{}{
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
 		"data":    userConversations,
-	}); err != nil {
-		http.Error(w, fmt.Sprintf("Error encoding response: %v", err), http.StatusInternalServerError)
-	}
+	})
 }
 
 // FetchMessages fetches all messages in a conversation
 func FetchMessages(w http.ResponseWriter, r *http.Request) {
-	uid := r.Context().Value("uid")
-	if uid == nil {
-		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized access")
+	if _, ok := auth.UID(r.Context()); !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
 		return
 	}
 
-	conversationID := r.URL.Query().Get("conversationID")
+	conversationID := utils.Param(r, "conversationID", "conversationID")
 	if conversationID == "" {
-		utils.RespondError(w, http.StatusBadRequest, "ConversationID is required")
+		apierror.Respond(w, r, http.StatusBadRequest, "ConversationID is required")
 		return
 	}
 
 	// Initialize Firebase database
-	ctx := context.Background()
+	ctx := r.Context()
 	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
 	var messages map[string]models.Message
 	ref := client.NewRef("messages/" + conversationID)
 	if err := ref.Get(ctx, &messages); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch messages")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch messages")
 		return
 	}
 
@@ -155,54 +145,71 @@ func FetchMessages(w http.ResponseWriter, r *http.Request) {
 
 // SendMessage sends a new message in a conversation
 func SendMessage(w http.ResponseWriter, r *http.Request) {
-	uid := r.Context().Value("uid")
-	if uid == nil {
-		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized access")
+	uid, ok := auth.UID(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
 		return
 	}
 
 	var message models.Message
-	if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, "Invalid input")
+	if err := validate.Decode(w, r, &message); err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
-	message.SenderID = uid.(string)
+	message.SenderID = uid
 	message.Timestamp = time.Now()
 	message.IsRead = false
 
 	conversationID := generateConversationID(message.SenderID, message.ReceiverID)
 
 	// Initialize Firebase database
-	ctx := context.Background()
+	ctx := r.Context()
 	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
+	// Save the message, update the conversation with the latest message and
+	// queue the receiver's push in one atomic update
 	messageID := uuid.New().String()
-	ref := client.NewRef("messages/" + conversationID + "/" + messageID)
-	if err := ref.Set(ctx, &message); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to send message")
-		return
+	updates := map[string]interface{}{
+		"messages/" + conversationID + "/" + messageID: &message,
+		"conversations/" + conversationID: map[string]interface{}{
+			"lastMessageId": messageID,
+			"lastMessage": map[string]interface{}{
+				"senderID":       message.SenderID,
+				"messageContent": message.MessageContent,
+				"timestamp":      message.Timestamp,
+			},
+			"participants": []string{message.SenderID, message.ReceiverID},
+			"updatedAt":    time.Now(),
+		},
 	}
 
-	// Update conversation with the latest message
-	convRef := client.NewRef("conversations/" + conversationID)
-	if err := convRef.Set(ctx, map[string]interface{}{
-		"lastMessageId": messageID,
-		"lastMessage": map[string]interface{}{
-			"senderID":       message.SenderID,
-			"messageContent": message.MessageContent,
-			"timestamp":      message.Timestamp,
+	var senderName string
+	if err := client.NewRef("users/"+message.SenderID+"/name").Get(ctx, &senderName); err != nil || senderName == "" {
+		senderName = "New message"
+	}
+	if err := notify.Stage(updates, message.ReceiverID, models.Notification{
+		Type:  notify.TypeNewMessage,
+		Title: senderName,
+		Body:  message.MessageContent,
+		Data: map[string]string{
+			"conversation_id": conversationID,
+			"message_id":      messageID,
 		},
-		"participants": []string{message.SenderID, message.ReceiverID},
-		"updatedAt":    time.Now(),
 	}); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to update conversation")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to queue message notification")
+		return
+	}
+
+	if err := client.NewRef("").Update(ctx, updates); err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to send message")
 		return
 	}
+	jobs.Notify()
 
 	utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
 		"success": true,
@@ -215,35 +222,28 @@ func SendMessage(w http.ResponseWriter, r *http.Request) {
 }
 func CreateChatRoom(w http.ResponseWriter, r *http.Request) {
 	// Extract user ID from the context
-	uid := r.Context().Value("uid")
-	if uid == nil {
-		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized access")
+	uid, ok := auth.UID(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
 		return
 	}
 
 	// Decode the request body to get the other participant's ID
-	var payload struct {
-		ParticipantID string `json:"participantID"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, "Invalid input")
-		return
-	}
-
-	if payload.ParticipantID == "" {
-		utils.RespondError(w, http.StatusBadRequest, "Participant ID is required")
+	var payload models.ChatRoomInput
+	if err := validate.Decode(w, r, &payload); err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
 	// Generate a unique conversation ID using both user IDs
-	currentUserID := uid.(string)
+	currentUserID := uid
 	conversationID := generateConversationID(currentUserID, payload.ParticipantID)
 
 	// Initialize Firebase database
-	ctx := context.Background()
+	ctx := r.Context()
 	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
@@ -275,7 +275,7 @@ func CreateChatRoom(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := convRef.Set(ctx, newChatRoom); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to create chatroom")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to create chatroom")
 		return
 	}
 
@@ -0,0 +1,230 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/auth"
+	"golang-firebase-backend/metrics"
+	"golang-firebase-backend/models"
+	"golang-firebase-backend/notify"
+	"golang-firebase-backend/utils"
+	"golang-firebase-backend/validate"
+)
+
+// streamHeartbeat keeps idle notification streams open through proxies
+const streamHeartbeat = 25 * time.Second
+
+// FetchNotifications - GET /notifications?cursor=<id>&limit=<n>
+func FetchNotifications(w http.ResponseWriter, r *http.Request) {
+	uid, ok := auth.UID(r.Context())
+	if !ok || uid == "" {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
+		return
+	}
+
+	limit := 0
+	if rawLimit := r.URL.Query().Get("limit"); rawLimit != "" {
+		parsed, err := strconv.Atoi(rawLimit)
+		if err != nil || parsed <= 0 {
+			apierror.Respond(w, r, http.StatusBadRequest, "Limit must be a positive number")
+			return
+		}
+		limit = parsed
+	}
+
+	notifications, next, err := notify.List(r.Context(), uid, r.URL.Query().Get("cursor"), limit)
+	if err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch notifications")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success":     true,
+		"data":        notifications,
+		"next_cursor": next,
+	})
+}
+
+// FetchUnreadNotificationCount - GET /notifications/unread-count
+func FetchUnreadNotificationCount(w http.ResponseWriter, r *http.Request) {
+	uid, ok := auth.UID(r.Context())
+	if !ok || uid == "" {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
+		return
+	}
+
+	count, err := notify.UnreadCount(r.Context(), uid)
+	if err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to count notifications")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data": map[string]int{
+			"unread": count,
+		},
+	})
+}
+
+// MarkNotificationRead - POST /notifications/read
+func MarkNotificationRead(w http.ResponseWriter, r *http.Request) {
+	uid, ok := auth.UID(r.Context())
+	if !ok || uid == "" {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
+		return
+	}
+
+	var requestBody struct {
+		ID string `json:"id" validate:"required"`
+	}
+	requestBody.ID = r.PathValue("id")
+	if requestBody.ID == "" {
+		if err := validate.Decode(w, r, &requestBody); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+	}
+
+	err := notify.MarkRead(r.Context(), uid, requestBody.ID)
+	if errors.Is(err, notify.ErrNotFound) {
+		apierror.Respond(w, r, http.StatusNotFound, "Notification not found")
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to mark notification as read")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Notification marked as read",
+	})
+}
+
+// MarkAllNotificationsRead - POST /notifications/read-all
+func MarkAllNotificationsRead(w http.ResponseWriter, r *http.Request) {
+	uid, ok := auth.UID(r.Context())
+	if !ok || uid == "" {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
+		return
+	}
+
+	updated, err := notify.MarkAllRead(r.Context(), uid)
+	if err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to mark notifications as read")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data": map[string]int{
+			"updated": updated,
+		},
+		"message": "All notifications marked as read",
+	})
+}
+
+// StreamNotifications - GET /notifications/stream
+// Pushes new notifications as server-sent events while the connection is open
+func StreamNotifications(w http.ResponseWriter, r *http.Request) {
+	uid, ok := auth.UID(r.Context())
+	if !ok || uid == "" {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Streaming is not supported")
+		return
+	}
+
+	notifications, cancel := notify.Subscribe(uid)
+	defer cancel()
+	metrics.Streams.Inc()
+	defer metrics.Streams.Dec()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(streamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprint(w, ": ping\n\n")
+			flusher.Flush()
+		case notification, open := <-notifications:
+			if !open {
+				return
+			}
+			payload, err := json.Marshal(notification)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "id: %s\nevent: notification\ndata: %s\n\n", notification.ID, payload)
+			flusher.Flush()
+		}
+	}
+}
+
+// NotificationSettings - GET/PUT /user/notification-settings
+func NotificationSettings(w http.ResponseWriter, r *http.Request) {
+	uid, ok := auth.UID(r.Context())
+	if !ok || uid == "" {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
+		return
+	}
+
+	ctx := r.Context()
+
+	if r.Method == http.MethodGet {
+		settings, err := notify.LoadSettings(ctx, uid)
+		if err != nil {
+			apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch notification settings")
+			return
+		}
+
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+			"success": true,
+			"data":    settings,
+		})
+		return
+	}
+
+	var settings models.NotificationSettings
+	if err := validate.Decode(w, r, &settings); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	saved, err := notify.SaveSettings(ctx, uid, settings)
+	var settingsErr *notify.SettingsError
+	if errors.As(err, &settingsErr) {
+		apierror.Respond(w, r, http.StatusUnprocessableEntity, settingsErr.Error())
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to save notification settings")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    saved,
+		"message": "Notification settings updated successfully",
+	})
+}
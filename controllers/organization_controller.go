@@ -0,0 +1,169 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/models"
+	"golang-firebase-backend/organizations"
+	"golang-firebase-backend/utils"
+	"golang-firebase-backend/validate"
+)
+
+const (
+	defaultOrganizationMatches = 5
+	maxOrganizationMatches     = 20
+)
+
+// FetchOrganizations - GET /api/v1/organizations
+func FetchOrganizations(w http.ResponseWriter, r *http.Request) {
+	orgs, err := organizations.List(r.Context())
+	if err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch organizations")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    orgs,
+	})
+}
+
+// MatchOrganizations - GET /api/v1/organizations/match?query=
+func MatchOrganizations(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query().Get("query")
+	if query == "" {
+		apierror.Respond(w, r, http.StatusBadRequest, "Search term is required")
+		return
+	}
+
+	limit := defaultOrganizationMatches
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxOrganizationMatches {
+			apierror.Write(w, r, apierror.Validation("Invalid input").
+				WithField("limit", "must be a whole number between 1 and "+strconv.Itoa(maxOrganizationMatches)))
+			return
+		}
+		limit = n
+	}
+
+	matches, err := organizations.Match(r.Context(), query, limit)
+	if err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to match organizations")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    matches,
+	})
+}
+
+// ShowOrganization - GET /api/v1/organizations/{id}
+func ShowOrganization(w http.ResponseWriter, r *http.Request) {
+	org, err := organizations.Get(r.Context(), r.PathValue("id"))
+	if err != nil {
+		writeOrganizationError(w, r, err, "Failed to fetch organization")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    org,
+	})
+}
+
+// CreateOrganization - POST /api/v1/organizations
+func CreateOrganization(w http.ResponseWriter, r *http.Request) {
+	var input models.OrganizationInput
+	if err := validate.Decode(w, r, &input); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	org, err := organizations.Create(r.Context(), input)
+	if err != nil {
+		writeOrganizationError(w, r, err, "Failed to create organization")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
+		"success": true,
+		"data":    org,
+		"message": "Organization created successfully",
+	})
+}
+
+// UpdateOrganization - PUT /api/v1/organizations/{id}
+func UpdateOrganization(w http.ResponseWriter, r *http.Request) {
+	var input models.OrganizationInput
+	if err := validate.Decode(w, r, &input); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	org, err := organizations.Update(r.Context(), r.PathValue("id"), input)
+	if err != nil {
+		writeOrganizationError(w, r, err, "Failed to update organization")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    org,
+		"message": "Organization updated successfully",
+	})
+}
+
+// DeleteOrganization - DELETE /api/v1/organizations/{id}
+func DeleteOrganization(w http.ResponseWriter, r *http.Request) {
+	if err := organizations.Delete(r.Context(), r.PathValue("id")); err != nil {
+		writeOrganizationError(w, r, err, "Failed to delete organization")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Organization deleted successfully",
+	})
+}
+
+// MigrateOrganizations - POST /api/v1/admin/organizations/migrate
+func MigrateOrganizations(w http.ResponseWriter, r *http.Request) {
+	var input models.OrganizationMigrationInput
+	if r.ContentLength != 0 {
+		if err := validate.Decode(w, r, &input); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
+	}
+
+	report, err := organizations.Migrate(r.Context(), input)
+	if err != nil {
+		writeOrganizationError(w, r, err, "Failed to migrate organizations")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    report,
+	})
+}
+
+func writeOrganizationError(w http.ResponseWriter, r *http.Request, err error, message string) {
+	switch {
+	case errors.Is(err, organizations.ErrNotFound):
+		apierror.Respond(w, r, http.StatusNotFound, "Organization not found")
+	case errors.Is(err, organizations.ErrNameTaken):
+		apierror.Write(w, r, apierror.Conflict("An organization with this name is already registered"))
+	case errors.Is(err, organizations.ErrDomainTaken):
+		apierror.Write(w, r, apierror.Conflict(err.Error()))
+	case errors.Is(err, organizations.ErrInvalidInput):
+		apierror.Write(w, r, apierror.Validation(err.Error()))
+	default:
+		apierror.Respond(w, r, http.StatusInternalServerError, message)
+	}
+}
@@ -1,12 +1,15 @@
 package controllers
 
 import (
-	"context"
-	"encoding/json"
 	"fmt"
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/auth"
 	"golang-firebase-backend/config"
+	"golang-firebase-backend/etag"
+	"golang-firebase-backend/mergepatch"
 	"golang-firebase-backend/models"
 	"golang-firebase-backend/utils"
+	"golang-firebase-backend/validate"
 	"net/http"
 
 	"github.com/google/uuid"
@@ -14,15 +17,15 @@ import (
 
 // ViewUserPortfolios - GET /user/portfolios/view
 func ViewUserPortfolios(w http.ResponseWriter, r *http.Request) {
-	uid, ok := r.Context().Value("uid").(string)
+	uid, ok := auth.UID(r.Context())
 	if !ok {
-		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized access")
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
 		return
 	}
 
-	client, err := config.FirebaseApp.Database(context.Background())
+	client, err := config.Database(r.Context())
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase database")
 		return
 	}
 
@@ -31,9 +34,9 @@ func ViewUserPortfolios(w http.ResponseWriter, r *http.Request) {
 	var portfolios map[string]models.Portfolio
 
 	// Ambil seluruh portfolio milik user
-	err = ref.Get(context.Background(), &portfolios)
+	err = ref.Get(r.Context(), &portfolios)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch user portfolios")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch user portfolios")
 		return
 	}
 
@@ -50,82 +53,85 @@ func ViewUserPortfolios(w http.ResponseWriter, r *http.Request) {
 	utils.RespondJSON(w, http.StatusOK, result)
 }
 
-// ViewPortfoliosByUID - GET /user/portfolios/view?uid=<uid>
-func ViewPortfoliosByUID(w http.ResponseWriter, r *http.Request) {
-	// Ambil parameter uid dari query string
-	uid := r.URL.Query().Get("uid")
-	if uid == "" {
-		utils.RespondError(w, http.StatusBadRequest, "UID is required as a query parameter")
+// ViewPortfolio - GET /api/v1/users/me/portfolios/{id}
+func ViewPortfolio(w http.ResponseWriter, r *http.Request) {
+	uid, ok := auth.UID(r.Context())
+	if !ok || uid == "" {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
 		return
 	}
 
-	client, err := config.FirebaseApp.Database(context.Background())
+	client, err := config.Database(r.Context())
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase database")
 		return
 	}
 
-	// Referensi ke portfolio milik user tertentu
-	ref := client.NewRef(fmt.Sprintf("portfolios/%s", uid))
-	var portfolios map[string]models.Portfolio
-
-	// Ambil seluruh portfolio milik user
-	err = ref.Get(context.Background(), &portfolios)
+	ref := client.NewRef(fmt.Sprintf("portfolios/%s/%s", uid, r.PathValue("id")))
+	var portfolio models.Portfolio
+	tag, err := etag.Get(r.Context(), ref, &portfolio)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch user portfolios")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch portfolio")
 		return
 	}
-
-	if portfolios == nil {
-		utils.RespondJSON(w, http.StatusOK, []models.Portfolio{}) // Jika tidak ada portfolio, return array kosong
+	if portfolio.ID == "" {
+		apierror.Respond(w, r, http.StatusNotFound, "Portfolio not found")
 		return
 	}
 
-	// Ubah ke slice untuk respons JSON
-	var result []models.Portfolio
-	for _, portfolio := range portfolios {
-		result = append(result, portfolio)
+	etag.Set(w, tag)
+	utils.RespondJSON(w, http.StatusOK, portfolio)
+}
+
+// ViewPortfoliosByUID - GET /user/portfolios/view?uid=<uid>
+func ViewPortfoliosByUID(w http.ResponseWriter, r *http.Request) {
+	// Ambil parameter uid dari path atau query string
+	uid := utils.Param(r, "uid", "uid")
+	if uid == "" {
+		apierror.Respond(w, r, http.StatusBadRequest, "UID is required as a query parameter")
+		return
 	}
 
-	utils.RespondJSON(w, http.StatusOK, result)
+	respondPortfolios(w, r, uid)
 }
 
+// ViewSpecificUserPortfolios - /user/portfolios/view-specific, the legacy
+// form of ViewPortfoliosByUID that takes the user in the request body
 func ViewSpecificUserPortfolios(w http.ResponseWriter, r *http.Request) {
 	// Decode request body
 	var requestBody struct {
-		UserID string `json:"userID"`
+		UserID string `json:"userID" validate:"required"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil || requestBody.UserID == "" {
-		utils.RespondError(w, http.StatusBadRequest, "UserID is required in the request body")
+	if err := validate.Decode(w, r, &requestBody); err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
-	userID := requestBody.UserID
+	respondPortfolios(w, r, requestBody.UserID)
+}
 
-	client, err := config.FirebaseApp.Database(context.Background())
+// respondPortfolios sends every portfolio of uid
+func respondPortfolios(w http.ResponseWriter, r *http.Request, uid string) {
+	client, err := config.Database(r.Context())
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase database")
 		return
 	}
 
-	// Referensi ke portfolio user tertentu
-	ref := client.NewRef(fmt.Sprintf("portfolios/%s", userID))
+	// Referensi ke portfolio milik user tertentu
+	ref := client.NewRef(fmt.Sprintf("portfolios/%s", uid))
 	var portfolios map[string]models.Portfolio
 
 	// Ambil seluruh portfolio milik user
-	err = ref.Get(context.Background(), &portfolios)
+	err = ref.Get(r.Context(), &portfolios)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch portfolios for the specified user")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch user portfolios")
 		return
 	}
 
-	if portfolios == nil {
-		portfolios = make(map[string]models.Portfolio)
-	}
-
-	// Ubah ke slice untuk respons JSON
-	var result []models.Portfolio
+	// Ubah ke slice untuk respons JSON; tanpa portfolio, return array kosong
+	result := make([]models.Portfolio, 0, len(portfolios))
 	for _, portfolio := range portfolios {
 		result = append(result, portfolio)
 	}
@@ -135,30 +141,44 @@ func ViewSpecificUserPortfolios(w http.ResponseWriter, r *http.Request) {
 
 // CreatePortfolio - POST /user/portfolios/create
 func CreatePortfolio(w http.ResponseWriter, r *http.Request) {
-	uid, ok := r.Context().Value("uid").(string)
+	uid, ok := auth.UID(r.Context())
 	if !ok {
-		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized access")
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
 		return
 	}
 
-	var portfolio models.Portfolio
-	if err := json.NewDecoder(r.Body).Decode(&portfolio); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, "Invalid request payload")
+	var input models.PortfolioInput
+	if err := validate.Decode(w, r, &input); err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
-	portfolio.ID = uuid.New().String()
-	portfolio.UserID = uid
+	portfolio := models.Portfolio{
+		ID:          uuid.New().String(),
+		UserID:      uid,
+		Title:       input.Title,
+		Description: input.Description,
+		Link:        input.Link,
+		Photo:       input.Photo,
+		Type:        input.Type,
+		Status:      input.Status,
+		DateCreated: input.DateCreated,
+		DateEnd:     input.DateEnd,
+		IsPresent:   input.IsPresent,
+	}
+	if portfolio.IsPresent {
+		portfolio.DateEnd = ""
+	}
 
-	client, err := config.FirebaseApp.Database(context.Background())
+	client, err := config.Database(r.Context())
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase database")
 		return
 	}
 
 	ref := client.NewRef("portfolios/" + uid + "/" + portfolio.ID)
-	if err := ref.Set(context.Background(), portfolio); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to create portfolio")
+	if err := ref.Set(r.Context(), portfolio); err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to create portfolio")
 		return
 	}
 
@@ -166,28 +186,37 @@ func CreatePortfolio(w http.ResponseWriter, r *http.Request) {
 }
 
 func UpdatePortfolio(w http.ResponseWriter, r *http.Request) {
-	uid, ok := r.Context().Value("uid").(string)
+	uid, ok := auth.UID(r.Context())
 	if !ok || uid == "" {
-		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized access")
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
+		return
+	}
+
+	if mergepatch.Requested(r) {
+		patchPortfolio(w, r, uid, r.PathValue("id"))
 		return
 	}
 
 	var portfolio models.Portfolio
-	if err := json.NewDecoder(r.Body).Decode(&portfolio); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, "Invalid request payload")
+	if err := validate.Decode(w, r, &portfolio); err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
+	if id := r.PathValue("id"); id != "" {
+		portfolio.ID = id
+	}
 
-	client, err := config.FirebaseApp.Database(context.Background())
+	client, err := config.Database(r.Context())
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase database")
 		return
 	}
 
-	ref := client.NewRef(fmt.Sprintf("portfolios/%s/%s", portfolio.UserID, portfolio.ID))
+	// Hanya portfolio milik user yang login yang boleh diubah
+	ref := client.NewRef(fmt.Sprintf("portfolios/%s/%s", uid, portfolio.ID))
 	var existingPortfolio models.Portfolio
-	if err := ref.Get(context.Background(), &existingPortfolio); err != nil || existingPortfolio.ID == "" {
-		utils.RespondError(w, http.StatusNotFound, "Portfolio not found")
+	if err := ref.Get(r.Context(), &existingPortfolio); err != nil || existingPortfolio.ID == "" {
+		apierror.Respond(w, r, http.StatusNotFound, "Portfolio not found")
 		return
 	}
 
@@ -201,57 +230,131 @@ func UpdatePortfolio(w http.ResponseWriter, r *http.Request) {
 	if portfolio.Link != "" {
 		existingPortfolio.Link = portfolio.Link
 	}
-	if portfolio.Photo != "" || portfolio.Photo == "" {
-		existingPortfolio.Photo = portfolio.Photo // Tetap kosong jika input kosong
+	if portfolio.Photo != "" {
+		existingPortfolio.Photo = portfolio.Photo
 	}
-	if portfolio.Type != "" || portfolio.Type == "" {
-		existingPortfolio.Type = portfolio.Type // Tetap kosong jika input kosong
+	if portfolio.Type != "" {
+		existingPortfolio.Type = portfolio.Type
 	}
-	if portfolio.Status != "" || portfolio.Status == "" {
-		existingPortfolio.Status = portfolio.Status // Tetap kosong jika input kosong
+	if portfolio.Status != "" {
+		existingPortfolio.Status = portfolio.Status
 	}
 	if portfolio.DateCreated != "" {
 		existingPortfolio.DateCreated = portfolio.DateCreated
 	}
-	if portfolio.DateEnd != "" || portfolio.DateEnd == "" {
-		existingPortfolio.DateEnd = portfolio.DateEnd // Tetap kosong jika input kosong
+	if portfolio.DateEnd != "" {
+		existingPortfolio.DateEnd = portfolio.DateEnd
 	}
+	// Portfolio yang masih berjalan tidak punya tanggal selesai
 	existingPortfolio.IsPresent = portfolio.IsPresent
+	if existingPortfolio.IsPresent {
+		existingPortfolio.DateEnd = ""
+	}
 
-	// Simpan ke Firebase
-	if err := ref.Set(context.Background(), existingPortfolio); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to update portfolio")
+	// Tanggal gabungan harus tetap valid
+	if err := validate.Struct(existingPortfolio); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	// Simpan ke Firebase; If-Match dicek di dalam transaksi
+	updates := map[string]interface{}{
+		"title":        existingPortfolio.Title,
+		"description":  existingPortfolio.Description,
+		"link":         existingPortfolio.Link,
+		"photo":        existingPortfolio.Photo,
+		"type":         existingPortfolio.Type,
+		"status":       existingPortfolio.Status,
+		"date_created": existingPortfolio.DateCreated,
+		"date_end":     existingPortfolio.DateEnd,
+		"is_present":   existingPortfolio.IsPresent,
+	}
+	tag, err := etag.Update(r.Context(), r, ref, updates)
+	if err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
+	etag.Set(w, tag)
 	utils.RespondJSON(w, http.StatusOK, existingPortfolio)
 }
 
+// patchPortfolio applies a JSON merge patch to a portfolio of the signed-in user
+func patchPortfolio(w http.ResponseWriter, r *http.Request, uid, id string) {
+	if id == "" {
+		apierror.Respond(w, r, http.StatusBadRequest, "Portfolio ID is required")
+		return
+	}
+
+	patch, err := mergepatch.Decode(w, r, "title", "description", "link", "photo", "type", "status", "date_created", "date_end", "is_present")
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	client, err := config.Database(r.Context())
+	if err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase database")
+		return
+	}
+
+	ref := client.NewRef(fmt.Sprintf("portfolios/%s/%s", uid, id))
+	var portfolio models.Portfolio
+	if err := ref.Get(r.Context(), &portfolio); err != nil || portfolio.ID == "" {
+		apierror.Respond(w, r, http.StatusNotFound, "Portfolio not found")
+		return
+	}
+
+	if err := mergepatch.ApplyTo(&portfolio, patch); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	updates := mergepatch.Updates(patch)
+	// Portfolio yang masih berjalan tidak punya tanggal selesai
+	if portfolio.IsPresent && portfolio.DateEnd != "" {
+		portfolio.DateEnd = ""
+		updates["date_end"] = nil
+	}
+
+	tag, err := etag.Update(r.Context(), r, ref, updates)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	etag.Set(w, tag)
+	utils.RespondJSON(w, http.StatusOK, portfolio)
+}
+
 // DeletePortfolio - POST /user/portfolios/delete
 func DeletePortfolio(w http.ResponseWriter, r *http.Request) {
-	uid, ok := r.Context().Value("uid").(string)
+	uid, ok := auth.UID(r.Context())
 	if !ok {
-		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized access")
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
 		return
 	}
 
 	var requestBody struct {
-		ID string `json:"id"`
+		ID string `json:"id" validate:"required"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil || requestBody.ID == "" {
-		utils.RespondError(w, http.StatusBadRequest, "Portfolio ID is required in the request body")
-		return
+	requestBody.ID = r.PathValue("id")
+	if requestBody.ID == "" {
+		if err := validate.Decode(w, r, &requestBody); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
 	}
 
-	client, err := config.FirebaseApp.Database(context.Background())
+	client, err := config.Database(r.Context())
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase database")
 		return
 	}
 
 	ref := client.NewRef(fmt.Sprintf("portfolios/%s/%s", uid, requestBody.ID))
-	if err := ref.Delete(context.Background()); err != nil {
-		utils.RespondError(w, http.StatusNotFound, "Portfolio not found or already deleted")
+	if err := ref.Delete(r.Context()); err != nil {
+		apierror.Respond(w, r, http.StatusNotFound, "Portfolio not found or already deleted")
 		return
 	}
 
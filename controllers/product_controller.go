@@ -2,16 +2,22 @@ package controllers
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
 	"strings"
 	"time"
 
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/auth"
 	"golang-firebase-backend/config"
+	"golang-firebase-backend/etag"
+	"golang-firebase-backend/logging"
+	"golang-firebase-backend/mergepatch"
 	"golang-firebase-backend/models"
 	"golang-firebase-backend/utils"
+	"golang-firebase-backend/validate"
 
+	"firebase.google.com/go/db"
 	"github.com/google/uuid"
 )
 
@@ -21,26 +27,23 @@ func matchStringsIgnoreCase(str1, str2 string) bool {
 }
 
 func CreateProduct(w http.ResponseWriter, r *http.Request) {
-	var productInput struct {
-		NameProduct string   `json:"nameProduct"`
-		Description string   `json:"description"`
-		PhotoURL    []string `json:"photo_url"`
-		Price       string   `json:"price"`
-		IdCategory  string   `json:"idCategory"`
-		IdService   string   `json:"idService"`
-	}
+	var productInput models.ProductInput
 
-	if err := json.NewDecoder(r.Body).Decode(&productInput); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, "Invalid input")
+	if err := validate.Decode(w, r, &productInput); err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
-	ctx := context.Background()
-	userID := r.Context().Value("uid").(string)
+	ctx := r.Context()
+	userID, ok := auth.UID(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
+		return
+	}
 
 	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
@@ -48,14 +51,20 @@ func CreateProduct(w http.ResponseWriter, r *http.Request) {
 	sellerRef := client.NewRef("registerSellers/" + userID)
 	var seller models.RegisterSeller
 	if err := sellerRef.Get(ctx, &seller); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch seller information")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch seller information")
+		return
+	}
+
+	// Alumni yang wajib verifikasi ulang belum boleh menambah produk
+	if seller.ReverificationRequired {
+		apierror.Write(w, r, apierror.New(http.StatusForbidden, "reverification_required", "Alumni sellers must be verified again before listing new products"))
 		return
 	}
 
 	// Ambil Major dari seller
 	majorName := seller.Major
 	if majorName == "" {
-		utils.RespondError(w, http.StatusForbidden, "Seller must have a valid Major in registerSellers")
+		apierror.Respond(w, r, http.StatusForbidden, "Seller must have a valid Major in registerSellers")
 		return
 	}
 
@@ -63,7 +72,7 @@ func CreateProduct(w http.ResponseWriter, r *http.Request) {
 	majorsRef := client.NewRef("majors")
 	var majors map[string]models.Major
 	if err := majorsRef.Get(ctx, &majors); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch majors")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch majors")
 		return
 	}
 
@@ -76,7 +85,7 @@ func CreateProduct(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if majorID == "" {
-		utils.RespondError(w, http.StatusBadRequest, "No matching Major ID found for seller's Major")
+		apierror.Respond(w, r, http.StatusBadRequest, "No matching Major ID found for seller's Major")
 		return
 	}
 
@@ -84,14 +93,14 @@ func CreateProduct(w http.ResponseWriter, r *http.Request) {
 	categoriesRef := client.NewRef("categories/" + productInput.IdCategory)
 	var category models.Category
 	if err := categoriesRef.Get(ctx, &category); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch category")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch category")
 		return
 	}
 
 	// Validate Category
 	if category.IdMajor != majorID {
-		fmt.Printf("Category Major Mismatch: Category ID Major: %s, Expected Major ID: %s\n", category.IdMajor, majorID)
-		utils.RespondError(w, http.StatusBadRequest, "Selected Category is not part of the seller's Major")
+		logging.From(r.Context()).Warn("category is not part of the seller's major", "category_major", category.IdMajor, "seller_major", majorID)
+		apierror.Respond(w, r, http.StatusBadRequest, "Selected Category is not part of the seller's Major")
 		return
 	}
 
@@ -99,16 +108,14 @@ func CreateProduct(w http.ResponseWriter, r *http.Request) {
 	servicesRef := client.NewRef("services/" + productInput.IdService)
 	var service models.Service
 	if err := servicesRef.Get(ctx, &service); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch service")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch service")
 		return
 	}
 
-	// Debug service data
-
 	// Validate Service
 	if service.IdCategory != productInput.IdCategory {
-		fmt.Printf("Service-Category Mismatch: Service ID Category: %s, Provided Category ID: %s\n", service.IdCategory, productInput.IdCategory)
-		utils.RespondError(w, http.StatusBadRequest, "Selected Service is not part of the chosen Category")
+		logging.From(r.Context()).Warn("service is not part of the category", "service_category", service.IdCategory, "category", productInput.IdCategory)
+		apierror.Respond(w, r, http.StatusBadRequest, "Selected Service is not part of the chosen Category")
 		return
 	}
 
@@ -126,9 +133,12 @@ func CreateProduct(w http.ResponseWriter, r *http.Request) {
 		UpdatedAt:   time.Now(),
 	}
 
-	ref := client.NewRef("products/" + userID + "/" + product.UID)
-	if err := ref.Set(ctx, &product); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to create product")
+	// Produk dan indeks pemiliknya ditulis bersamaan
+	if err := client.NewRef("").Update(ctx, map[string]interface{}{
+		"products/" + userID + "/" + product.UID: &product,
+		productOwnerPath(product.UID):            userID,
+	}); err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to create product")
 		return
 	}
 
@@ -140,19 +150,23 @@ func CreateProduct(w http.ResponseWriter, r *http.Request) {
 }
 
 func FetchProducts(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
-	userID := r.Context().Value("uid").(string)
+	ctx := r.Context()
+	userID, ok := auth.UID(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
+		return
+	}
 
 	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
 	productsRef := client.NewRef("products/" + userID)
 	var products map[string]models.Product
 	if err := productsRef.Get(ctx, &products); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch products")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch products")
 		return
 	}
 
@@ -169,19 +183,19 @@ func FetchProducts(w http.ResponseWriter, r *http.Request) {
 }
 
 func FetchProductsByUserID(w http.ResponseWriter, r *http.Request) {
-	// Ambil `userID` dari query parameter
-	userID := r.URL.Query().Get("uid")
+	// Ambil `userID` dari path atau query parameter
+	userID := utils.Param(r, "uid", "uid")
 	if userID == "" {
-		utils.RespondError(w, http.StatusBadRequest, "User ID is required")
+		apierror.Respond(w, r, http.StatusBadRequest, "User ID is required")
 		return
 	}
 
-	ctx := context.Background()
+	ctx := r.Context()
 
 	// Inisialisasi koneksi ke Firebase Database
 	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
@@ -191,7 +205,7 @@ func FetchProductsByUserID(w http.ResponseWriter, r *http.Request) {
 
 	// Ambil semua produk dari userID yang diberikan
 	if err := productsRef.Get(ctx, &products); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch products for the given User ID")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch products for the given User ID")
 		return
 	}
 
@@ -216,14 +230,14 @@ func ViewProduct(w http.ResponseWriter, r *http.Request) {
 
 	// Validasi input parameter
 	if userName == "" || productName == "" {
-		utils.RespondError(w, http.StatusBadRequest, "Both 'name' and 'product_name' query parameters are required")
+		apierror.Respond(w, r, http.StatusBadRequest, "Both 'name' and 'product_name' query parameters are required")
 		return
 	}
 
-	ctx := context.Background()
+	ctx := r.Context()
 	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
@@ -232,7 +246,7 @@ func ViewProduct(w http.ResponseWriter, r *http.Request) {
 	var sellers map[string]models.RegisterSeller
 
 	if err := sellersRef.Get(ctx, &sellers); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch sellers")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch sellers")
 		return
 	}
 
@@ -247,7 +261,7 @@ func ViewProduct(w http.ResponseWriter, r *http.Request) {
 
 	// Jika pengguna tidak ditemukan
 	if userID == "" {
-		utils.RespondError(w, http.StatusNotFound, "User not found")
+		apierror.Respond(w, r, http.StatusNotFound, "User not found")
 		return
 	}
 
@@ -256,7 +270,7 @@ func ViewProduct(w http.ResponseWriter, r *http.Request) {
 	var products map[string]models.Product
 
 	if err := productsRef.Get(ctx, &products); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch products")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch products")
 		return
 	}
 
@@ -273,7 +287,7 @@ func ViewProduct(w http.ResponseWriter, r *http.Request) {
 
 	// Jika produk tidak ditemukan
 	if !found {
-		utils.RespondError(w, http.StatusNotFound, "Product not found")
+		apierror.Respond(w, r, http.StatusNotFound, "Product not found")
 		return
 	}
 
@@ -285,41 +299,57 @@ func ViewProduct(w http.ResponseWriter, r *http.Request) {
 }
 
 func ViewProductByID(w http.ResponseWriter, r *http.Request) {
-	// Mengambil query parameter
+	// Mengambil path atau query parameter
 	userID := r.URL.Query().Get("user_id")
-	productID := r.URL.Query().Get("product_id")
+	productID := utils.Param(r, "id", "product_id")
 
-	// Validasi input parameter
-	if userID == "" || productID == "" {
-		utils.RespondError(w, http.StatusBadRequest, "Both 'user_id' and 'product_id' query parameters are required")
+	// Validasi input parameter; route v1 boleh tanpa user_id
+	if productID == "" || (userID == "" && r.PathValue("id") == "") {
+		apierror.Respond(w, r, http.StatusBadRequest, "Both 'user_id' and 'product_id' query parameters are required")
 		return
 	}
 
 	// Membuat konteks dan inisialisasi database
-	ctx := context.Background()
+	ctx := r.Context()
 	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
+	// Cari pemilik produk jika user_id tidak diberikan
+	if userID == "" {
+		owner, err := findProductOwner(ctx, client, productID)
+		if err != nil {
+			apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch product")
+			return
+		}
+		if owner == "" {
+			apierror.Respond(w, r, http.StatusNotFound, "Product not found")
+			return
+		}
+		userID = owner
+	}
+
 	// Referensi ke produk berdasarkan userID dan productID
 	productRef := client.NewRef(fmt.Sprintf("products/%s/%s", userID, productID))
 	var product models.Product
 
 	// Mendapatkan data produk dari Firebase
-	if err := productRef.Get(ctx, &product); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch product")
+	tag, err := etag.Get(ctx, productRef, &product)
+	if err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch product")
 		return
 	}
 
 	// Jika produk tidak ditemukan
 	if product.NameProduct == "" {
-		utils.RespondError(w, http.StatusNotFound, "Product not found")
+		apierror.Respond(w, r, http.StatusNotFound, "Product not found")
 		return
 	}
 
 	// Mengembalikan respons produk
+	etag.Set(w, tag)
 	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
 		"data":    product,
@@ -327,35 +357,38 @@ func ViewProductByID(w http.ResponseWriter, r *http.Request) {
 }
 
 func UpdateProduct(w http.ResponseWriter, r *http.Request) {
-	// Ambil UID dari query parameter
-	productUID := r.URL.Query().Get("uid")
+	// Ambil UID dari path atau query parameter
+	productUID := utils.Param(r, "id", "uid")
 
 	// Validasi UID
 	if productUID == "" {
-		utils.RespondError(w, http.StatusBadRequest, "Product UID is required in query parameter")
+		apierror.Respond(w, r, http.StatusBadRequest, "Product UID is required in query parameter")
 		return
 	}
 
-	// Ambil data yang ingin diperbarui dari body request
-	var updateInput struct {
-		Description string   `json:"description,omitempty"`
-		PhotoURL    []string `json:"photo_url,omitempty"`
-		Price       string   `json:"price,omitempty"`
-		IdCategory  string   `json:"idCategory,omitempty"`
-		IdService   string   `json:"idService,omitempty"`
+	if mergepatch.Requested(r) {
+		patchProduct(w, r, productUID)
+		return
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&updateInput); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, "Invalid input")
+	// Ambil data yang ingin diperbarui dari body request
+	var updateInput models.ProductUpdateInput
+
+	if err := validate.Decode(w, r, &updateInput); err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
-	ctx := context.Background()
-	userID := r.Context().Value("uid").(string)
+	ctx := r.Context()
+	userID, ok := auth.UID(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
+		return
+	}
 
 	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
@@ -363,7 +396,7 @@ func UpdateProduct(w http.ResponseWriter, r *http.Request) {
 	productRef := client.NewRef("products/" + userID + "/" + productUID)
 	var existingProduct models.Product
 	if err := productRef.Get(ctx, &existingProduct); err != nil {
-		utils.RespondError(w, http.StatusNotFound, "Product not found")
+		apierror.Respond(w, r, http.StatusNotFound, "Product not found")
 		return
 	}
 
@@ -383,7 +416,7 @@ func UpdateProduct(w http.ResponseWriter, r *http.Request) {
 		categoryRef := client.NewRef("categories/" + updateInput.IdCategory)
 		var category models.Category
 		if err := categoryRef.Get(ctx, &category); err != nil {
-			utils.RespondError(w, http.StatusBadRequest, "Invalid category ID")
+			apierror.Respond(w, r, http.StatusBadRequest, "Invalid category ID")
 			return
 		}
 		updates["idCategory"] = updateInput.IdCategory
@@ -393,11 +426,11 @@ func UpdateProduct(w http.ResponseWriter, r *http.Request) {
 		serviceRef := client.NewRef("services/" + updateInput.IdService)
 		var service models.Service
 		if err := serviceRef.Get(ctx, &service); err != nil {
-			utils.RespondError(w, http.StatusBadRequest, "Invalid service ID")
+			apierror.Respond(w, r, http.StatusBadRequest, "Invalid service ID")
 			return
 		}
 		if service.IdCategory != updateInput.IdCategory {
-			utils.RespondError(w, http.StatusBadRequest, "Service does not belong to the selected category")
+			apierror.Respond(w, r, http.StatusBadRequest, "Service does not belong to the selected category")
 			return
 		}
 		updates["idService"] = updateInput.IdService
@@ -405,14 +438,80 @@ func UpdateProduct(w http.ResponseWriter, r *http.Request) {
 
 	updates["updated_at"] = time.Now()
 
-	// Terapkan pembaruan
-	if err := productRef.Update(ctx, updates); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to update product")
+	// Terapkan pembaruan; If-Match dicek di dalam transaksi
+	tag, err := etag.Update(ctx, r, productRef, updates)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	etag.Set(w, tag)
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Product updated successfully",
+	})
+}
+
+// patchProduct applies a JSON merge patch to a product of the signed-in seller
+func patchProduct(w http.ResponseWriter, r *http.Request, productUID string) {
+	patch, err := mergepatch.Decode(w, r, "description", "photo_url", "price", "idCategory", "idService")
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	ctx := r.Context()
+	userID, ok := auth.UID(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
+		return
+	}
+
+	client, err := config.Database(ctx)
+	if err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		return
+	}
+
+	productRef := client.NewRef("products/" + userID + "/" + productUID)
+	var product models.Product
+	if err := productRef.Get(ctx, &product); err != nil || product.UID == "" {
+		apierror.Respond(w, r, http.StatusNotFound, "Product not found")
+		return
+	}
+
+	if err := mergepatch.ApplyTo(&product, patch); err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
+	// Kategori dan layanan harus tetap cocok setelah diubah
+	if patch.Has("idCategory") || patch.Has("idService") {
+		var category models.Category
+		if err := client.NewRef("categories/"+product.IdCategory).Get(ctx, &category); err != nil || category.Title == "" {
+			apierror.Write(w, r, apierror.Validation("Invalid input").WithField("idCategory", "does not exist"))
+			return
+		}
+		var service models.Service
+		if err := client.NewRef("services/"+product.IdService).Get(ctx, &service); err != nil || service.IdCategory != product.IdCategory {
+			apierror.Write(w, r, apierror.Validation("Invalid input").WithField("idService", "does not belong to the selected category"))
+			return
+		}
+	}
+
+	product.UpdatedAt = time.Now()
+	updates := mergepatch.Updates(patch)
+	updates["updated_at"] = product.UpdatedAt
+	tag, err := etag.Update(ctx, r, productRef, updates)
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	etag.Set(w, tag)
 	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
 		"success": true,
+		"data":    product,
 		"message": "Product updated successfully",
 	})
 }
@@ -422,28 +521,44 @@ func DeleteProduct(w http.ResponseWriter, r *http.Request) {
 		UID string `json:"uid"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, "Invalid input")
-		return
+	requestBody.UID = r.PathValue("id")
+	if requestBody.UID == "" {
+		if err := validate.Decode(w, r, &requestBody); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
 	}
 
 	if requestBody.UID == "" {
-		utils.RespondError(w, http.StatusUnprocessableEntity, "Product UID is required")
+		apierror.Respond(w, r, http.StatusUnprocessableEntity, "Product UID is required")
 		return
 	}
 
-	ctx := context.Background()
-	userID := r.Context().Value("uid").(string)
+	ctx := r.Context()
+	userID, ok := auth.UID(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
+		return
+	}
 
 	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
-	productRef := client.NewRef("products/" + userID + "/" + requestBody.UID)
-	if err := productRef.Delete(ctx); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to delete product")
+	// Indeks pemilik hanya dihapus bila produk ini memang milik user
+	updates := map[string]interface{}{"products/" + userID + "/" + requestBody.UID: nil}
+	var owner string
+	if err := client.NewRef(productOwnerPath(requestBody.UID)).Get(ctx, &owner); err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to delete product")
+		return
+	}
+	if owner == userID {
+		updates[productOwnerPath(requestBody.UID)] = nil
+	}
+	if err := client.NewRef("").Update(ctx, updates); err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to delete product")
 		return
 	}
 
@@ -457,14 +572,14 @@ func SearchProducts(w http.ResponseWriter, r *http.Request) {
 	searchTerm := r.URL.Query().Get("query")
 
 	if searchTerm == "" {
-		utils.RespondError(w, http.StatusBadRequest, "Search term is required")
+		apierror.Respond(w, r, http.StatusBadRequest, "Search term is required")
 		return
 	}
 
-	ctx := context.Background()
+	ctx := r.Context()
 	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
@@ -473,12 +588,18 @@ func SearchProducts(w http.ResponseWriter, r *http.Request) {
 	var users map[string]map[string]interface{}
 
 	if err := usersRef.Get(ctx, &users); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch users")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch users")
 		return
 	}
 
+	// Produk bisa difilter berdasarkan organisasi penjual
+	organizationID := r.URL.Query().Get("organization")
 	var matchingUsers []string
 	for id, user := range users {
+		if organizationID != "" && user["organization_id"] != organizationID {
+			delete(users, id)
+			continue
+		}
 		if userName, ok := user["name"].(string); ok && strings.Contains(strings.ToLower(userName), strings.ToLower(searchTerm)) {
 			matchingUsers = append(matchingUsers, id)
 		}
@@ -489,12 +610,16 @@ func SearchProducts(w http.ResponseWriter, r *http.Request) {
 	var products map[string]map[string]models.Product
 
 	if err := productsRef.Get(ctx, &products); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch products")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch products")
 		return
 	}
 
 	var filteredProducts []models.Product
 	for userID, userProducts := range products {
+		if _, ok := users[userID]; organizationID != "" && !ok {
+			continue
+		}
+
 		// Check if the user ID matches the search query
 		if contains(matchingUsers, userID) {
 			for _, product := range userProducts {
@@ -516,6 +641,69 @@ func SearchProducts(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// productOwnerPath indexes the seller of a product by the product ID, so
+// that products can be found without the seller in the URL
+func productOwnerPath(productID string) string {
+	return "productOwners/" + productID
+}
+
+// productOwnersIndexed is set once the owner index covers the products
+// created before it existed
+const productOwnersIndexed = "productOwnersIndexed"
+
+// findProductOwner returns the UID of the seller that owns the product, or
+// an empty string if no seller has it
+func findProductOwner(ctx context.Context, client *db.Client, productID string) (string, error) {
+	var owner string
+	if err := client.NewRef(productOwnerPath(productID)).Get(ctx, &owner); err != nil {
+		return "", err
+	}
+	if owner != "" {
+		return owner, nil
+	}
+
+	var indexed bool
+	if err := client.NewRef(productOwnersIndexed).Get(ctx, &indexed); err != nil {
+		return "", err
+	}
+	if indexed {
+		return "", nil
+	}
+	owners, err := indexProductOwners(ctx, client)
+	if err != nil {
+		return "", err
+	}
+	return owners[productID], nil
+}
+
+// indexProductOwners fills the owner index from the products written before
+// it existed. It runs once, on the first lookup that misses the index.
+func indexProductOwners(ctx context.Context, client *db.Client) (map[string]string, error) {
+	var sellers map[string]interface{}
+	if err := client.NewRef("products").GetShallow(ctx, &sellers); err != nil {
+		return nil, err
+	}
+
+	owners := map[string]string{}
+	updates := map[string]interface{}{productOwnersIndexed: true}
+	for seller := range sellers {
+		var products map[string]interface{}
+		if err := client.NewRef("products/"+seller).GetShallow(ctx, &products); err != nil {
+			return nil, err
+		}
+		for productID := range products {
+			owners[productID] = seller
+			updates[productOwnerPath(productID)] = seller
+		}
+	}
+
+	if err := client.NewRef("").Update(ctx, updates); err != nil {
+		return nil, err
+	}
+	logging.From(ctx).Info("indexed product owners", "products", len(owners))
+	return owners, nil
+}
+
 // Helper function to check if a slice contains a string
 func contains(slice []string, item string) bool {
 	for _, s := range slice {
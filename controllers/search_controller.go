@@ -5,6 +5,8 @@ import (
 	"net/http"
 	"strings"
 
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/auth"
 	"golang-firebase-backend/config"
 	"golang-firebase-backend/models"
 	"golang-firebase-backend/utils"
@@ -18,31 +20,39 @@ func SearchController(w http.ResponseWriter, r *http.Request) {
 	// Get the search term from query parameters
 	searchTerm := r.URL.Query().Get("query")
 	if searchTerm == "" {
-		utils.RespondError(w, http.StatusBadRequest, "Search term is required")
+		apierror.Respond(w, r, http.StatusBadRequest, "Search term is required")
 		return
 	}
 
 	searchTerm = strings.ToLower(searchTerm)
-	ctx := context.Background()
+	organizationID := r.URL.Query().Get("organization")
+	ctx := r.Context()
 
 	// Initialize Firebase Database
 	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
 	// Fetch matching users
-	users, userErr := searchUsers(ctx, client, searchTerm)
+	users, userErr := searchUsers(ctx, client, searchTerm, organizationID)
 	if userErr != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to search users")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to search users")
 		return
 	}
 
 	// Fetch matching products
-	products, productErr := searchProducts(ctx, client, searchTerm, users)
+	var members map[string]bool
+	if organizationID != "" {
+		if members, err = organizationMembers(ctx, client, organizationID); err != nil {
+			apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch users")
+			return
+		}
+	}
+	products, productErr := searchProducts(ctx, client, searchTerm, users, members)
 	if productErr != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to search products")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to search products")
 		return
 	}
 
@@ -54,8 +64,15 @@ func SearchController(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// searchUsers finds users matching the search term
-func searchUsers(ctx context.Context, client *db.Client, searchTerm string) ([]map[string]interface{}, error) {
+// searchUser is a user found by SearchController
+type searchUser struct {
+	ID string `json:"id"`
+	models.UserProfile
+}
+
+// searchUsers finds users matching the search term, only those of the
+// organization when organizationID is set
+func searchUsers(ctx context.Context, client *db.Client, searchTerm, organizationID string) ([]searchUser, error) {
 	usersRef := client.NewRef("users")
 	var users map[string]models.User
 
@@ -63,21 +80,16 @@ func searchUsers(ctx context.Context, client *db.Client, searchTerm string) ([]m
 		return nil, err
 	}
 
-	var matchingUsers []map[string]interface{}
+	var matchingUsers []searchUser
 	for id, user := range users {
+		if organizationID != "" && user.OrganizationID != organizationID {
+			continue
+		}
 		if strings.Contains(strings.ToLower(user.Name), searchTerm) {
-			matchingUsers = append(matchingUsers, map[string]interface{}{
-				"id":           id, // Include the user ID here
-				"name":         user.Name,
-				"email":        user.Email,
-				"organization": user.Organization,
-				"major":        user.Major,
-				"language":     user.Language,
-				"photo_url":    user.PhotoURL,
-				"verified":     user.Verified,
-				"role":         user.Role,
-				"created_at":   user.CreatedAt,
-				"last_sign_in": user.LastSignIn,
+			user.UID = id
+			matchingUsers = append(matchingUsers, searchUser{
+				ID:          id, // Include the user ID here
+				UserProfile: user.Profile(auth.View(ctx, id)),
 			})
 		}
 	}
@@ -85,8 +97,9 @@ func searchUsers(ctx context.Context, client *db.Client, searchTerm string) ([]m
 	return matchingUsers, nil
 }
 
-// searchProducts finds products matching the search term or owned by matching users
-func searchProducts(ctx context.Context, client *db.Client, searchTerm string, matchingUsers []map[string]interface{}) ([]models.Product, error) {
+// searchProducts finds products matching the search term or owned by matching
+// users. A non-nil sellers limits the products to those of the listed sellers.
+func searchProducts(ctx context.Context, client *db.Client, searchTerm string, matchingUsers []searchUser, sellers map[string]bool) ([]models.Product, error) {
 	productsRef := client.NewRef("products")
 	var products map[string]map[string]models.Product
 
@@ -96,13 +109,15 @@ func searchProducts(ctx context.Context, client *db.Client, searchTerm string, m
 
 	userIDs := make(map[string]bool)
 	for _, user := range matchingUsers {
-		if uid, ok := user["id"].(string); ok {
-			userIDs[uid] = true
-		}
+		userIDs[user.ID] = true
 	}
 
 	var filteredProducts []models.Product
 	for userID, userProducts := range products {
+		if sellers != nil && !sellers[userID] {
+			continue
+		}
+
 		// Include products owned by matching users
 		if userIDs[userID] {
 			for _, product := range userProducts {
@@ -120,3 +135,21 @@ func searchProducts(ctx context.Context, client *db.Client, searchTerm string, m
 
 	return filteredProducts, nil
 }
+
+// organizationMembers returns the UIDs of the users of an organization
+func organizationMembers(ctx context.Context, client *db.Client, organizationID string) (map[string]bool, error) {
+	var users map[string]struct {
+		OrganizationID string `json:"organization_id"`
+	}
+	if err := client.NewRef("users").Get(ctx, &users); err != nil {
+		return nil, err
+	}
+
+	members := map[string]bool{}
+	for uid, user := range users {
+		if user.OrganizationID == organizationID {
+			members[uid] = true
+		}
+	}
+	return members, nil
+}
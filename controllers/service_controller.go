@@ -1,31 +1,31 @@
 package controllers
 
 import (
-	"context"
-	"encoding/json"
 	"net/http"
 
-	"fmt"
+	"golang-firebase-backend/apierror"
 	"golang-firebase-backend/config"
+	"golang-firebase-backend/logging"
 	"golang-firebase-backend/models"
 	"golang-firebase-backend/utils"
+	"golang-firebase-backend/validate"
 
 	"github.com/google/uuid"
 )
 
 // Fetch all services
 func FetchServices(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
-	client, err := config.FirebaseApp.Database(ctx)
+	ctx := r.Context()
+	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
 	var services map[string]models.Service
 	ref := client.NewRef("services")
 	if err := ref.Get(ctx, &services); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch services")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch services")
 		return
 	}
 
@@ -43,17 +43,20 @@ func ShowService(w http.ResponseWriter, r *http.Request) {
 		Id           string `json:"id,omitempty"`
 		TitleService string `json:"title_service,omitempty"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, "Invalid input")
-		return
+	requestBody.Id = r.PathValue("id")
+	if requestBody.Id == "" {
+		if err := validate.Decode(w, r, &requestBody); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
 	}
 
-	fmt.Println("Request Body:", requestBody) // Log input JSON
+	logging.From(r.Context()).Debug("showing service", "id", requestBody.Id, "title_service", requestBody.TitleService)
 
-	ctx := context.Background()
-	client, err := config.FirebaseApp.Database(ctx)
+	ctx := r.Context()
+	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
@@ -61,11 +64,11 @@ func ShowService(w http.ResponseWriter, r *http.Request) {
 	var services map[string]models.Service
 	ref := client.NewRef("services")
 	if err := ref.Get(ctx, &services); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch services")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch services")
 		return
 	}
 
-	fmt.Println("Fetched Services:", services) // Log data layanan yang diambil
+	logging.From(r.Context()).Debug("fetched services", "count", len(services))
 
 	// Cari layanan berdasarkan ID atau TitleService
 	for id, service := range services {
@@ -81,31 +84,22 @@ func ShowService(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	utils.RespondError(w, http.StatusNotFound, "Service not found")
+	apierror.Respond(w, r, http.StatusNotFound, "Service not found")
 }
 
 func CreateService(w http.ResponseWriter, r *http.Request) {
-	var serviceInput struct {
-		TitleService  string `json:"title_service"`
-		IconUrl       string `json:"icon_url"`
-		TitleCategory string `json:"title_category"`
-	}
+	var serviceInput models.ServiceInput
 
-	if err := json.NewDecoder(r.Body).Decode(&serviceInput); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, "Invalid input")
+	if err := validate.Decode(w, r, &serviceInput); err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
 	// Validasi input
-	if serviceInput.TitleService == "" || serviceInput.IconUrl == "" || serviceInput.TitleCategory == "" {
-		utils.RespondError(w, http.StatusUnprocessableEntity, "TitleService, IconUrl, and TitleCategory are required")
-		return
-	}
-
-	ctx := context.Background()
-	client, err := config.FirebaseApp.Database(ctx)
+	ctx := r.Context()
+	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
@@ -113,7 +107,7 @@ func CreateService(w http.ResponseWriter, r *http.Request) {
 	var categories map[string]models.Category
 	refCategories := client.NewRef("categories")
 	if err := refCategories.Get(ctx, &categories); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch categories")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch categories")
 		return
 	}
 
@@ -126,7 +120,7 @@ func CreateService(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if idCategory == "" {
-		utils.RespondError(w, http.StatusBadRequest, "TitleCategory not found")
+		apierror.Respond(w, r, http.StatusBadRequest, "TitleCategory not found")
 		return
 	}
 
@@ -140,7 +134,7 @@ func CreateService(w http.ResponseWriter, r *http.Request) {
 	id := uuid.New().String()
 	ref := client.NewRef("services/" + id)
 	if err := ref.Set(ctx, &service); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to create service")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to create service")
 		return
 	}
 
@@ -157,26 +151,29 @@ func DeleteService(w http.ResponseWriter, r *http.Request) {
 	var requestBody struct {
 		Id string `json:"id"`
 	}
-	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, "Invalid input")
-		return
+	requestBody.Id = r.PathValue("id")
+	if requestBody.Id == "" {
+		if err := validate.Decode(w, r, &requestBody); err != nil {
+			apierror.Write(w, r, err)
+			return
+		}
 	}
 
 	if requestBody.Id == "" {
-		utils.RespondError(w, http.StatusUnprocessableEntity, "Service ID is required")
+		apierror.Respond(w, r, http.StatusUnprocessableEntity, "Service ID is required")
 		return
 	}
 
-	ctx := context.Background()
-	client, err := config.FirebaseApp.Database(ctx)
+	ctx := r.Context()
+	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
 	ref := client.NewRef("services/" + requestBody.Id)
 	if err := ref.Delete(ctx); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to delete service")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to delete service")
 		return
 	}
 
@@ -189,26 +186,22 @@ func DeleteService(w http.ResponseWriter, r *http.Request) {
 // Update an existing service
 func UpdateService(w http.ResponseWriter, r *http.Request) {
 	// Get id_service from query parameters
-	idService := r.URL.Query().Get("id_service")
+	idService := utils.Param(r, "id", "id_service")
 	if idService == "" {
-		utils.RespondError(w, http.StatusUnprocessableEntity, "Service ID is required")
+		apierror.Respond(w, r, http.StatusUnprocessableEntity, "Service ID is required")
 		return
 	}
 
-	var requestBody struct {
-		TitleService  string `json:"title_service,omitempty"`
-		IconUrl       string `json:"icon_url,omitempty"`
-		TitleCategory string `json:"title_category,omitempty"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, "Invalid input")
+	var requestBody models.ServiceUpdateInput
+	if err := validate.Decode(w, r, &requestBody); err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
-	ctx := context.Background()
-	client, err := config.FirebaseApp.Database(ctx)
+	ctx := r.Context()
+	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
@@ -218,7 +211,7 @@ func UpdateService(w http.ResponseWriter, r *http.Request) {
 	// Check if the service exists
 	var existingService models.Service
 	if err := ref.Get(ctx, &existingService); err != nil {
-		utils.RespondError(w, http.StatusNotFound, "Service not found")
+		apierror.Respond(w, r, http.StatusNotFound, "Service not found")
 		return
 	}
 
@@ -236,7 +229,7 @@ func UpdateService(w http.ResponseWriter, r *http.Request) {
 		var categories map[string]models.Category
 		refCategories := client.NewRef("categories")
 		if err := refCategories.Get(ctx, &categories); err != nil {
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch categories")
+			apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch categories")
 			return
 		}
 
@@ -249,7 +242,7 @@ func UpdateService(w http.ResponseWriter, r *http.Request) {
 		}
 
 		if idCategory == "" {
-			utils.RespondError(w, http.StatusBadRequest, "TitleCategory not found")
+			apierror.Respond(w, r, http.StatusBadRequest, "TitleCategory not found")
 			return
 		}
 
@@ -258,7 +251,7 @@ func UpdateService(w http.ResponseWriter, r *http.Request) {
 
 	// Save updated service back to Firebase
 	if err := ref.Set(ctx, &existingService); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to update service")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to update service")
 		return
 	}
 
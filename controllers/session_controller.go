@@ -0,0 +1,81 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/auth"
+	"golang-firebase-backend/sessions"
+	"golang-firebase-backend/utils"
+)
+
+// ListSessions - GET /api/v1/users/me/sessions
+func ListSessions(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
+		return
+	}
+
+	list, err := sessions.List(r.Context(), principal.UID, principal.SessionID)
+	if err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch sessions")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    list,
+	})
+}
+
+// SignOutSession - DELETE /api/v1/users/me/sessions/{id}
+func SignOutSession(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
+		return
+	}
+
+	id := r.PathValue("id")
+	err := sessions.SignOut(r.Context(), principal.UID, id)
+	if errors.Is(err, sessions.ErrNotFound) {
+		apierror.Respond(w, r, http.StatusNotFound, "Session not found")
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to sign out session")
+		return
+	}
+	auth.Default().ForgetSession(principal.UID, id)
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"message": "Session signed out successfully",
+	})
+}
+
+// SignOutOtherSessions - POST /api/v1/users/me/sessions/sign-out-others
+func SignOutOtherSessions(w http.ResponseWriter, r *http.Request) {
+	principal, ok := auth.FromContext(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
+		return
+	}
+
+	signedOut, err := sessions.SignOutOthers(r.Context(), principal.UID, principal.SessionID)
+	if err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to sign out sessions")
+		return
+	}
+	for _, id := range signedOut {
+		auth.Default().ForgetSession(principal.UID, id)
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success":    true,
+		"signed_out": len(signedOut),
+		"message":    "Other sessions signed out successfully",
+	})
+}
@@ -1,11 +1,11 @@
 package controllers
 
 import (
-	"context"
-	"encoding/json"
+	"golang-firebase-backend/apierror"
 	"golang-firebase-backend/config"
 	"golang-firebase-backend/models"
 	"golang-firebase-backend/utils"
+	"golang-firebase-backend/validate"
 	"net/http"
 
 	"github.com/google/uuid"
@@ -13,10 +13,10 @@ import (
 
 // Fetch all skills
 func FetchSkills(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
-	client, err := config.FirebaseApp.Database(ctx)
+	ctx := r.Context()
+	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
@@ -24,7 +24,7 @@ func FetchSkills(w http.ResponseWriter, r *http.Request) {
 	var skills map[string]models.Skill
 	ref := client.NewRef("skills")
 	if err := ref.Get(ctx, &skills); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch skills")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch skills")
 		return
 	}
 
@@ -40,23 +40,23 @@ func FetchSkills(w http.ResponseWriter, r *http.Request) {
 
 // Show a specific skill
 func ShowSkill(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
+	id := utils.Param(r, "id", "id")
 	if id == "" {
-		utils.RespondError(w, http.StatusBadRequest, "Skill ID is required")
+		apierror.Respond(w, r, http.StatusBadRequest, "Skill ID is required")
 		return
 	}
 
-	ctx := context.Background()
-	client, err := config.FirebaseApp.Database(ctx)
+	ctx := r.Context()
+	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
 	var skill models.Skill
 	ref := client.NewRef("skills/" + id)
 	if err := ref.Get(ctx, &skill); err != nil {
-		utils.RespondError(w, http.StatusNotFound, "Skill not found")
+		apierror.Respond(w, r, http.StatusNotFound, "Skill not found")
 		return
 	}
 
@@ -67,20 +67,15 @@ func ShowSkill(w http.ResponseWriter, r *http.Request) {
 // Create a new skill
 func CreateSkill(w http.ResponseWriter, r *http.Request) {
 	var skill models.Skill
-	if err := json.NewDecoder(r.Body).Decode(&skill); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, "Invalid input")
+	if err := validate.Decode(w, r, &skill); err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
-	if skill.TitleSkills == "" {
-		utils.RespondError(w, http.StatusUnprocessableEntity, "TitleSkills is required")
-		return
-	}
-
-	ctx := context.Background()
-	client, err := config.FirebaseApp.Database(ctx)
+	ctx := r.Context()
+	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
@@ -88,7 +83,7 @@ func CreateSkill(w http.ResponseWriter, r *http.Request) {
 	id := uuid.New().String()
 	ref := client.NewRef("skills/" + id)
 	if err := ref.Set(ctx, &skill); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to create skill")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to create skill")
 		return
 	}
 
@@ -102,27 +97,22 @@ func CreateSkill(w http.ResponseWriter, r *http.Request) {
 
 // Update an existing skill
 func UpdateSkill(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
+	id := utils.Param(r, "id", "id")
 	if id == "" {
-		utils.RespondError(w, http.StatusBadRequest, "Skill ID is required")
+		apierror.Respond(w, r, http.StatusBadRequest, "Skill ID is required")
 		return
 	}
 
 	var skill models.Skill
-	if err := json.NewDecoder(r.Body).Decode(&skill); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, "Invalid input")
-		return
-	}
-
-	if skill.TitleSkills == "" {
-		utils.RespondError(w, http.StatusUnprocessableEntity, "TitleSkills is required")
+	if err := validate.Decode(w, r, &skill); err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
-	ctx := context.Background()
-	client, err := config.FirebaseApp.Database(ctx)
+	ctx := r.Context()
+	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
@@ -130,7 +120,7 @@ func UpdateSkill(w http.ResponseWriter, r *http.Request) {
 	if err := ref.Update(ctx, map[string]interface{}{
 		"TitleSkills": skill.TitleSkills,
 	}); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to update skill")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to update skill")
 		return
 	}
 
@@ -142,22 +132,22 @@ func UpdateSkill(w http.ResponseWriter, r *http.Request) {
 
 // Delete a skill
 func DeleteSkill(w http.ResponseWriter, r *http.Request) {
-	id := r.URL.Query().Get("id")
+	id := utils.Param(r, "id", "id")
 	if id == "" {
-		utils.RespondError(w, http.StatusBadRequest, "Skill ID is required")
+		apierror.Respond(w, r, http.StatusBadRequest, "Skill ID is required")
 		return
 	}
 
-	ctx := context.Background()
-	client, err := config.FirebaseApp.Database(ctx)
+	ctx := r.Context()
+	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
 	ref := client.NewRef("skills/" + id)
 	if err := ref.Delete(ctx); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to delete skill")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to delete skill")
 		return
 	}
 
@@ -1,7 +1,7 @@
 package controllers
 
 import (
-	"context"
+	"golang-firebase-backend/apierror"
 	"golang-firebase-backend/config"
 	"golang-firebase-backend/utils"
 	"net/http"
@@ -10,16 +10,16 @@ import (
 )
 
 func ListFiles(w http.ResponseWriter, r *http.Request) {
-	ctx := context.Background()
-	client, err := config.FirebaseApp.Storage(ctx)
+	ctx := r.Context()
+	client, err := config.Storage(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Storage")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Storage")
 		return
 	}
 
-	bucketHandle, err := client.Bucket("skillx-butterscoth.firebasestorage.app")
+	bucketHandle, err := client.DefaultBucket()
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to get bucket handle")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to get bucket handle")
 		return
 	}
 
@@ -32,7 +32,7 @@ func ListFiles(w http.ResponseWriter, r *http.Request) {
 			break
 		}
 		if err != nil {
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to iterate files")
+			apierror.Respond(w, r, http.StatusInternalServerError, "Failed to iterate files")
 			return
 		}
 		files = append(files, obj.Name)
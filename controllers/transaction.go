@@ -2,46 +2,64 @@ package controllers
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/sha512"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"net/http"
-	"strconv"
-	"strings"
 	"time"
 
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/auth"
 	"golang-firebase-backend/config"
+	"golang-firebase-backend/jobs"
+	"golang-firebase-backend/logging"
+	"golang-firebase-backend/metrics"
 	"golang-firebase-backend/models"
+	"golang-firebase-backend/notify"
+	"golang-firebase-backend/tracing"
 	"golang-firebase-backend/utils"
+	"golang-firebase-backend/validate"
 
+	"firebase.google.com/go/db"
 	"github.com/google/uuid"
 	"github.com/midtrans/midtrans-go"
 	"github.com/midtrans/midtrans-go/snap"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-func CreateTransaction(w http.ResponseWriter, r *http.Request) {
-	var transactionInput struct {
-		UserId    string `json:"user_id"`
-		ProductId string `json:"product_id"`
-		Quantity  int    `json:"quantity"`
-	}
+// Payments serves the transaction routes. main builds it with the Snap
+// client and the database it initialized, so that the payment handlers do
+// not depend on package globals and can be given fakes.
+type Payments struct {
+	Midtrans *config.MidtransConfig
+	Database func(ctx context.Context) (*db.Client, error)
+}
+
+func (p *Payments) CreateTransaction(w http.ResponseWriter, r *http.Request) {
+	logger := logging.From(r.Context())
+	var transactionInput models.TransactionInput
 
 	// Decode JSON input
-	if err := json.NewDecoder(r.Body).Decode(&transactionInput); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, "Invalid input")
+	if err := validate.Decode(w, r, &transactionInput); err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
 	// Validate input
 	if transactionInput.UserId == "" || transactionInput.ProductId == "" || transactionInput.Quantity <= 0 {
-		utils.RespondError(w, http.StatusBadRequest, "User ID, Product ID, and quantity are required")
+		apierror.Respond(w, r, http.StatusBadRequest, "User ID, Product ID, and quantity are required")
 		return
 	}
 
 	// Initialize Firebase client
-	ctx := context.Background()
-	client, err := config.Database(ctx)
+	// Tidak dibatalkan saat klien putus, agar transaksi Midtrans yang sudah dibuat tetap tersimpan
+	ctx := context.WithoutCancel(r.Context())
+	client, err := p.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
@@ -49,37 +67,40 @@ func CreateTransaction(w http.ResponseWriter, r *http.Request) {
 	productRef := client.NewRef("products/" + transactionInput.UserId + "/" + transactionInput.ProductId)
 	var product models.Product
 	if err := productRef.Get(ctx, &product); err != nil {
-		fmt.Printf("Error fetching product from Firebase: %v\n", err)
-		utils.RespondError(w, http.StatusNotFound, "Product not found")
+		logger.Error("failed to fetch product", "product_id", transactionInput.ProductId, "error", err)
+		apierror.Respond(w, r, http.StatusNotFound, "Product not found")
 		return
 	}
 
-	// Log product data for debugging
-	fmt.Printf("Fetched product: %+v\n", product)
-
 	// Validate product data
 	if product.Price == "" {
-		utils.RespondError(w, http.StatusBadRequest, "Product price is missing")
+		apierror.Respond(w, r, http.StatusBadRequest, "Product price is missing")
 		return
 	}
 
-	// Normalize and parse price
-	normalizedPrice := strings.ReplaceAll(product.Price, ".", "")
-	normalizedPrice = strings.ReplaceAll(normalizedPrice, ",", "")
-	pricePerUnit, err := strconv.ParseFloat(normalizedPrice, 64)
-	if err != nil {
-		fmt.Printf("Error parsing price: %v (Raw Price: %s)\n", err, product.Price)
-		utils.RespondError(w, http.StatusInternalServerError, "Invalid product price format")
+	// Parse the price, which may be grouped by thousands like "150.000"
+	price, ok := validate.ParsePrice(product.Price)
+	if !ok {
+		logger.Error("invalid product price", "product_id", transactionInput.ProductId, "price", product.Price)
+		apierror.Respond(w, r, http.StatusInternalServerError, "Invalid product price format")
 		return
 	}
+	pricePerUnit := float64(price)
 
 	// Calculate total price
 	totalPrice := pricePerUnit * float64(transactionInput.Quantity)
 
+	// Pembeli adalah pengguna yang login; user_id pada input adalah penjual
+	buyerID, ok := auth.UID(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
 	// Create a new transaction
 	transaction := models.Transaction{
 		IdTransaction:   uuid.New().String(),
-		UserId:          transactionInput.UserId,
+		UserId:          buyerID,
 		SellerId:        transactionInput.UserId,
 		ProductId:       transactionInput.ProductId,
 		Price:           fmt.Sprintf("%.2f", pricePerUnit),
@@ -92,13 +113,13 @@ func CreateTransaction(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Midtrans Snap API integration
-	if config.GlobalMidtransConfig == nil || config.GlobalMidtransConfig.SnapClient == nil {
-		fmt.Println("Midtrans SnapClient is not initialized")
-		utils.RespondError(w, http.StatusInternalServerError, "Payment gateway not configured")
+	if !p.configured() {
+		logger.Error("Midtrans SnapClient is not initialized")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Payment gateway not configured")
 		return
 	}
 
-	snapClient := config.GlobalMidtransConfig.SnapClient
+	snapClient := p.Midtrans.SnapClient
 
 	// Prepare Snap request
 	snapReq := &snap.Request{
@@ -119,14 +140,20 @@ func CreateTransaction(w http.ResponseWriter, r *http.Request) {
 		},
 	}
 
-	// Log Snap request for debugging
-	fmt.Printf("Snap Request: %+v\n", snapReq)
+	// Hanya ringkasan yang dicatat; data pelanggan tidak boleh masuk log
+	logger.Debug("creating Snap transaction", "order_id", transaction.IdTransaction, "gross_amount", snapReq.TransactionDetails.GrossAmt)
 
 	// Create transaction on Midtrans
-	snapResp, err := snapClient.CreateTransaction(snapReq)
+	snapStart := time.Now()
+	_, span := tracing.Start(ctx, "midtrans.snap.create_transaction", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("midtrans.order_id", transaction.IdTransaction)))
+	snapResp, snapErr := snapClient.CreateTransaction(snapReq)
+	err = asError(snapErr)
+	tracing.End(span, err)
+	metrics.ObserveSnap(snapStart, err)
 	if err != nil {
-		fmt.Printf("Error creating Snap transaction: %v\n", err)
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to create transaction with payment gateway")
+		logger.Error("failed to create Snap transaction", "order_id", transaction.IdTransaction, "error", err)
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to create transaction with payment gateway")
 		return
 	}
 
@@ -134,15 +161,33 @@ func CreateTransaction(w http.ResponseWriter, r *http.Request) {
 	transaction.PaymentToken = snapResp.Token
 	transaction.PaymentUrl = snapResp.RedirectURL
 
-	// Save transaction to Firebase
-	transactionRef := client.NewRef("transactions/" + transaction.UserId + "/" + transaction.IdTransaction)
-	if err := transactionRef.Set(ctx, &transaction); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to store transaction data")
+	// Save transaction to Firebase together with the buyer's notification
+	updates := map[string]interface{}{
+		transactionPath(transaction.UserId, transaction.IdTransaction): &transaction,
+		transactionOwnerPath(transaction.IdTransaction):                transaction.UserId,
+	}
+	if err := notify.Stage(updates, transaction.UserId, models.Notification{
+		Type:  notify.TypeOrderStatusChanged,
+		Title: "Complete your SkillX payment",
+		Body: fmt.Sprintf("Your order for %s (x%d) totalling Rp %s is waiting for payment.",
+			product.NameProduct, transaction.Quantity, transaction.TotalPrice),
+		Data: map[string]string{
+			"transaction_id": transaction.IdTransaction,
+			"status":         transaction.Status,
+			"payment_url":    transaction.PaymentUrl,
+		},
+	}); err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to queue order notification")
+		return
+	}
+
+	if err := client.NewRef("").Update(ctx, updates); err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to store transaction data")
 		return
 	}
+	jobs.Notify()
 
-	// Log successful transaction creation
-	fmt.Println("Transaction created successfully")
+	logger.Info("transaction created", "transaction_id", transaction.IdTransaction, "product_id", transaction.ProductId)
 
 	// Respond with Snap token and redirect URL
 	utils.RespondJSON(w, http.StatusCreated, map[string]interface{}{
@@ -154,3 +199,186 @@ func CreateTransaction(w http.ResponseWriter, r *http.Request) {
 		"message": "Transaction created successfully",
 	})
 }
+
+func transactionPath(buyerID, id string) string {
+	return "transactions/" + buyerID + "/" + id
+}
+
+// transactionOwnerPath indexes the buyer of a transaction by its ID, which is
+// all a payment notification carries
+func transactionOwnerPath(id string) string {
+	return "transactionOwners/" + id
+}
+
+var errUnknownTransaction = errors.New("unknown transaction")
+
+// HandlePaymentNotification receives the payment status notifications of
+// Midtrans. The buyer is told about every change of the order status and the
+// seller about the payment once it is settled.
+func (p *Payments) HandlePaymentNotification(w http.ResponseWriter, r *http.Request) {
+	logger := logging.From(r.Context())
+
+	var notification models.PaymentNotification
+	if err := validate.DecodeLoose(w, r, &notification); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	if !p.configured() {
+		apierror.Respond(w, r, http.StatusServiceUnavailable, "Payment gateway not configured")
+		return
+	}
+	if !validPaymentSignature(notification, p.Midtrans.ServerKey.Value()) {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Invalid signature")
+		return
+	}
+
+	status, ok := paymentStatus(notification.TransactionStatus, notification.FraudStatus)
+	if !ok {
+		// Refunds and other statuses do not change the order
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+		return
+	}
+
+	ctx := context.WithoutCancel(r.Context())
+	client, err := p.Database(ctx)
+	if err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		return
+	}
+
+	transaction, changed, err := updatePaymentStatus(ctx, client, notification, status)
+	if errors.Is(err, errUnknownTransaction) {
+		apierror.Respond(w, r, http.StatusNotFound, "Transaction not found")
+		return
+	}
+	if err != nil {
+		logger.Error("failed to update transaction status", "order_id", notification.OrderID, "error", err)
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to update transaction")
+		return
+	}
+	// Midtrans mengirim ulang notifikasi yang sama; notifikasi hanya sekali
+	if !changed {
+		utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+		return
+	}
+
+	updates := map[string]interface{}{}
+	data := map[string]string{"transaction_id": transaction.IdTransaction, "status": status}
+	if err := notify.Stage(updates, transaction.UserId, models.Notification{
+		Type:  notify.TypeOrderStatusChanged,
+		Title: "SkillX order " + status,
+		Body:  fmt.Sprintf("Your order totalling Rp %s is now %s.", transaction.TotalPrice, status),
+		Data:  data,
+	}); err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to queue order notification")
+		return
+	}
+	if status == "paid" && transaction.SellerId != "" {
+		if err := notify.Stage(updates, transaction.SellerId, models.Notification{
+			Type:  notify.TypePaymentReceived,
+			Title: "Payment received",
+			Body:  fmt.Sprintf("An order of %d item(s) totalling Rp %s was paid.", transaction.Quantity, transaction.TotalPrice),
+			Data:  data,
+		}); err != nil {
+			apierror.Respond(w, r, http.StatusInternalServerError, "Failed to queue payment notification")
+			return
+		}
+	}
+	if err := client.NewRef("").Update(ctx, updates); err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to queue notifications")
+		return
+	}
+	jobs.Notify()
+
+	logger.Info("transaction status updated", "transaction_id", transaction.IdTransaction, "status", status)
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{"success": true})
+}
+
+// configured reports whether the Snap client is ready to create payments
+func (p *Payments) configured() bool {
+	return p.Midtrans != nil && p.Midtrans.SnapClient != nil
+}
+
+// validPaymentSignature checks the signature Midtrans computes from the
+// notification and the server key
+func validPaymentSignature(n models.PaymentNotification, serverKey string) bool {
+	sum := sha512.Sum512([]byte(n.OrderID + n.StatusCode + n.GrossAmount + serverKey))
+	expected := hex.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(n.SignatureKey)) == 1
+}
+
+// paymentStatus maps a Midtrans transaction status to the status of the order.
+// Card payments are captured before the fraud check; only accepted ones are paid.
+func paymentStatus(transactionStatus, fraudStatus string) (string, bool) {
+	switch transactionStatus {
+	case "capture":
+		switch fraudStatus {
+		case "accept", "":
+			return "paid", true
+		case "challenge":
+			return "pending", true
+		case "deny":
+			return "failed", true
+		}
+		return "", false
+	case "settlement":
+		return "paid", true
+	case "pending":
+		return "pending", true
+	case "deny", "failure":
+		return "failed", true
+	case "cancel", "expire":
+		return "cancelled", true
+	}
+	return "", false
+}
+
+// updatePaymentStatus saves the new status of the transaction and reports
+// whether it changed
+func updatePaymentStatus(ctx context.Context, client *db.Client, n models.PaymentNotification, status string) (models.Transaction, bool, error) {
+	var buyerID string
+	if err := client.NewRef(transactionOwnerPath(n.OrderID)).Get(ctx, &buyerID); err != nil {
+		return models.Transaction{}, false, err
+	}
+	if buyerID == "" {
+		return models.Transaction{}, false, errUnknownTransaction
+	}
+
+	var transaction models.Transaction
+	changed := false
+	err := client.NewRef(transactionPath(buyerID, n.OrderID)).Transaction(ctx, func(node db.TransactionNode) (interface{}, error) {
+		if err := node.Unmarshal(&transaction); err != nil {
+			return nil, err
+		}
+		if transaction.IdTransaction == "" {
+			return nil, errUnknownTransaction
+		}
+		// A paid order stays paid, whatever arrives late
+		changed = transaction.Status != status && transaction.Status != "paid"
+		if !changed {
+			return transaction, nil
+		}
+
+		now := time.Now()
+		transaction.Status = status
+		transaction.OrderId = n.OrderID
+		transaction.UpdatedAt = now
+		if n.PaymentType != "" {
+			transaction.PaymentType = n.PaymentType
+		}
+		if status == "paid" {
+			transaction.SettlementTime = now
+		}
+		return transaction, nil
+	})
+	return transaction, changed, err
+}
+
+// asError converts the *midtrans.Error returned by the Snap client to an
+// error, so that a nil pointer does not become a non-nil interface
+func asError(err *midtrans.Error) error {
+	if err == nil {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,112 @@
+package controllers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"golang-firebase-backend/config"
+	"golang-firebase-backend/models"
+
+	"firebase.google.com/go/db"
+	"github.com/midtrans/midtrans-go/snap"
+)
+
+func TestPaymentStatus(t *testing.T) {
+	tests := []struct {
+		transactionStatus string
+		fraudStatus       string
+		want              string
+		wantKnown         bool
+	}{
+		{"capture", "accept", "paid", true},
+		{"capture", "", "paid", true},
+		{"capture", "challenge", "pending", true},
+		{"capture", "deny", "failed", true},
+		{"capture", "review", "", false},
+		{"settlement", "", "paid", true},
+		{"settlement", "accept", "paid", true},
+		{"pending", "", "pending", true},
+		{"deny", "", "failed", true},
+		{"deny", "deny", "failed", true},
+		{"failure", "", "failed", true},
+		{"cancel", "", "cancelled", true},
+		{"expire", "", "cancelled", true},
+		{"refund", "", "", false},
+		{"", "", "", false},
+	}
+	for _, tt := range tests {
+		got, known := paymentStatus(tt.transactionStatus, tt.fraudStatus)
+		if got != tt.want || known != tt.wantKnown {
+			t.Errorf("paymentStatus(%q, %q) = %q, %v; want %q, %v",
+				tt.transactionStatus, tt.fraudStatus, got, known, tt.want, tt.wantKnown)
+		}
+	}
+}
+
+func TestValidPaymentSignature(t *testing.T) {
+	const serverKey = "SB-Mid-server-test"
+	n := sign(models.PaymentNotification{OrderID: "order-1", StatusCode: "200", GrossAmount: "150000.00"}, serverKey)
+
+	if !validPaymentSignature(n, serverKey) {
+		t.Error("valid signature was rejected")
+	}
+	if validPaymentSignature(n, "other-key") {
+		t.Error("signature made with another server key was accepted")
+	}
+	tampered := n
+	tampered.GrossAmount = "1.00"
+	if validPaymentSignature(tampered, serverKey) {
+		t.Error("signature of a changed amount was accepted")
+	}
+}
+
+// sign sets the signature Midtrans computes with serverKey
+func sign(n models.PaymentNotification, serverKey string) models.PaymentNotification {
+	sum := sha512.Sum512([]byte(n.OrderID + n.StatusCode + n.GrossAmount + serverKey))
+	n.SignatureKey = hex.EncodeToString(sum[:])
+	return n
+}
+
+func TestHandlePaymentNotification(t *testing.T) {
+	const serverKey = "SB-Mid-server-test"
+	midtrans := &config.MidtransConfig{ServerKey: serverKey, SnapClient: &snap.Client{}}
+	unavailable := func(context.Context) (*db.Client, error) { return nil, errors.New("database unavailable") }
+	settled := models.PaymentNotification{
+		OrderID: "order-1", StatusCode: "200", GrossAmount: "150000.00", TransactionStatus: "settlement",
+	}
+	refunded := settled
+	refunded.TransactionStatus = "refund"
+
+	tests := []struct {
+		name         string
+		payments     *Payments
+		notification models.PaymentNotification
+		wantStatus   int
+	}{
+		{"gateway not configured", &Payments{Database: unavailable}, sign(settled, serverKey), http.StatusServiceUnavailable},
+		{"signature of another key", &Payments{Midtrans: midtrans, Database: unavailable}, sign(settled, "other-key"), http.StatusUnauthorized},
+		{"status that changes nothing", &Payments{Midtrans: midtrans, Database: unavailable}, sign(refunded, serverKey), http.StatusOK},
+		{"database unavailable", &Payments{Midtrans: midtrans, Database: unavailable}, sign(settled, serverKey), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(tt.notification)
+			if err != nil {
+				t.Fatalf("Marshal: %v", err)
+			}
+			r := httptest.NewRequest(http.MethodPost, "/api/v1/transactions/notifications", bytes.NewReader(body))
+			w := httptest.NewRecorder()
+			tt.payments.HandlePaymentNotification(w, r)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
@@ -1,51 +1,51 @@
 package controllers
 
 import (
-	"encoding/json"
 	"net/http"
 
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/auth"
 	"golang-firebase-backend/config"
 	"golang-firebase-backend/models"
 	"golang-firebase-backend/utils"
-
-	"golang.org/x/net/context"
+	"golang-firebase-backend/validate"
 )
 
 func AddUserSkill(w http.ResponseWriter, r *http.Request) {
 	// Retrieve the UID from context
-	uid := r.Context().Value("uid")
-	if uid == nil {
-		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized access")
+	uid, ok := auth.UID(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
 		return
 	}
 
 	// Parse the request body for skill data
 	var userSkill models.UserSkill
-	if err := json.NewDecoder(r.Body).Decode(&userSkill); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, "Invalid request payload")
+	if err := validate.Decode(w, r, &userSkill); err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
 	// Ensure the UserId from the request matches the UID in context
 	if userSkill.UserId != uid {
-		utils.RespondError(w, http.StatusUnauthorized, "User ID does not match authentication token")
+		apierror.Respond(w, r, http.StatusUnauthorized, "User ID does not match authentication token")
 		return
 	}
 
 	// Process the skill addition for the user (UID)
 	// Example logic: Save skill to Firebase Realtime Database or Firestore
 	// Save the skill to the Firebase Realtime Database (or Firestore, as needed)
-	ctx := context.Background()
-	dbClient, err := config.FirebaseApp.Database(ctx)
+	ctx := r.Context()
+	dbClient, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
 	// Save the user skill data
 	ref := dbClient.NewRef("user_skills/" + userSkill.UserId + "/" + userSkill.IdSkill)
 	if err := ref.Set(ctx, userSkill); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to save user skill data")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to save user skill data")
 		return
 	}
 
@@ -56,25 +56,25 @@ func AddUserSkill(w http.ResponseWriter, r *http.Request) {
 }
 func ListUserSkills(w http.ResponseWriter, r *http.Request) {
 	// Retrieve the UID from context
-	uid := r.Context().Value("uid")
-	if uid == nil {
-		utils.RespondError(w, http.StatusUnauthorized, "Unauthorized access")
+	uid, ok := auth.UID(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
 		return
 	}
 
 	// Fetch skills from Firebase Realtime Database
-	ctx := context.Background()
-	dbClient, err := config.FirebaseApp.Database(ctx)
+	ctx := r.Context()
+	dbClient, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
 	// Get all skills for the user
-	ref := dbClient.NewRef("user_skills/" + uid.(string))
+	ref := dbClient.NewRef("user_skills/" + uid)
 	var skills map[string]models.UserSkill
 	if err := ref.Get(ctx, &skills); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to retrieve user skills: "+err.Error())
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to retrieve user skills: "+err.Error())
 		return
 	}
 
@@ -2,13 +2,18 @@ package controllers
 
 import (
 	"context"
-	"encoding/json"
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/auth"
 	"golang-firebase-backend/config"
+	"golang-firebase-backend/etag"
+	"golang-firebase-backend/logging"
+	"golang-firebase-backend/mergepatch"
 	"golang-firebase-backend/models"
+	"golang-firebase-backend/organizations"
 	"golang-firebase-backend/services"
 	"golang-firebase-backend/utils"
+	"golang-firebase-backend/validate"
 
-	"log"
 	"net/http"
 	"strings"
 )
@@ -17,15 +22,15 @@ func GetUser(w http.ResponseWriter, r *http.Request) {
 	// Ambil UID dari query parameter
 	uid := r.URL.Query().Get("uid")
 	if uid == "" {
-		utils.RespondError(w, http.StatusBadRequest, "UID is required")
+		apierror.Respond(w, r, http.StatusBadRequest, "UID is required")
 		return
 	}
 
 	// Inisialisasi Firebase Realtime Database
-	ctx := context.Background()
-	client, err := config.FirebaseApp.Database(ctx)
+	ctx := r.Context()
+	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
@@ -33,7 +38,7 @@ func GetUser(w http.ResponseWriter, r *http.Request) {
 	var user models.User
 	ref := client.NewRef("users/" + uid)
 	if err := ref.Get(ctx, &user); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch user data")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch user data")
 		return
 	}
 
@@ -43,139 +48,98 @@ func GetUser(w http.ResponseWriter, r *http.Request) {
 		var major models.Major
 		majorRef := client.NewRef("majors/" + user.Major)
 		if err := majorRef.Get(ctx, &major); err != nil {
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch major data")
+			apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch major data")
 			return
 		}
 		majorTitle = major.TitleMajor
 	}
 
 	// Tambahkan major title ke response user
-	response := map[string]interface{}{
-		"uid":          user.UID,
-		"name":         user.Name,
-		"email":        user.Email,
-		"organization": user.Organization,
-		"major": map[string]string{
+	user.UID = uid
+	response := struct {
+		models.UserProfile
+		Major map[string]string `json:"major"`
+	}{
+		UserProfile: user.Profile(auth.View(ctx, uid)),
+		Major: map[string]string{
 			"idMajor":    user.Major,
 			"titleMajor": majorTitle,
 		},
-		"language":   user.Language,
-		"photo_url":  user.PhotoURL,
-		"verified":   user.Verified,
-		"role":       user.Role,
-		"created_at": user.CreatedAt,
-		"last_sign_in": func() interface{} {
-			if user.LastSignIn.IsZero() {
-				return nil
-			}
-			return user.LastSignIn
-		}(),
 	}
 
 	utils.RespondJSON(w, http.StatusOK, response)
 }
 func FetchUserByUID(w http.ResponseWriter, r *http.Request) {
-	// Extract UID from the request query parameters
-	uid := r.URL.Query().Get("uid")
+	// Extract UID from the request path or query parameters
+	uid := utils.Param(r, "uid", "uid")
 	if uid == "" {
-		utils.RespondError(w, http.StatusBadRequest, "UID is required")
+		apierror.Respond(w, r, http.StatusBadRequest, "UID is required")
 		return
 	}
 
 	// Initialize Firebase Realtime Database
-	ctx := context.Background()
-	dbClient, err := config.FirebaseApp.Database(ctx)
+	ctx := r.Context()
+	dbClient, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
 	// Retrieve user data from Firebase
 	var user models.User
 	userRef := dbClient.NewRef("users/" + uid)
-	if err := userRef.Get(ctx, &user); err != nil {
-		utils.RespondError(w, http.StatusNotFound, "User not found")
+	tag, err := etag.Get(ctx, userRef, &user)
+	if err != nil {
+		apierror.Respond(w, r, http.StatusNotFound, "User not found")
 		return
 	}
 
 	// Prepare the response data
-	response := map[string]interface{}{
-		"uid":          user.UID,
-		"name":         user.Name,
-		"email":        user.Email,
-		"organization": user.Organization,
-		"major":        user.Major,
-		"language":     user.Language,
-		"photo_url":    user.PhotoURL,
-		"verified":     user.Verified,
-		"role":         user.Role,
-		"created_at":   user.CreatedAt,
-		"last_sign_in": func() interface{} {
-			if user.LastSignIn.IsZero() {
-				return nil
-			}
-			return user.LastSignIn
-		}(),
-	}
+	user.UID = uid
+	response := user.Profile(auth.View(ctx, uid))
 
 	// Send the response back to the frontend
+	etag.Set(w, tag)
 	utils.RespondJSON(w, http.StatusOK, response)
 }
 
 func UpdateUser(w http.ResponseWriter, r *http.Request) {
-	log.Printf("Received request on %s", r.URL.Path)
-	ctx := context.Background()
-
-	// Extract Authorization header
-	authHeader := r.Header.Get("Authorization")
-	if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
-		log.Printf("Invalid or missing Authorization header")
-		http.Error(w, "Missing or invalid Authorization header", http.StatusUnauthorized)
-		return
-	}
-	idToken := strings.TrimPrefix(authHeader, "Bearer ")
+	logger := logging.From(r.Context())
+	ctx := r.Context()
 
-	// Verify ID token
-	client, err := config.FirebaseApp.Auth(ctx)
-	if err != nil {
-		log.Printf("Failed to initialize Firebase Auth: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	uid, ok := auth.UID(ctx)
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized")
 		return
 	}
 
-	token, err := client.VerifyIDToken(ctx, idToken)
-	if err != nil {
-		log.Printf("Invalid or expired token: %v", err)
-		http.Error(w, "Unauthorized", http.StatusUnauthorized)
+	if mergepatch.Requested(r) {
+		patchUser(w, r, uid)
 		return
 	}
 
-	uid := token.Subject
-	log.Printf("Verified UID: %s", uid)
-
 	// Decode the request payload
 	var updatedUser map[string]interface{}
-	if err := json.NewDecoder(r.Body).Decode(&updatedUser); err != nil {
-		utils.RespondError(w, http.StatusBadRequest, "Invalid request payload")
+	if err := validate.Decode(w, r, &updatedUser); err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
 	// Initialize Firebase Database
-	dbClient, err := config.FirebaseApp.Database(ctx)
+	dbClient, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
 	// Reference the user in the database
 	userRef := dbClient.NewRef("users/" + uid)
-	log.Printf("Updating Firebase user: %s", uid)
 
 	// Validate existing user
 	var existingUser models.User
 	if err := userRef.Get(ctx, &existingUser); err != nil {
-		log.Printf("User not found: %v", err)
-		utils.RespondError(w, http.StatusNotFound, "User not found")
+		logger.Warn("user not found", "error", err)
+		apierror.Respond(w, r, http.StatusNotFound, "User not found")
 		return
 	}
 
@@ -187,7 +151,14 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 		existingUser.Name = name
 	}
 	if organization, ok := updatedUser["organization"].(string); ok && organization != "" {
-		existingUser.Organization = organization
+		warning, err := setOrganization(ctx, &existingUser, organization)
+		if err != nil {
+			apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch organizations")
+			return
+		}
+		if warning != "" {
+			warnings = append(warnings, warning)
+		}
 	}
 	if language, ok := updatedUser["language"].(string); ok && language != "" {
 		existingUser.Language = language
@@ -200,7 +171,7 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 			if services.IsValidMajorTitle(ctx, v) {
 				existingUser.Major = v
 			} else {
-				log.Printf("Invalid major title: %s, removing major", v)
+				logger.Warn("invalid major title, removing major", "major", v)
 				existingUser.Major = "" // Remove major if invalid
 				warnings = append(warnings, "Provided major is not registered. Major field will not be updated.")
 			}
@@ -209,29 +180,37 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 				if services.IsValidMajorTitle(ctx, titleMajor) {
 					existingUser.Major = titleMajor
 				} else {
-					log.Printf("Invalid major title: %s, removing major", titleMajor)
+					logger.Warn("invalid major title, removing major", "major", titleMajor)
 					existingUser.Major = "" // Remove major if invalid
 					warnings = append(warnings, "Provided major is not registered. Major field will not be updated.")
 				}
 			} else {
-				log.Printf("Invalid major format, removing major")
+				logger.Warn("invalid major format, removing major")
 				existingUser.Major = "" // Remove major if invalid format
 				warnings = append(warnings, "Invalid major format provided. Major field will not be updated.")
 			}
 		default:
-			log.Printf("Invalid major format, removing major")
+			logger.Warn("invalid major format, removing major")
 			existingUser.Major = "" // Remove major if invalid format
 			warnings = append(warnings, "Invalid major format provided. Major field will not be updated.")
 		}
 	}
 
-	// Write updated user to Firebase
-	if err := userRef.Set(ctx, existingUser); err != nil {
-		log.Printf("Failed to update user in Firebase: %v", err)
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to update user data")
+	// Write updated fields to Firebase; If-Match dicek di dalam transaksi
+	tag, err := etag.Update(ctx, r, userRef, map[string]interface{}{
+		"name":            existingUser.Name,
+		"organization":    existingUser.Organization,
+		"organization_id": existingUser.OrganizationID,
+		"language":        existingUser.Language,
+		"major":           existingUser.Major,
+	})
+	if err != nil {
+		logger.Error("failed to update user", "error", err)
+		apierror.Write(w, r, err)
 		return
 	}
-	log.Println("User updated successfully")
+	logger.Info("user updated")
+	etag.Set(w, tag)
 
 	// Respond with success and warnings (if any)
 	response := map[string]interface{}{
@@ -242,20 +221,101 @@ func UpdateUser(w http.ResponseWriter, r *http.Request) {
 	utils.RespondJSON(w, http.StatusOK, response)
 }
 
+// patchUser applies a JSON merge patch to the profile of the signed-in user
+func patchUser(w http.ResponseWriter, r *http.Request, uid string) {
+	ctx := r.Context()
+
+	patch, err := mergepatch.Decode(w, r, "name", "organization", "language", "major")
+	if err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	dbClient, err := config.Database(ctx)
+	if err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		return
+	}
+
+	userRef := dbClient.NewRef("users/" + uid)
+	var user models.User
+	if err := userRef.Get(ctx, &user); err != nil || user.UID == "" {
+		apierror.Respond(w, r, http.StatusNotFound, "User not found")
+		return
+	}
+
+	if err := mergepatch.ApplyTo(&user, patch); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	if patch.Has("major") && user.Major != "" && !services.IsValidMajorTitle(ctx, user.Major) {
+		apierror.Write(w, r, apierror.Validation("Invalid input").WithField("major", "is not a registered major"))
+		return
+	}
+
+	updates := mergepatch.Updates(patch)
+	warnings := []string{}
+	if patch.Has("organization") {
+		user.OrganizationID = ""
+		if user.Organization != "" {
+			warning, err := setOrganization(ctx, &user, user.Organization)
+			if err != nil {
+				apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch organizations")
+				return
+			}
+			if warning != "" {
+				warnings = append(warnings, warning)
+			}
+			updates["organization"] = user.Organization
+		}
+		updates["organization_id"] = user.OrganizationID
+	}
+
+	tag, err := etag.Update(ctx, r, userRef, updates)
+	if err != nil {
+		logging.From(r.Context()).Error("failed to patch user", "error", err)
+		apierror.Write(w, r, err)
+		return
+	}
+	etag.Set(w, tag)
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"message":  "User updated successfully",
+		"uid":      uid,
+		"warnings": warnings,
+	})
+}
+
+// setOrganization sets the organization of the user from an organization ID
+// or name. Names the registry does not know are kept as free text, with a
+// warning for the response.
+func setOrganization(ctx context.Context, user *models.User, organization string) (string, error) {
+	org, ok, err := organizations.Resolve(ctx, organization)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		user.Organization, user.OrganizationID, user.OrganizationRaw = organization, "", ""
+		return "Provided organization is not registered. Pick one from /api/v1/organizations/match to link it.", nil
+	}
+	user.Organization, user.OrganizationID, user.OrganizationRaw = org.Name, org.ID, ""
+	return "", nil
+}
+
 func SearchUsersByName(w http.ResponseWriter, r *http.Request) {
 	// Get the search term from query parameters
 	searchTerm := r.URL.Query().Get("query")
 	if searchTerm == "" {
-		utils.RespondError(w, http.StatusBadRequest, "Search term is required")
+		apierror.Respond(w, r, http.StatusBadRequest, "Search term is required")
 		return
 	}
 
-	ctx := context.Background()
+	ctx := r.Context()
 
 	// Initialize Firebase Realtime Database
-	client, err := config.FirebaseApp.Database(ctx)
+	client, err := config.Database(ctx)
 	if err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to connect to Firebase Database")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase Database")
 		return
 	}
 
@@ -265,27 +325,16 @@ func SearchUsersByName(w http.ResponseWriter, r *http.Request) {
 
 	// Fetch all users from the database
 	if err := usersRef.Get(ctx, &users); err != nil {
-		utils.RespondError(w, http.StatusInternalServerError, "Failed to fetch users")
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch users")
 		return
 	}
 
 	// Filter users based on the search term
-	var matchingUsers []map[string]interface{}
+	var matchingUsers []models.UserProfile
 	for id, user := range users {
 		if strings.Contains(strings.ToLower(user.Name), strings.ToLower(searchTerm)) {
-			matchingUsers = append(matchingUsers, map[string]interface{}{
-				"uid":          id,
-				"name":         user.Name,
-				"email":        user.Email,
-				"organization": user.Organization,
-				"major":        user.Major,
-				"language":     user.Language,
-				"photo_url":    user.PhotoURL,
-				"verified":     user.Verified,
-				"role":         user.Role,
-				"created_at":   user.CreatedAt,
-				"last_sign_in": user.LastSignIn,
-			})
+			user.UID = id
+			matchingUsers = append(matchingUsers, user.Profile(auth.View(ctx, id)))
 		}
 	}
 
@@ -0,0 +1,278 @@
+// Package cors answers cross-origin requests from the browser apps. Origins
+// are matched against an allow-list that may contain wildcard subdomain
+// patterns such as "https://*.skillx.id", and single routes can use a
+// different policy than the rest of the API.
+package cors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang-firebase-backend/apierror"
+)
+
+// Defaults used when a policy leaves the list empty
+var (
+	DefaultMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+	DefaultHeaders = []string{
+		"Accept", "Authorization", "Content-Type", "Origin", "X-Auth-Token",
+		"X-Request-ID", "If-Match", "If-None-Match", "traceparent", "tracestate",
+	}
+	DefaultExposedHeaders = []string{
+		"X-Request-ID", "ETag", "Link", "Deprecation", "Retry-After",
+		"RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy",
+	}
+)
+
+// Policy decides which cross-origin requests are allowed
+type Policy struct {
+	// AllowedOrigins lists origins such as "https://app.skillx.id",
+	// patterns such as "https://*.skillx.id" that match any subdomain, or
+	// "*" for every origin
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	ExposedHeaders []string
+	// AllowCredentials lets the browser send cookies and Authorization with
+	// the request. It cannot be combined with "*", since that would let
+	// every site make credentialed requests.
+	AllowCredentials bool
+	// MaxAge lets the browser cache the answer to a preflight request
+	MaxAge time.Duration
+}
+
+// ErrCredentialsWithAnyOrigin is returned for a policy that allows
+// credentials from every origin
+var ErrCredentialsWithAnyOrigin = errors.New(`cors: "*" cannot be an allowed origin when credentials are allowed`)
+
+// CORS applies a default policy and per-route overrides
+type CORS struct {
+	policy *policy
+	routes map[string]*policy
+}
+
+// New returns a CORS middleware applying policy to every route
+func New(p Policy) (*CORS, error) {
+	compiled, err := compile(p)
+	if err != nil {
+		return nil, err
+	}
+	return &CORS{policy: compiled, routes: map[string]*policy{}}, nil
+}
+
+// Route applies p instead of the default policy to the route with path, the
+// ServeMux path pattern such as "/api/v1/products/{id}"
+func (c *CORS) Route(path string, p Policy) error {
+	compiled, err := compile(p)
+	if err != nil {
+		return fmt.Errorf("%w (route %s)", err, path)
+	}
+	c.routes[path] = compiled
+	return nil
+}
+
+// Middleware answers preflight requests and adds the CORS headers to the
+// responses of allowed origins. route returns the pattern that handles a
+// request, which selects the policy.
+func (c *CORS) Middleware(route func(*http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		requestedMethod := r.Header.Get("Access-Control-Request-Method")
+		preflight := r.Method == http.MethodOptions && requestedMethod != ""
+
+		w.Header().Add("Vary", "Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if !preflight {
+			p := c.lookup(route(r))
+			if allowOrigin, ok := p.allowOrigin(origin); ok {
+				w.Header().Set("Access-Control-Allow-Origin", allowOrigin)
+				if p.credentials {
+					w.Header().Set("Access-Control-Allow-Credentials", "true")
+				}
+				if p.exposed != "" {
+					w.Header().Set("Access-Control-Expose-Headers", p.exposed)
+				}
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// The policy of the route the actual request will be sent to
+		probe := *r
+		probe.Method = requestedMethod
+		p := c.lookup(route(&probe))
+
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+
+		allowOrigin, ok := p.allowOrigin(origin)
+		if !ok {
+			apierror.Respond(w, r, http.StatusForbidden, "Origin "+origin+" is not allowed")
+			return
+		}
+		if !p.methods[requestedMethod] {
+			apierror.Respond(w, r, http.StatusForbidden, "Method "+requestedMethod+" is not allowed for cross-origin requests")
+			return
+		}
+		requestedHeaders := headerList(r.Header.Values("Access-Control-Request-Headers"))
+		for _, header := range requestedHeaders {
+			if !p.anyHeader && !p.headers[strings.ToLower(header)] {
+				apierror.Respond(w, r, http.StatusForbidden, "Header "+header+" is not allowed for cross-origin requests")
+				return
+			}
+		}
+
+		w.Header().Set("Access-Control-Allow-Origin", allowOrigin)
+		if p.credentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+		w.Header().Set("Access-Control-Allow-Methods", p.methodList)
+		if len(requestedHeaders) > 0 {
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(requestedHeaders, ", "))
+		}
+		if p.maxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(p.maxAge))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
+
+func (c *CORS) lookup(pattern string) *policy {
+	// Patterns of v1 routes start with the method
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		pattern = path
+	}
+	if p, ok := c.routes[pattern]; ok {
+		return p
+	}
+	return c.policy
+}
+
+// policy is a Policy prepared for matching
+type policy struct {
+	anyOrigin   bool
+	origins     map[string]bool
+	wildcards   []wildcard
+	methods     map[string]bool
+	methodList  string
+	anyHeader   bool
+	headers     map[string]bool
+	exposed     string
+	credentials bool
+	maxAge      int
+}
+
+// wildcard matches the origins of every subdomain, e.g. prefix "https://"
+// and suffix ".skillx.id"
+type wildcard struct {
+	prefix, suffix string
+}
+
+func compile(p Policy) (*policy, error) {
+	compiled := &policy{
+		origins:     map[string]bool{},
+		methods:     map[string]bool{},
+		headers:     map[string]bool{},
+		credentials: p.AllowCredentials,
+		maxAge:      int(p.MaxAge / time.Second),
+	}
+
+	for _, origin := range p.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(origin), "/"))
+		switch {
+		case origin == "*":
+			compiled.anyOrigin = true
+		case strings.Contains(origin, "://*."):
+			scheme, host, _ := strings.Cut(origin, "://*")
+			compiled.wildcards = append(compiled.wildcards, wildcard{prefix: scheme + "://", suffix: host})
+		case origin != "":
+			compiled.origins[origin] = true
+		}
+	}
+	if compiled.anyOrigin && compiled.credentials {
+		return nil, ErrCredentialsWithAnyOrigin
+	}
+
+	methods := p.AllowedMethods
+	if len(methods) == 0 {
+		methods = DefaultMethods
+	}
+	for _, method := range methods {
+		compiled.methods[strings.ToUpper(method)] = true
+	}
+	// Preflight requests are never needed for these, but browsers may ask
+	compiled.methods[http.MethodGet] = true
+	compiled.methods[http.MethodHead] = true
+	compiled.methodList = strings.Join(methods, ", ")
+
+	headers := p.AllowedHeaders
+	if len(headers) == 0 {
+		headers = DefaultHeaders
+	}
+	for _, header := range headers {
+		if header == "*" {
+			compiled.anyHeader = true
+		}
+		compiled.headers[strings.ToLower(header)] = true
+	}
+
+	exposed := p.ExposedHeaders
+	if exposed == nil {
+		exposed = DefaultExposedHeaders
+	}
+	compiled.exposed = strings.Join(exposed, ", ")
+	return compiled, nil
+}
+
+// allowOrigin returns the value of Access-Control-Allow-Origin for origin
+func (p *policy) allowOrigin(origin string) (string, bool) {
+	normalized := strings.ToLower(origin)
+	allowed := p.origins[normalized]
+	for _, w := range p.wildcards {
+		if allowed {
+			break
+		}
+		allowed = w.matches(normalized)
+	}
+	if allowed {
+		return origin, true
+	}
+	if p.anyOrigin {
+		return "*", true
+	}
+	return "", false
+}
+
+func (w wildcard) matches(origin string) bool {
+	if len(origin) <= len(w.prefix)+len(w.suffix) || !strings.HasPrefix(origin, w.prefix) || !strings.HasSuffix(origin, w.suffix) {
+		return false
+	}
+	// Only the subdomain labels may differ
+	for _, c := range origin[len(w.prefix) : len(origin)-len(w.suffix)] {
+		if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-' || c == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+// headerList splits Access-Control-Request-Headers into header names
+func headerList(values []string) []string {
+	var headers []string
+	for _, value := range values {
+		for _, header := range strings.Split(value, ",") {
+			if header = strings.TrimSpace(header); header != "" {
+				headers = append(headers, header)
+			}
+		}
+	}
+	return headers
+}
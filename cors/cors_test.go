@@ -0,0 +1,311 @@
+package cors
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+// serve sends r through c in front of a mux with a few v1 style routes
+func serve(t *testing.T, c *CORS, r *http.Request) *httptest.ResponseRecorder {
+	t.Helper()
+	mux := http.NewServeMux()
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) }
+	mux.HandleFunc("GET /api/v1/products/{id}", ok)
+	mux.HandleFunc("PUT /api/v1/products/{id}", ok)
+	mux.HandleFunc("GET /api/v1/admin/jobs", ok)
+	route := func(r *http.Request) string {
+		_, pattern := mux.Handler(r)
+		return pattern
+	}
+
+	w := httptest.NewRecorder()
+	c.Middleware(route, mux).ServeHTTP(w, r)
+	return w
+}
+
+func request(method, path, origin string) *http.Request {
+	r := httptest.NewRequest(method, path, nil)
+	if origin != "" {
+		r.Header.Set("Origin", origin)
+	}
+	return r
+}
+
+func preflight(path, origin, method, headers string) *http.Request {
+	r := request(http.MethodOptions, path, origin)
+	r.Header.Set("Access-Control-Request-Method", method)
+	if headers != "" {
+		r.Header.Set("Access-Control-Request-Headers", headers)
+	}
+	return r
+}
+
+func mustNew(t *testing.T, p Policy) *CORS {
+	t.Helper()
+	c, err := New(p)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return c
+}
+
+func TestAllowedOrigins(t *testing.T) {
+	c := mustNew(t, Policy{AllowedOrigins: []string{"https://skillx.id", "https://*.skillx.id", "http://localhost:8100/"}})
+
+	tests := []struct {
+		origin string
+		want   string // Access-Control-Allow-Origin, empty when not allowed
+	}{
+		{"https://skillx.id", "https://skillx.id"},
+		{"https://shop.skillx.id", "https://shop.skillx.id"},
+		{"https://admin.eu.skillx.id", "https://admin.eu.skillx.id"},
+		{"https://Shop.SkillX.id", "https://Shop.SkillX.id"},
+		{"http://localhost:8100", "http://localhost:8100"},
+		{"http://shop.skillx.id", ""},
+		{"https://.skillx.id", ""},
+		{"https://evilskillx.id", ""},
+		{"https://shop.skillx.id.evil.com", ""},
+		{"https://shop_1.skillx.id", ""},
+		{"https://evil.com/.skillx.id", ""},
+		{"http://localhost:4200", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			w := serve(t, c, request(http.MethodGet, "/api/v1/products/1", tt.origin))
+			if w.Code != http.StatusOK {
+				t.Errorf("status = %d, want the handler to run", w.Code)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.want {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.want)
+			}
+			if tt.want != "" && w.Header().Get("Access-Control-Expose-Headers") == "" {
+				t.Error("Access-Control-Expose-Headers is missing")
+			}
+		})
+	}
+}
+
+func TestVaryOrigin(t *testing.T) {
+	c := mustNew(t, Policy{AllowedOrigins: []string{"https://skillx.id"}})
+
+	tests := []struct {
+		name string
+		r    *http.Request
+		want []string
+	}{
+		{"without origin", request(http.MethodGet, "/api/v1/products/1", ""), []string{"Origin"}},
+		{"allowed origin", request(http.MethodGet, "/api/v1/products/1", "https://skillx.id"), []string{"Origin"}},
+		{"other origin", request(http.MethodGet, "/api/v1/products/1", "https://evil.com"), []string{"Origin"}},
+		{
+			"preflight",
+			preflight("/api/v1/products/1", "https://skillx.id", http.MethodPut, ""),
+			[]string{"Origin", "Access-Control-Request-Method", "Access-Control-Request-Headers"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, c, tt.r)
+			if got := w.Header().Values("Vary"); !slices.Equal(got, tt.want) {
+				t.Errorf("Vary = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	c := mustNew(t, Policy{
+		AllowedOrigins: []string{"https://*.skillx.id"},
+		AllowedMethods: []string{"GET", "PUT"},
+		MaxAge:         10 * time.Minute,
+	})
+
+	tests := []struct {
+		name        string
+		r           *http.Request
+		wantStatus  int
+		wantHeaders string
+	}{
+		{
+			name:        "allowed method and headers",
+			r:           preflight("/api/v1/products/1", "https://shop.skillx.id", http.MethodPut, "content-type, If-Match"),
+			wantStatus:  http.StatusNoContent,
+			wantHeaders: "content-type, If-Match",
+		},
+		{
+			name:       "no requested headers",
+			r:          preflight("/api/v1/products/1", "https://shop.skillx.id", http.MethodPut, ""),
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "method outside the policy",
+			r:          preflight("/api/v1/products/1", "https://shop.skillx.id", http.MethodDelete, ""),
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "header outside the policy",
+			r:          preflight("/api/v1/products/1", "https://shop.skillx.id", http.MethodPut, "Content-Type, X-Debug"),
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "origin outside the policy",
+			r:          preflight("/api/v1/products/1", "https://skillx.evil.com", http.MethodPut, ""),
+			wantStatus: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, c, tt.r)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if tt.wantStatus != http.StatusNoContent {
+				if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+					t.Errorf("Access-Control-Allow-Origin = %q on a rejected preflight", got)
+				}
+				return
+			}
+
+			for header, want := range map[string]string{
+				"Access-Control-Allow-Origin":  tt.r.Header.Get("Origin"),
+				"Access-Control-Allow-Methods": "GET, PUT",
+				"Access-Control-Allow-Headers": tt.wantHeaders,
+				"Access-Control-Max-Age":       "600",
+			} {
+				if got := w.Header().Get(header); got != want {
+					t.Errorf("%s = %q, want %q", header, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCredentials(t *testing.T) {
+	tests := []struct {
+		name            string
+		policy          Policy
+		origin          string
+		wantOrigin      string
+		wantCredentials string
+	}{
+		{
+			name:            "allowed with credentials",
+			policy:          Policy{AllowedOrigins: []string{"https://skillx.id"}, AllowCredentials: true},
+			origin:          "https://skillx.id",
+			wantOrigin:      "https://skillx.id",
+			wantCredentials: "true",
+		},
+		{
+			name:            "wildcard subdomain with credentials",
+			policy:          Policy{AllowedOrigins: []string{"https://*.skillx.id"}, AllowCredentials: true},
+			origin:          "https://shop.skillx.id",
+			wantOrigin:      "https://shop.skillx.id",
+			wantCredentials: "true",
+		},
+		{
+			name:   "other origin gets no credentials",
+			policy: Policy{AllowedOrigins: []string{"https://skillx.id"}, AllowCredentials: true},
+			origin: "https://evil.com",
+		},
+		{
+			name:       "any origin without credentials",
+			policy:     Policy{AllowedOrigins: []string{"*"}},
+			origin:     "https://evil.com",
+			wantOrigin: "*",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := mustNew(t, tt.policy)
+			for _, r := range []*http.Request{
+				request(http.MethodGet, "/api/v1/products/1", tt.origin),
+				preflight("/api/v1/products/1", tt.origin, http.MethodPut, "Authorization"),
+			} {
+				w := serve(t, c, r)
+				if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+					t.Errorf("%s: Access-Control-Allow-Origin = %q, want %q", r.Method, got, tt.wantOrigin)
+				}
+				if got := w.Header().Get("Access-Control-Allow-Credentials"); got != tt.wantCredentials {
+					t.Errorf("%s: Access-Control-Allow-Credentials = %q, want %q", r.Method, got, tt.wantCredentials)
+				}
+			}
+		})
+	}
+}
+
+func TestAnyOriginWithCredentialsIsRejected(t *testing.T) {
+	anyOrigin := Policy{AllowedOrigins: []string{"https://skillx.id", "*"}, AllowCredentials: true}
+	if _, err := New(anyOrigin); !errors.Is(err, ErrCredentialsWithAnyOrigin) {
+		t.Errorf("New = %v, want ErrCredentialsWithAnyOrigin", err)
+	}
+
+	c := mustNew(t, Policy{AllowedOrigins: []string{"https://skillx.id"}})
+	if err := c.Route("/api/v1/admin/jobs", anyOrigin); !errors.Is(err, ErrCredentialsWithAnyOrigin) {
+		t.Errorf("Route = %v, want ErrCredentialsWithAnyOrigin", err)
+	}
+}
+
+func TestRouteOverrides(t *testing.T) {
+	c := mustNew(t, Policy{AllowedOrigins: []string{"https://skillx.id", "https://admin.skillx.id"}})
+	if err := c.Route("/api/v1/admin/jobs", Policy{AllowedOrigins: []string{"https://admin.skillx.id"}, AllowCredentials: true}); err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+	if err := c.Route("/api/v1/products/{id}", Policy{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}}); err != nil {
+		t.Fatalf("Route: %v", err)
+	}
+
+	tests := []struct {
+		name            string
+		r               *http.Request
+		wantStatus      int
+		wantOrigin      string
+		wantCredentials string
+	}{
+		{
+			name:            "override allows its origin",
+			r:               request(http.MethodGet, "/api/v1/admin/jobs", "https://admin.skillx.id"),
+			wantStatus:      http.StatusOK,
+			wantOrigin:      "https://admin.skillx.id",
+			wantCredentials: "true",
+		},
+		{
+			name:       "override drops an origin of the default policy",
+			r:          request(http.MethodGet, "/api/v1/admin/jobs", "https://skillx.id"),
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "override opens a route to every origin",
+			r:          request(http.MethodGet, "/api/v1/products/1", "https://partner.example.com"),
+			wantStatus: http.StatusOK,
+			wantOrigin: "*",
+		},
+		{
+			name:       "preflight uses the override of the requested method's route",
+			r:          preflight("/api/v1/products/1", "https://skillx.id", http.MethodPut, ""),
+			wantStatus: http.StatusForbidden,
+		},
+		{
+			name:       "unmatched routes use the default policy",
+			r:          request(http.MethodGet, "/api/v1/unknown", "https://skillx.id"),
+			wantStatus: http.StatusNotFound,
+			wantOrigin: "https://skillx.id",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(t, c, tt.r)
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Origin"); got != tt.wantOrigin {
+				t.Errorf("Access-Control-Allow-Origin = %q, want %q", got, tt.wantOrigin)
+			}
+			if got := w.Header().Get("Access-Control-Allow-Credentials"); got != tt.wantCredentials {
+				t.Errorf("Access-Control-Allow-Credentials = %q, want %q", got, tt.wantCredentials)
+			}
+		})
+	}
+}
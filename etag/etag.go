@@ -0,0 +1,170 @@
+// Package etag implements optimistic concurrency for Realtime Database nodes.
+// A node's ETag is derived from its stored value, so every client that read
+// the same value sees the same tag. Updates that carry If-Match are applied
+// in a database transaction and rejected with 412 when the node has changed
+// since the client read it.
+package etag
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"golang-firebase-backend/apierror"
+
+	"firebase.google.com/go/db"
+)
+
+// Of returns the ETag of a stored JSON value. Object keys are sorted and
+// nulls and empty containers, which the database does not store, are dropped
+// first so that equal values always get equal tags.
+func Of(raw []byte) (string, error) {
+	decoder := json.NewDecoder(bytes.NewReader(raw))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return "", err
+	}
+	canonical, err := json.Marshal(prune(value))
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`, nil
+}
+
+// Get reads the node at ref into v and returns its ETag
+func Get(ctx context.Context, ref *db.Ref, v interface{}) (string, error) {
+	var raw json.RawMessage
+	if err := ref.Get(ctx, &raw); err != nil {
+		return "", err
+	}
+	if err := json.Unmarshal(raw, v); err != nil {
+		return "", err
+	}
+	return Of(raw)
+}
+
+// Set adds the ETag header to the response
+func Set(w http.ResponseWriter, tag string) {
+	if tag != "" {
+		w.Header().Set("ETag", tag)
+	}
+}
+
+// Matches reports whether the If-Match header of the request accepts tag.
+// A request without If-Match matches anything.
+func Matches(r *http.Request, tag string) bool {
+	header := strings.Join(r.Header.Values("If-Match"), ",")
+	if header == "" {
+		return true
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Update applies updates to the node at ref in a transaction and returns the
+// new ETag. Keys may be paths such as "major/id" and nil values delete the
+// field, as with db.Ref.Update. The update fails with a 412 *apierror.Error
+// when the request's If-Match does not match the stored value, and with a 404
+// when the node does not exist.
+func Update(ctx context.Context, r *http.Request, ref *db.Ref, updates map[string]interface{}) (string, error) {
+	var tag string
+	err := ref.Transaction(ctx, func(node db.TransactionNode) (interface{}, error) {
+		var raw json.RawMessage
+		if err := node.Unmarshal(&raw); err != nil {
+			return nil, err
+		}
+
+		current, err := Of(raw)
+		if err != nil {
+			return nil, err
+		}
+		var value map[string]interface{}
+		decoder := json.NewDecoder(bytes.NewReader(raw))
+		decoder.UseNumber()
+		if err := decoder.Decode(&value); err != nil || value == nil {
+			return nil, apierror.NotFound("Resource not found")
+		}
+		if !Matches(r, current) {
+			return nil, apierror.FromStatus(http.StatusPreconditionFailed, "Resource was modified by another request; fetch it again and retry")
+		}
+
+		for path, v := range updates {
+			apply(value, strings.Split(path, "/"), v)
+		}
+
+		updated, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		if tag, err = Of(updated); err != nil {
+			return nil, err
+		}
+		return value, nil
+	})
+
+	if err != nil {
+		return "", err
+	}
+	return tag, nil
+}
+
+// apply sets or, for a nil value, deletes the field at path
+func apply(node map[string]interface{}, path []string, value interface{}) {
+	key := path[0]
+	if len(path) == 1 {
+		if value == nil {
+			delete(node, key)
+		} else {
+			node[key] = value
+		}
+		return
+	}
+
+	child, ok := node[key].(map[string]interface{})
+	if !ok {
+		if value == nil {
+			return
+		}
+		child = map[string]interface{}{}
+		node[key] = child
+	}
+	apply(child, path[1:], value)
+	if len(child) == 0 {
+		delete(node, key)
+	}
+}
+
+func prune(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if child = prune(child); child == nil {
+				delete(v, key)
+			} else {
+				v[key] = child
+			}
+		}
+		if len(v) == 0 {
+			return nil
+		}
+	case []interface{}:
+		for i, child := range v {
+			v[i] = prune(child)
+		}
+		if len(v) == 0 {
+			return nil
+		}
+	}
+	return value
+}
@@ -0,0 +1,112 @@
+package etag
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOf(t *testing.T) {
+	tests := []struct {
+		name  string
+		a, b  string
+		equal bool
+	}{
+		{"same value", `{"title":"Desain"}`, `{"title":"Desain"}`, true},
+		{"key order", `{"title":"Desain","id_major":"m1"}`, `{"id_major":"m1","title":"Desain"}`, true},
+		{"whitespace", `{"title":"Desain"}`, "{\n  \"title\": \"Desain\"\n}", true},
+		{"nulls are not stored", `{"title":"Desain","photo_url":null}`, `{"title":"Desain"}`, true},
+		{"empty objects are not stored", `{"title":"Desain","tags":{}}`, `{"title":"Desain"}`, true},
+		{"empty arrays are not stored", `{"title":"Desain","tags":[]}`, `{"title":"Desain"}`, true},
+		{"nested key order", `{"a":{"x":1,"y":2}}`, `{"a":{"y":2,"x":1}}`, true},
+		{"large numbers keep their precision", `{"n":9007199254740993}`, `{"n":9007199254740992}`, false},
+		{"different value", `{"title":"Desain"}`, `{"title":"Musik"}`, false},
+		{"different type", `{"price":150000}`, `{"price":"150000"}`, false},
+		{"array order", `{"tags":["a","b"]}`, `{"tags":["b","a"]}`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, err := Of([]byte(tt.a))
+			if err != nil {
+				t.Fatalf("Of(%s): %v", tt.a, err)
+			}
+			b, err := Of([]byte(tt.b))
+			if err != nil {
+				t.Fatalf("Of(%s): %v", tt.b, err)
+			}
+			if (a == b) != tt.equal {
+				t.Errorf("Of(%s) = %s, Of(%s) = %s, want equal %t", tt.a, a, tt.b, b, tt.equal)
+			}
+			if !strings.HasPrefix(a, `"`) || !strings.HasSuffix(a, `"`) || len(a) != 34 {
+				t.Errorf("Of = %s, want a quoted 32 digit hex tag", a)
+			}
+		})
+	}
+}
+
+func TestOfRejectsInvalidJSON(t *testing.T) {
+	if _, err := Of([]byte(`{"title":`)); err == nil {
+		t.Error("Of accepted invalid JSON")
+	}
+}
+
+func TestMatches(t *testing.T) {
+	const tag = `"0123456789abcdef0123456789abcdef"`
+
+	tests := []struct {
+		name    string
+		ifMatch []string
+		want    bool
+	}{
+		{"no header", nil, true},
+		{"same tag", []string{tag}, true},
+		{"any", []string{"*"}, true},
+		{"one of a list", []string{`"other", ` + tag}, true},
+		{"one of repeated headers", []string{`"other"`, tag}, true},
+		{"other tag", []string{`"other"`}, false},
+		{"unquoted tag", []string{strings.Trim(tag, `"`)}, false},
+		{"weak tags never match", []string{"W/" + tag}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPut, "/", nil)
+			for _, value := range tt.ifMatch {
+				r.Header.Add("If-Match", value)
+			}
+			if got := Matches(r, tag); got != tt.want {
+				t.Errorf("Matches = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		name  string
+		path  string
+		value interface{}
+		want  map[string]interface{}
+	}{
+		{"set a field", "title", "Musik", map[string]interface{}{"title": "Musik", "major": map[string]interface{}{"id": "m1"}}},
+		{"delete a field", "title", nil, map[string]interface{}{"major": map[string]interface{}{"id": "m1"}}},
+		{"set a nested field", "major/id", "m2", map[string]interface{}{"title": "Desain", "major": map[string]interface{}{"id": "m2"}}},
+		{"create parents", "photo/url", "https://example.com/a.png", map[string]interface{}{
+			"title": "Desain",
+			"major": map[string]interface{}{"id": "m1"},
+			"photo": map[string]interface{}{"url": "https://example.com/a.png"},
+		}},
+		{"deleting the last child drops the parent", "major/id", nil, map[string]interface{}{"title": "Desain"}},
+		{"delete under a missing parent", "photo/url", nil, map[string]interface{}{"title": "Desain", "major": map[string]interface{}{"id": "m1"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := map[string]interface{}{"title": "Desain", "major": map[string]interface{}{"id": "m1"}}
+			apply(node, strings.Split(tt.path, "/"), tt.value)
+			if !reflect.DeepEqual(node, tt.want) {
+				t.Errorf("node = %v, want %v", node, tt.want)
+			}
+		})
+	}
+}
@@ -1,37 +1,45 @@
 package handlers
 
 import (
-	"context"
-	"encoding/json"
 	"net/http"
 
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/auth"
 	"golang-firebase-backend/config"
+	"golang-firebase-backend/models"
+	"golang-firebase-backend/utils"
 )
 
-// HandleGetAllSellers fetches all the registerSeller data
+// HandleGetAllSellers fetches all the registerSeller data. Pending requests
+// without a verified campus email are left out of the queue, and ID cards
+// are only served by HandleAdminGetIDCard.
 func HandleGetAllSellers(w http.ResponseWriter, r *http.Request) {
 	// Initialize Firebase database client
-	client, err := config.FirebaseApp.Database(context.Background())
+	client, err := config.Database(r.Context())
 	if err != nil {
-		http.Error(w, `{"error": "Failed to connect to Firebase"}`, http.StatusInternalServerError)
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase")
 		return
 	}
 
 	// Fetch all registerSeller data
 	sellerRef := client.NewRef("registerSellers")
-	var sellers map[string]map[string]interface{}
-	if err := sellerRef.Get(context.Background(), &sellers); err != nil {
-		http.Error(w, `{"error": "Failed to fetch sellers"}`, http.StatusInternalServerError)
+	var sellers map[string]models.RegisterSeller
+	if err := sellerRef.Get(r.Context(), &sellers); err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch sellers")
 		return
 	}
 
 	// Prepare the response data (convert the map into a slice)
-	var sellerList []map[string]interface{}
-	for _, seller := range sellers {
-		sellerList = append(sellerList, seller)
+	var sellerList []models.SellerProfile
+	for uid, seller := range sellers {
+		// Pengajuan tanpa email kampus terverifikasi tidak masuk antrean
+		if !seller.EmailVerified && seller.Status == "pending" {
+			continue
+		}
+		seller.UID = uid
+		sellerList = append(sellerList, seller.Profile(auth.View(r.Context(), uid)))
 	}
 
 	// Send JSON response
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(sellerList)
+	utils.RespondJSON(w, http.StatusOK, sellerList)
 }
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"golang-firebase-backend/alumni"
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/auth"
+	"golang-firebase-backend/models"
+	"golang-firebase-backend/utils"
+	"golang-firebase-backend/validate"
+)
+
+// HandleConfirmAlumni - POST /api/v1/seller-requests/me/alumni
+func HandleConfirmAlumni(w http.ResponseWriter, r *http.Request) {
+	uid, ok := auth.UID(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
+		return
+	}
+
+	var request models.AlumniConfirmationInput
+	if err := validate.Decode(w, r, &request); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+	// Yang masih kuliah wajib mengisi perkiraan tanggal lulus yang baru
+	if request.Status == "studying" && request.GraduationYear == 0 {
+		apierror.Write(w, r, apierror.Validation("Graduation date is required while still studying").
+			WithField("graduation_year", "is required"))
+		return
+	}
+
+	seller, err := alumni.Confirm(r.Context(), uid, request)
+	switch {
+	case errors.Is(err, alumni.ErrNotFound):
+		apierror.Respond(w, r, http.StatusNotFound, "No seller request found for this user")
+		return
+	case errors.Is(err, alumni.ErrNotSeller):
+		apierror.Write(w, r, apierror.Conflict("Only accepted sellers can confirm their alumni status"))
+		return
+	case errors.Is(err, alumni.ErrAlreadyAlumni):
+		apierror.Write(w, r, apierror.New(http.StatusConflict, "already_alumni", "You are already confirmed as alumni"))
+		return
+	case errors.Is(err, alumni.ErrGraduationDate):
+		apierror.Write(w, r, apierror.Validation("Graduation date has already passed").
+			WithField("graduation_year", "must be a graduation date that has not passed"))
+		return
+	case err != nil:
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to save alumni status")
+		return
+	}
+
+	message := "Graduation date updated"
+	if seller.AlumniStatus == alumni.StatusAlumni {
+		message = "Alumni status confirmed"
+		if seller.ReverificationRequired {
+			message += "; your seller account will be verified again"
+		}
+	}
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"message":         message,
+		"register_seller": seller.Profile(models.ViewSelf),
+	})
+}
@@ -0,0 +1,128 @@
+package handlers
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/auth"
+	"golang-firebase-backend/campus"
+	"golang-firebase-backend/models"
+	"golang-firebase-backend/utils"
+	"golang-firebase-backend/validate"
+)
+
+// HandleCampusEmailStatus - GET /api/v1/seller-requests/me/email-verification
+func HandleCampusEmailStatus(w http.ResponseWriter, r *http.Request) {
+	uid, ok := auth.UID(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
+		return
+	}
+
+	verification, err := campus.Status(r.Context(), uid)
+	if err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch campus email verification")
+		return
+	}
+	if verification == nil {
+		apierror.Respond(w, r, http.StatusNotFound, "No campus email verification was started")
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    verification,
+	})
+}
+
+// HandleSendCampusCode - POST /api/v1/seller-requests/me/email-verification
+func HandleSendCampusCode(w http.ResponseWriter, r *http.Request) {
+	uid, ok := auth.UID(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
+		return
+	}
+
+	var request models.CampusEmailInput
+	if err := validate.Decode(w, r, &request); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	verification, err := campus.SendCode(r.Context(), uid, request.Email)
+	if err != nil {
+		writeCampusError(w, r, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    verification,
+		"message": "Verification code sent to " + verification.Email,
+	})
+}
+
+// HandleConfirmCampusCode - POST /api/v1/seller-requests/me/email-verification/confirm
+func HandleConfirmCampusCode(w http.ResponseWriter, r *http.Request) {
+	uid, ok := auth.UID(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
+		return
+	}
+
+	var request models.CampusCodeInput
+	if err := validate.Decode(w, r, &request); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	verification, err := campus.Confirm(r.Context(), uid, request.Code)
+	if err != nil {
+		writeCampusError(w, r, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    verification,
+		"message": "Campus email verified",
+	})
+}
+
+// writeCampusError answers with the API error of a campus verification error
+func writeCampusError(w http.ResponseWriter, r *http.Request, err error) {
+	var (
+		throttled *campus.ThrottledError
+		locked    *campus.LockedError
+	)
+	switch {
+	case errors.As(err, &throttled):
+		retryAfter := int(math.Ceil(throttled.RetryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		apierror.Write(w, r, apierror.New(http.StatusTooManyRequests, apierror.CodeRateLimited,
+			"A code was sent recently; retry in "+strconv.Itoa(retryAfter)+" seconds"))
+	case errors.As(err, &locked):
+		retryAfter := int(math.Ceil(locked.RetryAfter.Minutes()))
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(locked.RetryAfter.Seconds()))))
+		apierror.Write(w, r, apierror.New(http.StatusTooManyRequests, "too_many_attempts",
+			"Too many wrong codes today; retry in "+strconv.Itoa(retryAfter)+" minutes"))
+	case errors.Is(err, campus.ErrDomainNotAllowed):
+		apierror.Write(w, r, apierror.Validation("Email is not a campus address").
+			WithField("email", "must be an email address of a recognised campus"))
+	case errors.Is(err, campus.ErrEmailTaken):
+		apierror.Write(w, r, apierror.Conflict("This campus email is already verified by another account"))
+	case errors.Is(err, campus.ErrNoCode):
+		apierror.Respond(w, r, http.StatusNotFound, "No verification code was requested")
+	case errors.Is(err, campus.ErrCodeExpired):
+		apierror.Write(w, r, apierror.New(http.StatusGone, "code_expired", "Verification code has expired; request a new one"))
+	case errors.Is(err, campus.ErrTooManyAttempts):
+		apierror.Write(w, r, apierror.New(http.StatusTooManyRequests, "too_many_attempts", "Too many wrong codes; request a new one"))
+	case errors.Is(err, campus.ErrWrongCode):
+		apierror.Write(w, r, apierror.Validation("Verification code is wrong").WithField("code", "does not match the code that was sent"))
+	default:
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to verify campus email")
+	}
+}
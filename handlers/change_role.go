@@ -1,64 +1,62 @@
 package handlers
 
 import (
-	"context"
-	"encoding/json"
 	"net/http"
 
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/auth"
 	"golang-firebase-backend/config"
+	"golang-firebase-backend/models"
+	"golang-firebase-backend/utils"
+	"golang-firebase-backend/validate"
 )
 
 func HandleChangeRole(w http.ResponseWriter, r *http.Request) {
 	// Ambil UID dari context
-	uid := r.Context().Value("uid").(string)
-
-	// Decode body request
-	var request struct {
-		Role string `json:"role"` // "buyer" atau "seller"
-	}
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
+	uid, ok := auth.UID(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
 		return
 	}
 
-	// Validasi role
-	if request.Role != "buyer" && request.Role != "seller" {
-		http.Error(w, "Invalid role", http.StatusBadRequest)
+	// Decode body request
+	var request models.RoleChangeInput
+	if err := validate.Decode(w, r, &request); err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
 
 	// Inisialisasi Firebase database client
-	client, err := config.FirebaseApp.Database(context.Background())
+	client, err := config.Database(r.Context())
 	if err != nil {
-		http.Error(w, "Failed to connect to Firebase", http.StatusInternalServerError)
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase")
 		return
 	}
 
 	// Ambil data user
 	userRef := client.NewRef("users/" + uid)
 	var user map[string]interface{}
-	if err := userRef.Get(context.Background(), &user); err != nil {
-		http.Error(w, "User not found", http.StatusNotFound)
+	if err := userRef.Get(r.Context(), &user); err != nil {
+		apierror.Respond(w, r, http.StatusNotFound, "User not found")
 		return
 	}
 
 	// Jika role adalah "seller", pastikan user sudah verified
-	if request.Role == "seller" && (!user["verified"].(bool)) {
-		http.Error(w, "User is not verified to become a seller", http.StatusForbidden)
+	if verified, _ := user["verified"].(bool); request.Role == "seller" && !verified {
+		apierror.Write(w, r, apierror.New(http.StatusForbidden, "seller_not_verified", "User is not verified to become a seller"))
 		return
 	}
 
 	// Update role
-	if err := userRef.Update(context.Background(), map[string]interface{}{
+	if err := userRef.Update(r.Context(), map[string]interface{}{
 		"role": request.Role,
 	}); err != nil {
-		http.Error(w, "Failed to update role", http.StatusInternalServerError)
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to update role")
 		return
 	}
 
 	// Kirim respons sukses
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
 		"message": "Role updated successfully",
 		"role":    request.Role,
 	})
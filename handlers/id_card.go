@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/auth"
+	"golang-firebase-backend/idcards"
+	"golang-firebase-backend/utils"
+)
+
+// HandleUploadIDCard - PUT /api/v1/seller-requests/me/id-card
+// The body is the photo itself, not JSON.
+func HandleUploadIDCard(w http.ResponseWriter, r *http.Request) {
+	uid, ok := auth.UID(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
+		return
+	}
+	if r.ContentLength > idcards.MaxSize {
+		apierror.Write(w, r, apierror.FromStatus(http.StatusRequestEntityTooLarge, idcards.ErrTooLarge.Error()))
+		return
+	}
+
+	card, err := idcards.Upload(r.Context(), uid, r.Body)
+	if err != nil {
+		writeIDCardError(w, r, err)
+		return
+	}
+
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    card,
+		"message": "Student ID card uploaded",
+	})
+}
+
+// HandleGetIDCard - GET /api/v1/seller-requests/me/id-card
+func HandleGetIDCard(w http.ResponseWriter, r *http.Request) {
+	uid, ok := auth.UID(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
+		return
+	}
+	respondIDCard(w, r, uid)
+}
+
+// HandleAdminGetIDCard - GET /api/v1/admin/seller-requests/{uid}/id-card
+func HandleAdminGetIDCard(w http.ResponseWriter, r *http.Request) {
+	// Kartu identitas hanya boleh dilihat pemiliknya dan admin
+	principal, ok := auth.FromContext(r.Context())
+	if !ok || !principal.IsAdmin() {
+		apierror.Write(w, r, apierror.Forbidden("Only admins can view the ID cards of applicants"))
+		return
+	}
+	respondIDCard(w, r, r.PathValue("uid"))
+}
+
+func respondIDCard(w http.ResponseWriter, r *http.Request, uid string) {
+	card, err := idcards.Get(r.Context(), uid)
+	if err != nil {
+		writeIDCardError(w, r, err)
+		return
+	}
+
+	// The signed URL must not outlive its expiry in a cache
+	w.Header().Set("Cache-Control", "private, no-store")
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
+		"success": true,
+		"data":    card,
+	})
+}
+
+// writeIDCardError answers with the API error of an ID card error
+func writeIDCardError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, idcards.ErrTooLarge):
+		apierror.Write(w, r, apierror.FromStatus(http.StatusRequestEntityTooLarge, err.Error()))
+	case errors.Is(err, idcards.ErrUnsupportedType):
+		apierror.Write(w, r, apierror.FromStatus(http.StatusUnsupportedMediaType, err.Error()))
+	case errors.Is(err, idcards.ErrNotFound):
+		apierror.Respond(w, r, http.StatusNotFound, "No student ID card was uploaded")
+	case errors.Is(err, idcards.ErrDeleted):
+		apierror.Write(w, r, apierror.New(http.StatusGone, "id_card_deleted", "The student ID card was deleted after the application was decided"))
+	default:
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to access student ID card")
+	}
+}
@@ -1,26 +1,35 @@
 package handlers
 
 import (
-	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strings"
 	"time"
 
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/auth"
+	"golang-firebase-backend/campus"
 	"golang-firebase-backend/config"
+	"golang-firebase-backend/idcards"
+	"golang-firebase-backend/models"
+	"golang-firebase-backend/organizations"
+	"golang-firebase-backend/utils"
+	"golang-firebase-backend/validate"
 )
 
 func GetRegisterSellerStatus(w http.ResponseWriter, r *http.Request) {
 	// Extract UID from context
-	uid, ok := r.Context().Value("uid").(string)
+	uid, ok := auth.UID(r.Context())
 	if !ok || uid == "" {
-		http.Error(w, "Unauthorized: UID not found", http.StatusUnauthorized)
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized: UID not found")
 		return
 	}
 
 	// Initialize Firebase Database client
-	client, err := config.FirebaseApp.Database(context.Background())
+	client, err := config.Database(r.Context())
 	if err != nil {
-		http.Error(w, "Failed to connect to Firebase", http.StatusInternalServerError)
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase")
 		return
 	}
 
@@ -29,62 +38,106 @@ func GetRegisterSellerStatus(w http.ResponseWriter, r *http.Request) {
 
 	// Fetch the data
 	var registerSellerData map[string]interface{}
-	if err := ref.Get(context.Background(), &registerSellerData); err != nil {
-		http.Error(w, "Failed to fetch registerSeller data", http.StatusInternalServerError)
+	if err := ref.Get(r.Context(), &registerSellerData); err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch registerSeller data")
 		return
 	}
 
 	// Check if data exists
 	if registerSellerData == nil {
-		http.Error(w, "No registerSeller data found for this user", http.StatusNotFound)
+		apierror.Respond(w, r, http.StatusNotFound, "No registerSeller data found for this user")
 		return
 	}
 
 	// Extract status
 	status, ok := registerSellerData["status"].(string)
 	if !ok {
-		http.Error(w, "Invalid data format: 'status' not found", http.StatusInternalServerError)
+		apierror.Respond(w, r, http.StatusInternalServerError, "Invalid data format: 'status' not found")
 		return
 	}
 
-	// Return the status
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]string{
+	// Return the status, with the alumni status once the graduation date has passed
+	response := map[string]string{
 		"status": status,
-	})
+	}
+	if alumniStatus, _ := registerSellerData["alumni_status"].(string); alumniStatus != "" {
+		response["alumni_status"] = alumniStatus
+	}
+	utils.RespondJSON(w, http.StatusOK, response)
 }
 
 func HandleRequestSeller(w http.ResponseWriter, r *http.Request) {
 	// Ambil UID dari context
-	uid := r.Context().Value("uid").(string)
+	uid, ok := auth.UID(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
+		return
+	}
 
 	// Decode body request
-	var request struct {
-		Name            string `json:"name"`
-		Email           string `json:"email"`
-		Organization    string `json:"organization"`
-		Major           string `json:"major"`
-		PhotoURL        string `json:"photo_url"`
-		GraduationMonth string `json:"graduation_month,omitempty"`
-		GraduationYear  int    `json:"graduation_year,omitempty"`
-	}
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
+	var request models.SellerRequestInput
+	if err := validate.Decode(w, r, &request); err != nil {
+		apierror.Write(w, r, err)
+		return
+	}
+
+	// Hanya email kampus yang sudah diverifikasi yang masuk antrean admin
+	verification, err := campus.Verified(r.Context(), uid)
+	if errors.Is(err, campus.ErrNotVerified) {
+		apierror.Write(w, r, apierror.New(http.StatusForbidden, "campus_email_unverified", "Verify your campus email before applying"))
+		return
+	}
+	if err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch campus email verification")
+		return
+	}
+	if request.Email != "" && !strings.EqualFold(request.Email, verification.Email) {
+		apierror.Write(w, r, apierror.Validation("Email does not match the verified campus email").
+			WithField("email", "must be the verified campus email "+verification.Email))
+		return
+	}
+	organization, organizationID := request.Organization, verification.OrganizationID
+	if verification.Organization != "" {
+		organization = verification.Organization
+	}
+	if organizationID == "" {
+		// Nama kampus yang sama dengan nama, singkatan atau alias di registri
+		// organisasi langsung dipetakan
+		org, ok, err := organizations.Resolve(r.Context(), organization)
+		if err != nil {
+			apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch organizations")
+			return
+		}
+		if ok {
+			organization, organizationID = org.Name, org.ID
+		}
+	}
+
+	// Foto kartu mahasiswa diunggah terlebih dahulu ke penyimpanan privat
+	uploaded, err := idcards.Uploaded(r.Context(), uid)
+	if err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to fetch student ID card")
+		return
+	}
+	if !uploaded {
+		apierror.Write(w, r, apierror.Validation("Student ID card is required").
+			WithField("id_card", "upload the student ID card to /api/v1/seller-requests/me/id-card first"))
 		return
 	}
 
 	// Inisialisasi Firebase database client
-	client, err := config.FirebaseApp.Database(context.Background())
+	client, err := config.Database(r.Context())
 	if err != nil {
-		http.Error(w, "Failed to connect to Firebase", http.StatusInternalServerError)
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase")
 		return
 	}
 
-	// Cek apakah user sudah memiliki pengajuan
+	// Cek apakah user sudah memiliki pengajuan. Pengajuan lama yang belum
+	// punya email kampus terverifikasi boleh diajukan ulang.
 	ref := client.NewRef("registerSellers/" + uid)
 	var existing map[string]interface{}
-	if err := ref.Get(context.Background(), &existing); err == nil && existing != nil {
-		http.Error(w, "User has already submitted a request", http.StatusBadRequest)
+	if err := ref.Get(r.Context(), &existing); err == nil && existing != nil && !resubmittable(existing) {
+		apierror.Write(w, r, apierror.New(http.StatusBadRequest, "seller_request_exists", "User has already submitted a request"))
 		return
 	}
 
@@ -92,10 +145,11 @@ func HandleRequestSeller(w http.ResponseWriter, r *http.Request) {
 	newRequest := map[string]interface{}{
 		"uid":              uid,
 		"name":             request.Name,
-		"email":            request.Email,
-		"organization":     request.Organization,
+		"email":            verification.Email,
+		"email_verified":   true,
+		"organization":     organization,
+		"organization_id":  organizationID,
 		"major":            request.Major,
-		"photo_url":        request.PhotoURL,
 		"status":           "pending",
 		"verified":         false,
 		"graduation_month": request.GraduationMonth,
@@ -105,15 +159,31 @@ func HandleRequestSeller(w http.ResponseWriter, r *http.Request) {
 	}
 
 	// Simpan data ke Firebase
-	if err := ref.Set(context.Background(), newRequest); err != nil {
-		http.Error(w, "Failed to save request", http.StatusInternalServerError)
+	if err := ref.Set(r.Context(), newRequest); err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to save request")
 		return
 	}
 
 	// Kirim respons sukses
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
 		"message":         "Seller request submitted",
-		"register_seller": newRequest,
+		"register_seller": sellerOf(newRequest).Profile(models.ViewSelf),
 	})
 }
+
+// resubmittable reports whether a pending request was made before campus
+// emails were verified
+func resubmittable(existing map[string]interface{}) bool {
+	verified, _ := existing["email_verified"].(bool)
+	return !verified && existing["status"] == "pending"
+}
+
+// sellerOf decodes a seller request held as a map. Fields of the wrong type
+// are left empty.
+func sellerOf(m map[string]interface{}) models.RegisterSeller {
+	var seller models.RegisterSeller
+	if data, err := json.Marshal(m); err == nil {
+		json.Unmarshal(data, &seller)
+	}
+	return seller
+}
@@ -1,89 +1,75 @@
 package handlers
 
 import (
-	"context"
-	"encoding/json"
 	"net/http"
 
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/auth"
 	"golang-firebase-backend/config"
+	"golang-firebase-backend/models"
+	"golang-firebase-backend/utils"
 )
 
 // HandleGetUserAndSellerData fetches user and register seller data
 func HandleGetUserAndSellerData(w http.ResponseWriter, r *http.Request) {
 	// Get UID from context
-	uid := r.Context().Value("uid").(string)
-
-	// Initialize Firebase database client
-	client, err := config.FirebaseApp.Database(context.Background())
-	if err != nil {
-		http.Error(w, `{"error": "Failed to connect to Firebase"}`, http.StatusInternalServerError)
-		return
-	}
-
-	// Fetch user data
-	userRef := client.NewRef("users/" + uid)
-	var user map[string]interface{}
-	if err := userRef.Get(context.Background(), &user); err != nil {
-		http.Error(w, `{"error": "User not found"}`, http.StatusNotFound)
+	uid, ok := auth.UID(r.Context())
+	if !ok {
+		apierror.Respond(w, r, http.StatusUnauthorized, "Unauthorized access")
 		return
 	}
-
-	// Fetch registerSeller data
-	sellerRef := client.NewRef("registerSellers/" + uid)
-	var registerSeller map[string]interface{}
-	if err := sellerRef.Get(context.Background(), &registerSeller); err != nil {
-		registerSeller = nil // Handle case where no seller data exists
-	}
-
-	// Combine data
-	response := map[string]interface{}{
-		"user":           user,
-		"registerSeller": registerSeller,
-	}
-
-	// Send JSON response
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	respondUserAndSeller(w, r, uid)
 }
 
 // HandleGetUserAndSellerDataByQuery fetches user and register seller data by ID from query parameter
 func HandleGetUserAndSellerDataByQuery(w http.ResponseWriter, r *http.Request) {
-	// Extract ID from query parameter
-	id := r.URL.Query().Get("id")
+	// Extract ID from path or query parameter
+	id := utils.Param(r, "id", "id")
 	if id == "" {
-		http.Error(w, `{"error": "ID is required"}`, http.StatusBadRequest)
+		apierror.Respond(w, r, http.StatusBadRequest, "ID is required")
 		return
 	}
+	respondUserAndSeller(w, r, id)
+}
 
+// respondUserAndSeller sends the profiles of a user and their seller request
+// that the caller may see
+func respondUserAndSeller(w http.ResponseWriter, r *http.Request, uid string) {
 	// Initialize Firebase database client
-	client, err := config.FirebaseApp.Database(context.Background())
+	client, err := config.Database(r.Context())
 	if err != nil {
-		http.Error(w, `{"error": "Failed to connect to Firebase"}`, http.StatusInternalServerError)
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase")
 		return
 	}
 
 	// Fetch user data
-	userRef := client.NewRef("users/" + id)
-	var user map[string]interface{}
-	if err := userRef.Get(context.Background(), &user); err != nil {
-		http.Error(w, `{"error": "User not found"}`, http.StatusNotFound)
+	var user *models.User
+	if err := client.NewRef("users/"+uid).Get(r.Context(), &user); err != nil {
+		apierror.Respond(w, r, http.StatusNotFound, "User not found")
 		return
 	}
 
 	// Fetch registerSeller data
-	sellerRef := client.NewRef("registerSellers/" + id)
-	var registerSeller map[string]interface{}
-	if err := sellerRef.Get(context.Background(), &registerSeller); err != nil {
+	var registerSeller *models.RegisterSeller
+	if err := client.NewRef("registerSellers/"+uid).Get(r.Context(), &registerSeller); err != nil {
 		registerSeller = nil // Handle case where no seller data exists
 	}
 
-	// Combine data
+	// Combine data; nil when the user or request does not exist
+	view := auth.View(r.Context(), uid)
 	response := map[string]interface{}{
-		"user":           user,
-		"registerSeller": registerSeller,
+		"user":           nil,
+		"registerSeller": nil,
+	}
+	if user != nil {
+		user.UID = uid
+		response["user"] = user.Profile(view)
+	}
+	if registerSeller != nil {
+		registerSeller.UID = uid
+		response["registerSeller"] = registerSeller.Profile(view)
 	}
 
 	// Send JSON response
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(response)
+	utils.RespondJSON(w, http.StatusOK, response)
 }
@@ -1,78 +1,118 @@
 package handlers
 
 import (
-	"context"
-	"encoding/json"
 	"net/http"
 	"time"
 
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/auth"
 	"golang-firebase-backend/config"
+	"golang-firebase-backend/idcards"
+	"golang-firebase-backend/jobs"
+	"golang-firebase-backend/models"
+	"golang-firebase-backend/notify"
+	"golang-firebase-backend/utils"
+	"golang-firebase-backend/validate"
 )
 
 func HandleAdminVerifySeller(w http.ResponseWriter, r *http.Request) {
 	// Decode body request
-	var request struct {
-		UID    string `json:"uid"`
-		Status string `json:"status"` // "accepted" atau "denied"
-	}
-	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
-		http.Error(w, "Invalid input", http.StatusBadRequest)
+	var request models.SellerDecisionInput
+	if err := validate.Decode(w, r, &request); err != nil {
+		apierror.Write(w, r, err)
 		return
 	}
+	if uid := r.PathValue("uid"); uid != "" {
+		request.UID = uid
+	}
 
 	// Validasi input
 	if request.UID == "" {
-		http.Error(w, "UID is required", http.StatusBadRequest)
-		return
-	}
-	if request.Status != "accepted" && request.Status != "denied" {
-		http.Error(w, "Invalid status", http.StatusBadRequest)
+		apierror.Respond(w, r, http.StatusBadRequest, "UID is required")
 		return
 	}
 
 	// Inisialisasi Firebase database client
-	client, err := config.FirebaseApp.Database(context.Background())
+	client, err := config.Database(r.Context())
 	if err != nil {
-		http.Error(w, "Failed to connect to Firebase", http.StatusInternalServerError)
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to connect to Firebase")
 		return
 	}
 
 	// Ambil data pengajuan
 	ref := client.NewRef("registerSellers/" + request.UID)
 	var registerSeller map[string]interface{}
-	if err := ref.Get(context.Background(), &registerSeller); err != nil {
-		http.Error(w, "RegisterSeller not found", http.StatusNotFound)
+	if err := ref.Get(r.Context(), &registerSeller); err != nil || registerSeller == nil {
+		apierror.Respond(w, r, http.StatusNotFound, "RegisterSeller not found")
+		return
+	}
+
+	if verified, _ := registerSeller["email_verified"].(bool); !verified && request.Status == "accepted" {
+		apierror.Write(w, r, apierror.New(http.StatusConflict, "campus_email_unverified", "The applicant's campus email is not verified"))
 		return
 	}
 
 	// Update status dan waktu
+	updatedAt := time.Now().Format(time.RFC3339)
 	registerSeller["status"] = request.Status
-	registerSeller["updated_at"] = time.Now().Format(time.RFC3339)
+	registerSeller["updated_at"] = updatedAt
 	if request.Status == "accepted" {
 		registerSeller["verified"] = true
+		delete(registerSeller, "reverification_required")
 	}
 
-	// Simpan perubahan
-	if err := ref.Set(context.Background(), registerSeller); err != nil {
-		http.Error(w, "Failed to update register seller", http.StatusInternalServerError)
-		return
+	// Simpan perubahan dan notifikasi dalam satu update atomik
+	sellerPath := "registerSellers/" + request.UID
+	updates := map[string]interface{}{
+		sellerPath + "/status":     request.Status,
+		sellerPath + "/updated_at": updatedAt,
 	}
-
-	// Jika status "accepted", perbarui user menjadi verified
 	if request.Status == "accepted" {
-		userRef := client.NewRef("users/" + request.UID)
-		if err := userRef.Update(context.Background(), map[string]interface{}{
-			"verified": true,
-		}); err != nil {
-			http.Error(w, "Failed to update user status", http.StatusInternalServerError)
-			return
+		updates[sellerPath+"/verified"] = true
+		updates[sellerPath+"/reverification_required"] = nil
+		updates["users/"+request.UID+"/verified"] = true
+		if organizationID, _ := registerSeller["organization_id"].(string); organizationID != "" {
+			updates["users/"+request.UID+"/organization_id"] = organizationID
+			updates["users/"+request.UID+"/organization"] = registerSeller["organization"]
 		}
 	}
 
+	// Foto kartu mahasiswa dihapus otomatis setelah masa simpan
+	if err := idcards.ScheduleDeletion(r.Context(), updates, request.UID, time.Now()); err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to schedule deletion of the student ID card")
+		return
+	}
+
+	// Beri tahu pemohon sesuai preferensi notifikasinya
+	if err := notify.Stage(updates, request.UID, models.Notification{
+		Type:  notify.TypeSellerApplicationDecided,
+		Title: "SkillX seller application " + request.Status,
+		Body:  sellerDecisionMessage(request.Status),
+		Data:  map[string]string{"status": request.Status},
+	}); err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to queue notification")
+		return
+	}
+
+	if err := client.NewRef("").Update(r.Context(), updates); err != nil {
+		apierror.Respond(w, r, http.StatusInternalServerError, "Failed to update register seller")
+		return
+	}
+	jobs.Notify()
+
 	// Kirim respons sukses
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(map[string]interface{}{
+	seller := sellerOf(registerSeller)
+	seller.UID = request.UID
+	utils.RespondJSON(w, http.StatusOK, map[string]interface{}{
 		"message":         "Seller verification status updated",
-		"register_seller": registerSeller,
+		"register_seller": seller.Profile(auth.View(r.Context(), request.UID)),
 	})
 }
+
+// sellerDecisionMessage describes the admin's decision to the applicant
+func sellerDecisionMessage(status string) string {
+	if status == "accepted" {
+		return "Your request to become a seller on SkillX has been accepted. You can now switch to the seller role and start listing products."
+	}
+	return "Your request to become a seller on SkillX has been denied."
+}
@@ -0,0 +1,107 @@
+// Package health serves the liveness and readiness probes of the container
+// platform. /healthz answers as long as the process serves requests;
+// /readyz also runs the registered checks and fails while the server drains
+// for shutdown.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CheckTimeout bounds how long a single readiness check may take
+const CheckTimeout = 2 * time.Second
+
+// Check reports whether a dependency is usable
+type Check func(ctx context.Context) error
+
+var (
+	checksMu sync.RWMutex
+	checks   = map[string]Check{}
+	draining atomic.Bool
+)
+
+// Register adds a readiness check under name
+func Register(name string, check Check) {
+	checksMu.Lock()
+	defer checksMu.Unlock()
+	checks[name] = check
+}
+
+// Drain makes /readyz fail so that the platform stops routing new traffic
+// before the server shuts down
+func Drain() {
+	draining.Store(true)
+}
+
+// Live answers the liveness probe
+func Live(w http.ResponseWriter, r *http.Request) {
+	respond(w, http.StatusOK, map[string]interface{}{"status": "ok"})
+}
+
+// Ready answers the readiness probe with the result of every check
+func Ready(w http.ResponseWriter, r *http.Request) {
+	if draining.Load() {
+		respond(w, http.StatusServiceUnavailable, map[string]interface{}{"status": "draining"})
+		return
+	}
+
+	results := run(r.Context())
+	status, code := "ok", http.StatusOK
+	for _, result := range results {
+		if result != "ok" {
+			status, code = "unavailable", http.StatusServiceUnavailable
+		}
+	}
+	respond(w, code, map[string]interface{}{"status": status, "checks": results})
+}
+
+// run executes the checks concurrently and returns "ok" or the error of each
+func run(ctx context.Context) map[string]string {
+	checksMu.RLock()
+	names := make([]string, 0, len(checks))
+	for name := range checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	current := make([]Check, len(names))
+	for i, name := range names {
+		current[i] = checks[name]
+	}
+	checksMu.RUnlock()
+
+	ctx, cancel := context.WithTimeout(ctx, CheckTimeout)
+	defer cancel()
+
+	outcomes := make([]string, len(names))
+	var wg sync.WaitGroup
+	for i, check := range current {
+		wg.Add(1)
+		go func(i int, check Check) {
+			defer wg.Done()
+			outcomes[i] = "ok"
+			if err := check(ctx); err != nil {
+				outcomes[i] = err.Error()
+			}
+		}(i, check)
+	}
+	wg.Wait()
+
+	results := make(map[string]string, len(names))
+	for i, name := range names {
+		results[name] = outcomes[i]
+	}
+	return results
+}
+
+func respond(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,340 @@
+// Package idcards keeps the student ID card photos of seller applicants in a
+// private path of the storage bucket. Clients never get a permanent link: the
+// owner and admins are served URLs signed for a short time, seller profiles
+// leave the photo out, and photos are deleted a while after the application
+// was decided.
+//
+// Applications made before this package existed hold a public link to the
+// photo in photo_url. Such photos are copied into the private path the first
+// time they are needed. Applications with a verified campus email were made
+// after the cutover, so their photo_url is never followed.
+package idcards
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"github.com/google/uuid"
+
+	"golang-firebase-backend/config"
+	"golang-firebase-backend/logging"
+	"golang-firebase-backend/models"
+)
+
+// MaxSize is the largest photo that can be uploaded
+const MaxSize = 5 << 20
+
+var (
+	ErrNotFound        = errors.New("no student ID card was uploaded")
+	ErrDeleted         = errors.New("student ID card was deleted after the application was decided")
+	ErrTooLarge        = fmt.Errorf("student ID card must be at most %d MB", MaxSize>>20)
+	ErrUnsupportedType = errors.New("student ID card must be a JPEG, PNG or WebP image")
+)
+
+// objectPrefix is the private path of the bucket; storage rules must deny
+// clients access to it
+const objectPrefix = "private/id-cards/"
+
+// AllowedTypes lists the media types of photos that can be uploaded
+var AllowedTypes = []string{"image/jpeg", "image/png", "image/webp"}
+
+var (
+	settingsMu sync.RWMutex
+	settings   = config.Defaults(config.Development).IDCards
+	bucketName = config.Defaults(config.Development).Firebase.StorageBucket
+)
+
+// Setup replaces the URL lifetime and retention, and names the bucket that
+// photos linked by old applications must be in to be moved
+func Setup(cfg config.IDCardConfig, bucket string) {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	settings = cfg
+	bucketName = bucket
+}
+
+func current() (config.IDCardConfig, string) {
+	settingsMu.RLock()
+	defer settingsMu.RUnlock()
+	return settings, bucketName
+}
+
+func cardPath(uid string) string {
+	return "idCards/" + uid
+}
+
+// record is the stored state of the ID card of a user
+type record struct {
+	Path        string     `json:"path,omitempty"`
+	ContentType string     `json:"content_type,omitempty"`
+	Size        int64      `json:"size,omitempty"`
+	UploadedAt  time.Time  `json:"uploaded_at"`
+	DeleteAt    *time.Time `json:"delete_at,omitempty"`
+	DeletedAt   *time.Time `json:"deleted_at,omitempty"`
+}
+
+func bucket(ctx context.Context) (*storage.BucketHandle, error) {
+	client, err := config.Storage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	b, err := client.DefaultBucket()
+	if err != nil {
+		return nil, fmt.Errorf("error getting bucket: %v", err)
+	}
+	return b, nil
+}
+
+// Upload stores a new photo of the user's ID card, replacing the previous one
+func Upload(ctx context.Context, uid string, body io.Reader) (*models.IDCard, error) {
+	data, err := io.ReadAll(io.LimitReader(body, MaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading ID card: %v", err)
+	}
+	if len(data) > MaxSize {
+		return nil, ErrTooLarge
+	}
+	contentType := http.DetectContentType(data)
+	if !slices.Contains(AllowedTypes, contentType) {
+		return nil, ErrUnsupportedType
+	}
+
+	b, err := bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := load(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+
+	r := record{
+		Path:        objectPrefix + uid + "/" + uuid.New().String(),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+		UploadedAt:  time.Now(),
+	}
+	w := b.Object(r.Path).NewWriter(ctx)
+	w.ContentType = contentType
+	w.CacheControl = "private, no-store"
+	if _, err := w.Write(data); err != nil {
+		w.Close()
+		return nil, fmt.Errorf("error uploading ID card: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("error uploading ID card: %v", err)
+	}
+
+	if err := save(ctx, uid, r); err != nil {
+		b.Object(r.Path).Delete(ctx)
+		return nil, err
+	}
+	if previous != nil && previous.Path != "" {
+		if err := b.Object(previous.Path).Delete(ctx); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+			logging.From(ctx).Warn("failed to delete replaced ID card", "uid", uid, "error", err)
+		}
+	}
+	return sign(b, r)
+}
+
+// Get returns the ID card of the user with a freshly signed URL
+func Get(ctx context.Context, uid string) (*models.IDCard, error) {
+	b, err := bucket(ctx)
+	if err != nil {
+		return nil, err
+	}
+	r, err := find(ctx, b, uid)
+	if err != nil {
+		return nil, err
+	}
+	return sign(b, *r)
+}
+
+// Uploaded reports whether the user has an ID card that was not deleted
+func Uploaded(ctx context.Context, uid string) (bool, error) {
+	r, err := load(ctx, uid)
+	if err != nil {
+		return false, err
+	}
+	return r != nil && r.Path != "", nil
+}
+
+// find returns the stored ID card of the user, moving a photo linked by an
+// old application into the private path first
+func find(ctx context.Context, b *storage.BucketHandle, uid string) (*record, error) {
+	r, err := load(ctx, uid)
+	if err != nil {
+		return nil, err
+	}
+	if r == nil {
+		if r, err = migrate(ctx, b, uid); err != nil {
+			return nil, err
+		}
+	}
+	switch {
+	case r == nil:
+		return nil, ErrNotFound
+	case r.Path == "" && r.DeletedAt != nil:
+		return nil, ErrDeleted
+	case r.Path == "":
+		return nil, ErrNotFound
+	}
+	return r, nil
+}
+
+func sign(b *storage.BucketHandle, r record) (*models.IDCard, error) {
+	cfg, _ := current()
+	expires := time.Now().Add(cfg.URLTTL)
+	signed, err := b.SignedURL(r.Path, &storage.SignedURLOptions{
+		Method:  http.MethodGet,
+		Expires: expires,
+		Scheme:  storage.SigningSchemeV4,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error signing ID card URL: %v", err)
+	}
+	return &models.IDCard{
+		ContentType:  r.ContentType,
+		Size:         r.Size,
+		UploadedAt:   r.UploadedAt,
+		DeleteAt:     r.DeleteAt,
+		URL:          signed,
+		URLExpiresAt: expires,
+	}, nil
+}
+
+func load(ctx context.Context, uid string) (*record, error) {
+	client, err := config.Database(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var r record
+	if err := client.NewRef(cardPath(uid)).Get(ctx, &r); err != nil {
+		return nil, fmt.Errorf("error fetching ID card: %v", err)
+	}
+	if r.UploadedAt.IsZero() {
+		return nil, nil
+	}
+	return &r, nil
+}
+
+func save(ctx context.Context, uid string, r record) error {
+	client, err := config.Database(ctx)
+	if err != nil {
+		return err
+	}
+	if err := client.NewRef(cardPath(uid)).Set(ctx, &r); err != nil {
+		return fmt.Errorf("error saving ID card: %v", err)
+	}
+	return nil
+}
+
+// legacyApplication holds the fields of a seller request migrate looks at
+type legacyApplication struct {
+	PhotoURL      string `json:"photo_url"`
+	EmailVerified bool   `json:"email_verified"`
+}
+
+// migrate copies the photo an old application links to in photo_url into the
+// private path and removes the link. The public original is deleted only when
+// it lies under a folder named after the applicant, as the old clients
+// uploaded it; a link to another object is copied but never deleted. It
+// returns nil when there is no such photo in the bucket.
+func migrate(ctx context.Context, b *storage.BucketHandle, uid string) (*record, error) {
+	client, err := config.Database(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var application legacyApplication
+	if err := client.NewRef("registerSellers/"+uid).Get(ctx, &application); err != nil {
+		return nil, fmt.Errorf("error fetching seller request: %v", err)
+	}
+	if application.EmailVerified {
+		return nil, nil
+	}
+	_, name := current()
+	object, ok := legacyObject(application.PhotoURL, name)
+	if !ok {
+		return nil, nil
+	}
+
+	r := record{Path: objectPrefix + uid + "/" + uuid.New().String(), UploadedAt: time.Now()}
+	copier := b.Object(r.Path).CopierFrom(b.Object(object))
+	copier.CacheControl = "private, no-store"
+	attrs, err := copier.Run(ctx)
+	if errors.Is(err, storage.ErrObjectNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error moving ID card: %v", err)
+	}
+	r.ContentType = attrs.ContentType
+	r.Size = attrs.Size
+
+	err = client.NewRef("").Update(ctx, map[string]interface{}{
+		cardPath(uid):                           r,
+		"registerSellers/" + uid + "/photo_url": nil,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error saving ID card: %v", err)
+	}
+	if !owns(uid, object) {
+		logging.From(ctx).Warn("kept public ID card outside the folder of the applicant", "uid", uid, "object", object)
+		return &r, nil
+	}
+	if err := b.Object(object).Delete(ctx); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		logging.From(ctx).Warn("failed to delete public ID card", "uid", uid, "object", object, "error", err)
+	}
+	return &r, nil
+}
+
+// owns reports whether object lies under a folder named uid
+func owns(uid, object string) bool {
+	folders := strings.Split(object, "/")
+	return uid != "" && slices.Contains(folders[:len(folders)-1], uid)
+}
+
+// legacyObject returns the object of the bucket a Firebase download URL or
+// public storage URL points to. Objects in the private path are refused, so
+// that a link cannot be used to reach the ID card of another user.
+func legacyObject(raw, bucketName string) (string, bool) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "https" || bucketName == "" {
+		return "", false
+	}
+
+	var object string
+	switch u.Host {
+	case "firebasestorage.googleapis.com":
+		escaped, ok := strings.CutPrefix(u.EscapedPath(), "/v0/b/"+bucketName+"/o/")
+		if !ok {
+			return "", false
+		}
+		if object, err = url.PathUnescape(escaped); err != nil {
+			return "", false
+		}
+	case "storage.googleapis.com":
+		var ok bool
+		if object, ok = strings.CutPrefix(u.Path, "/"+bucketName+"/"); !ok {
+			return "", false
+		}
+	default:
+		return "", false
+	}
+
+	if object == "" || strings.HasPrefix(object, "private/") || strings.Contains(object, "..") {
+		return "", false
+	}
+	return object, true
+}
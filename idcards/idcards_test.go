@@ -0,0 +1,66 @@
+package idcards
+
+import "testing"
+
+func TestLegacyObject(t *testing.T) {
+	const bucket = "skillx.appspot.com"
+
+	tests := []struct {
+		name   string
+		url    string
+		want   string
+		wantOK bool
+	}{
+		{
+			name:   "firebase download URL",
+			url:    "https://firebasestorage.googleapis.com/v0/b/skillx.appspot.com/o/id_cards%2Fu1%2Fcard.jpg?alt=media&token=abc",
+			want:   "id_cards/u1/card.jpg",
+			wantOK: true,
+		},
+		{
+			name:   "public storage URL",
+			url:    "https://storage.googleapis.com/skillx.appspot.com/id_cards/u1/card.jpg",
+			want:   "id_cards/u1/card.jpg",
+			wantOK: true,
+		},
+		{name: "other bucket", url: "https://storage.googleapis.com/other.appspot.com/id_cards/u1/card.jpg"},
+		{name: "other host", url: "https://example.com/skillx.appspot.com/id_cards/u1/card.jpg"},
+		{name: "plain http", url: "http://storage.googleapis.com/skillx.appspot.com/id_cards/u1/card.jpg"},
+		{name: "private path", url: "https://storage.googleapis.com/skillx.appspot.com/private/id-cards/u2/x"},
+		{name: "escaped private path", url: "https://firebasestorage.googleapis.com/v0/b/skillx.appspot.com/o/private%2Fid-cards%2Fu2%2Fx"},
+		{name: "dot segments", url: "https://firebasestorage.googleapis.com/v0/b/skillx.appspot.com/o/id_cards%2F..%2Fprivate%2Fx"},
+		{name: "bucket only", url: "https://storage.googleapis.com/skillx.appspot.com/"},
+		{name: "empty", url: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := legacyObject(tt.url, bucket)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("legacyObject(%q) = %q, %t, want %q, %t", tt.url, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestOwns(t *testing.T) {
+	tests := []struct {
+		uid    string
+		object string
+		want   bool
+	}{
+		{"u1", "id_cards/u1/card.jpg", true},
+		{"u1", "u1/card.jpg", true},
+		{"u1", "id_cards/u2/card.jpg", false},
+		{"u1", "id_cards/u10/card.jpg", false},
+		{"u1", "id_cards/u1", false},
+		{"u1", "products/u2/u1.jpg", false},
+		{"", "id_cards//card.jpg", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.object, func(t *testing.T) {
+			if got := owns(tt.uid, tt.object); got != tt.want {
+				t.Errorf("owns(%q, %q) = %t, want %t", tt.uid, tt.object, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,89 @@
+package idcards
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"cloud.google.com/go/storage"
+	"firebase.google.com/go/db"
+
+	"golang-firebase-backend/config"
+	"golang-firebase-backend/jobs"
+)
+
+const typeDelete = "idcards.delete"
+
+type deletePayload struct {
+	UID  string `json:"uid"`
+	Path string `json:"path"`
+}
+
+func init() {
+	jobs.Register(typeDelete, deleteCard)
+}
+
+// ScheduleDeletion adds the deletion of the user's ID card, once the
+// retention period after decidedAt has passed, to a multi-path update
+func ScheduleDeletion(ctx context.Context, updates map[string]interface{}, uid string, decidedAt time.Time) error {
+	b, err := bucket(ctx)
+	if err != nil {
+		return err
+	}
+	r, err := find(ctx, b, uid)
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrDeleted) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	cfg, _ := current()
+	deleteAt := decidedAt.Add(cfg.Retention)
+	updates[cardPath(uid)+"/delete_at"] = deleteAt
+	_, err = jobs.Stage(updates, typeDelete, deletePayload{UID: uid, Path: r.Path}, jobs.At(deleteAt))
+	return err
+}
+
+func deleteCard(ctx context.Context, payload json.RawMessage) error {
+	var p deletePayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("invalid ID card payload: %v", err)
+	}
+
+	b, err := bucket(ctx)
+	if err != nil {
+		return err
+	}
+	if err := b.Object(p.Path).Delete(ctx); err != nil && !errors.Is(err, storage.ErrObjectNotExist) {
+		return fmt.Errorf("error deleting ID card: %v", err)
+	}
+
+	client, err := config.Database(ctx)
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	err = client.NewRef(cardPath(p.UID)).Transaction(ctx, func(node db.TransactionNode) (interface{}, error) {
+		var r record
+		if err := node.Unmarshal(&r); err != nil {
+			return nil, err
+		}
+		if r.UploadedAt.IsZero() {
+			return nil, nil
+		}
+		if r.Path == p.Path {
+			// The record stays so that admins learn why there is no photo
+			r.Path = ""
+			r.DeleteAt = nil
+			r.DeletedAt = &now
+		}
+		return r, nil
+	})
+	if err != nil {
+		return fmt.Errorf("error updating ID card: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"golang-firebase-backend/utils"
+)
+
+// TypeSendEmail delivers a single email through SMTP
+const TypeSendEmail = "email.send"
+
+// EmailPayload is the payload of a TypeSendEmail job
+type EmailPayload struct {
+	To      string `json:"to"`
+	Subject string `json:"subject"`
+	Body    string `json:"body"`
+}
+
+func init() {
+	Register(TypeSendEmail, sendEmail)
+}
+
+func sendEmail(ctx context.Context, payload json.RawMessage) error {
+	var email EmailPayload
+	if err := json.Unmarshal(payload, &email); err != nil {
+		return fmt.Errorf("invalid email payload: %v", err)
+	}
+	if email.To == "" {
+		return fmt.Errorf("email has no recipient")
+	}
+
+	return utils.SendEmail(ctx, email.To, email.Subject, email.Body)
+}
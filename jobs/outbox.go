@@ -0,0 +1,181 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"golang-firebase-backend/config"
+	"golang-firebase-backend/models"
+
+	"github.com/google/uuid"
+)
+
+// Outbox nodes in the Realtime Database. Jobs waiting to run (or running)
+// live under pendingNode; jobs that ran out of attempts are moved to deadNode.
+const (
+	pendingNode = "jobs/pending"
+	deadNode    = "jobs/dead"
+)
+
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+	StatusDead    = "dead"
+)
+
+const defaultMaxAttempts = 5
+
+var ErrJobNotFound = errors.New("job not found")
+
+// Option customizes a job before it is written to the outbox
+type Option func(*models.Job)
+
+// Delay postpones the first run of the job by d
+func Delay(d time.Duration) Option {
+	return func(job *models.Job) {
+		job.RunAt = time.Now().Add(d)
+	}
+}
+
+// At schedules the first run of the job at t
+func At(t time.Time) Option {
+	return func(job *models.Job) {
+		job.RunAt = t
+	}
+}
+
+// MaxAttempts overrides how many times the job is tried before it is dead-lettered
+func MaxAttempts(n int) Option {
+	return func(job *models.Job) {
+		job.MaxAttempts = n
+	}
+}
+
+// NewJob builds a pending job without saving it
+func NewJob(jobType string, payload interface{}, opts ...Option) (*models.Job, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding payload for %s: %v", jobType, err)
+	}
+
+	now := time.Now()
+	job := &models.Job{
+		ID:          uuid.New().String(),
+		Type:        jobType,
+		Payload:     raw,
+		Status:      StatusPending,
+		MaxAttempts: defaultMaxAttempts,
+		RunAt:       now,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	for _, opt := range opts {
+		opt(job)
+	}
+
+	return job, nil
+}
+
+// Stage adds a new job to a multi-path update so that it is committed
+// atomically together with the state change that caused it
+func Stage(updates map[string]interface{}, jobType string, payload interface{}, opts ...Option) (*models.Job, error) {
+	job, err := NewJob(jobType, payload, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	updates[pendingNode+"/"+job.ID] = job
+	return job, nil
+}
+
+// Enqueue saves a new job to the outbox on its own
+func Enqueue(ctx context.Context, jobType string, payload interface{}, opts ...Option) (*models.Job, error) {
+	job, err := NewJob(jobType, payload, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := config.Database(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := client.NewRef(pendingNode+"/"+job.ID).Set(ctx, job); err != nil {
+		return nil, fmt.Errorf("error saving job %s: %v", job.ID, err)
+	}
+
+	Notify()
+	return job, nil
+}
+
+// List returns the jobs with the given status ordered by their next run time.
+// "pending" also includes jobs that are currently running.
+func List(ctx context.Context, status string) ([]models.Job, error) {
+	node := pendingNode
+	if status == StatusDead {
+		node = deadNode
+	}
+
+	client, err := config.Database(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs map[string]models.Job
+	if err := client.NewRef(node).Get(ctx, &jobs); err != nil {
+		return nil, fmt.Errorf("error fetching jobs: %v", err)
+	}
+
+	jobList := make([]models.Job, 0, len(jobs))
+	for id, job := range jobs {
+		job.ID = id
+		if status == StatusRunning && job.Status != StatusRunning {
+			continue
+		}
+		jobList = append(jobList, job)
+	}
+
+	sort.Slice(jobList, func(i, j int) bool {
+		return jobList[i].RunAt.Before(jobList[j].RunAt)
+	})
+
+	return jobList, nil
+}
+
+// Retry moves a dead job back to the outbox with a fresh set of attempts
+func Retry(ctx context.Context, id string) (*models.Job, error) {
+	client, err := config.Database(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var job models.Job
+	if err := client.NewRef(deadNode+"/"+id).Get(ctx, &job); err != nil {
+		return nil, fmt.Errorf("error fetching job %s: %v", id, err)
+	}
+	if job.Type == "" {
+		return nil, ErrJobNotFound
+	}
+
+	now := time.Now()
+	job.ID = id
+	job.Status = StatusPending
+	job.Attempts = 0
+	job.RunAt = now
+	job.LockedUntil = time.Time{}
+	job.UpdatedAt = now
+
+	if err := client.NewRef("").Update(ctx, map[string]interface{}{
+		deadNode + "/" + id:    nil,
+		pendingNode + "/" + id: job,
+	}); err != nil {
+		return nil, fmt.Errorf("error requeueing job %s: %v", id, err)
+	}
+
+	Notify()
+	return &job, nil
+}
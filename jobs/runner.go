@@ -0,0 +1,400 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"golang-firebase-backend/config"
+	"golang-firebase-backend/models"
+	"golang-firebase-backend/tracing"
+
+	"firebase.google.com/go/db"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Handler runs a single job. Returning an error schedules a retry.
+type Handler func(ctx context.Context, payload json.RawMessage) error
+
+// Options configures the background runner
+type Options struct {
+	Workers      int           // Maximum number of jobs running at once
+	PollInterval time.Duration // How often the outbox is scanned for due jobs
+	Lease        time.Duration // How long a claim lasts without renewal; running jobs renew it every third of it
+	BaseBackoff  time.Duration // Delay before the first retry, doubled for every attempt
+	MaxBackoff   time.Duration
+}
+
+func (o *Options) setDefaults() {
+	if o.Workers <= 0 {
+		o.Workers = 4
+	}
+	if o.PollInterval <= 0 {
+		o.PollInterval = 5 * time.Second
+	}
+	if o.Lease <= 0 {
+		o.Lease = 2 * time.Minute
+	}
+	if o.BaseBackoff <= 0 {
+		o.BaseBackoff = 30 * time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = time.Hour
+	}
+}
+
+var (
+	errNotClaimable = errors.New("job is not claimable")
+	errLeaseLost    = errors.New("lease was taken over by another worker")
+)
+
+var (
+	handlersMu sync.RWMutex
+	handlers   = map[string]Handler{}
+
+	wake = make(chan struct{}, 1)
+
+	runnerMu sync.Mutex
+	cancel   context.CancelFunc
+	done     chan struct{}
+)
+
+// Register associates a job type with the handler that runs it
+func Register(jobType string, handler Handler) {
+	handlersMu.Lock()
+	defer handlersMu.Unlock()
+	handlers[jobType] = handler
+}
+
+func handlerFor(jobType string) (Handler, bool) {
+	handlersMu.RLock()
+	defer handlersMu.RUnlock()
+	handler, ok := handlers[jobType]
+	return handler, ok
+}
+
+// Notify wakes the runner so that newly committed jobs are picked up
+// without waiting for the next poll
+func Notify() {
+	select {
+	case wake <- struct{}{}:
+	default:
+	}
+}
+
+// Start launches the dispatcher and worker goroutines
+func Start(opts Options) {
+	opts.setDefaults()
+
+	runnerMu.Lock()
+	defer runnerMu.Unlock()
+	if cancel != nil {
+		return
+	}
+
+	ctx, stop := context.WithCancel(context.Background())
+	cancel = stop
+	done = make(chan struct{})
+
+	queue := make(chan models.Job)
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				// Jobs already taken off the queue are finished even while stopping
+				process(context.WithoutCancel(ctx), job, opts)
+			}
+		}()
+	}
+
+	go func() {
+		defer close(done)
+		dispatch(ctx, queue, opts)
+		close(queue)
+		wg.Wait()
+	}()
+
+	log.Printf("Job runner started with %d workers", opts.Workers)
+}
+
+// Stop stops polling and waits for running jobs to finish or for ctx to expire
+func Stop(ctx context.Context) error {
+	runnerMu.Lock()
+	stop, finished := cancel, done
+	cancel, done = nil, nil
+	runnerMu.Unlock()
+
+	if stop == nil {
+		return nil
+	}
+
+	stop()
+	select {
+	case <-finished:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Running reports whether the runner has been started
+func Running() bool {
+	runnerMu.Lock()
+	defer runnerMu.Unlock()
+	return cancel != nil
+}
+
+func dispatch(ctx context.Context, queue chan<- models.Job, opts Options) {
+	ticker := time.NewTicker(opts.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		due, err := dueJobs(ctx)
+		if err != nil {
+			log.Printf("Failed to scan job outbox: %v", err)
+		}
+		for _, job := range due {
+			select {
+			case queue <- job:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-wake:
+		}
+	}
+}
+
+func dueJobs(ctx context.Context) ([]models.Job, error) {
+	client, err := config.Database(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var jobs map[string]models.Job
+	if err := client.NewRef(pendingNode).Get(ctx, &jobs); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	var due []models.Job
+	for id, job := range jobs {
+		job.ID = id
+		if claimable(job, now) {
+			due = append(due, job)
+		}
+	}
+
+	return due, nil
+}
+
+func claimable(job models.Job, now time.Time) bool {
+	switch job.Status {
+	case StatusPending:
+		return !job.RunAt.After(now)
+	case StatusRunning:
+		// The worker that held the lease died before finishing
+		return job.LockedUntil.Before(now)
+	}
+	return false
+}
+
+func process(ctx context.Context, job models.Job, opts Options) {
+	client, err := config.Database(ctx)
+	if err != nil {
+		log.Printf("Job %s: failed to connect to Firebase Database: %v", job.ID, err)
+		return
+	}
+
+	ref := client.NewRef(pendingNode + "/" + job.ID)
+	claimed, err := claim(ctx, ref, opts.Lease)
+	if err != nil {
+		if !errors.Is(err, errNotClaimable) {
+			log.Printf("Job %s: failed to claim: %v", job.ID, err)
+		}
+		return
+	}
+
+	runCtx, span := tracing.Start(ctx, "job "+claimed.Type, trace.WithAttributes(
+		attribute.String("job.id", claimed.ID),
+		attribute.Int("job.attempt", claimed.Attempts),
+	))
+	runCtx, stop := context.WithCancel(runCtx)
+	held := make(chan struct{})
+	go func() {
+		defer close(held)
+		hold(runCtx, ref, claimed.LockedBy, opts.Lease, stop)
+	}()
+	runErr := run(runCtx, claimed)
+	stop()
+	<-held
+	tracing.End(span, runErr)
+	if runErr == nil {
+		if err := release(ctx, ref, claimed.LockedBy, nil); err != nil {
+			log.Printf("Job %s: failed to remove finished job: %v", claimed.ID, err)
+		}
+		return
+	}
+
+	lease := claimed.LockedBy
+	now := time.Now()
+	claimed.LastError = runErr.Error()
+	claimed.LockedUntil = time.Time{}
+	claimed.LockedBy = ""
+	claimed.UpdatedAt = now
+
+	if claimed.Attempts >= claimed.MaxAttempts {
+		claimed.Status = StatusDead
+		log.Printf("Job %s (%s) dead-lettered after %d attempts: %v", claimed.ID, claimed.Type, claimed.Attempts, runErr)
+		// The dead copy is written first so that a crash in between leaves
+		// the job in both nodes rather than in neither
+		deadRef := client.NewRef(deadNode + "/" + claimed.ID)
+		if err := deadRef.Set(ctx, claimed); err != nil {
+			log.Printf("Job %s: failed to dead-letter: %v", claimed.ID, err)
+			return
+		}
+		if err := release(ctx, ref, lease, nil); err != nil {
+			log.Printf("Job %s: failed to dead-letter: %v", claimed.ID, err)
+			if errors.Is(err, errLeaseLost) {
+				deadRef.Delete(ctx)
+			}
+		}
+		return
+	}
+
+	claimed.Status = StatusPending
+	claimed.RunAt = now.Add(backoff(claimed.Attempts, opts))
+	log.Printf("Job %s (%s) failed on attempt %d, retrying at %s: %v", claimed.ID, claimed.Type, claimed.Attempts, claimed.RunAt.Format(time.RFC3339), runErr)
+	if err := release(ctx, ref, lease, &claimed); err != nil {
+		log.Printf("Job %s: failed to schedule retry: %v", claimed.ID, err)
+	}
+}
+
+// claim atomically marks the job as running so that no other worker or
+// replica picks it up while the lease is held
+func claim(ctx context.Context, ref *db.Ref, lease time.Duration) (models.Job, error) {
+	var claimed models.Job
+	err := ref.Transaction(ctx, func(node db.TransactionNode) (interface{}, error) {
+		var current models.Job
+		if err := node.Unmarshal(&current); err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		if current.Type == "" || !claimable(current, now) {
+			return nil, errNotClaimable
+		}
+
+		current.Status = StatusRunning
+		current.Attempts++
+		current.LockedUntil = now.Add(lease)
+		current.LockedBy = uuid.New().String()
+		current.UpdatedAt = now
+		claimed = current
+		return current, nil
+	})
+	claimed.ID = ref.Key
+
+	return claimed, err
+}
+
+// hold renews the lease until ctx is done, so that jobs running longer than
+// one lease are not taken over. When the lease is lost anyway, e.g. because
+// the database could not be reached in time, the job is cancelled through
+// stop; the worker that took it over runs it again.
+func hold(ctx context.Context, ref *db.Ref, lease string, length time.Duration, stop context.CancelFunc) {
+	ticker := time.NewTicker(length / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		err := renew(ctx, ref, lease, time.Now().Add(length))
+		if errors.Is(err, errLeaseLost) {
+			log.Printf("Job %s: %v, cancelling", ref.Key, err)
+			stop()
+			return
+		}
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Job %s: failed to renew lease: %v", ref.Key, err)
+		}
+	}
+}
+
+// renew extends the lease held by the claim identified by lease until until
+func renew(ctx context.Context, ref *db.Ref, lease string, until time.Time) error {
+	return ref.Transaction(ctx, func(node db.TransactionNode) (interface{}, error) {
+		var current models.Job
+		if err := node.Unmarshal(&current); err != nil {
+			return nil, err
+		}
+		if current.Status != StatusRunning || current.LockedBy != lease {
+			return nil, errLeaseLost
+		}
+		current.LockedUntil = until
+		return current, nil
+	})
+}
+
+// release replaces the job with next, or removes it when next is nil, as long
+// as the claim identified by lease still holds it. A worker whose lease
+// expired while it ran gets errLeaseLost and leaves the job to the worker
+// that took it over.
+func release(ctx context.Context, ref *db.Ref, lease string, next *models.Job) error {
+	return ref.Transaction(ctx, func(node db.TransactionNode) (interface{}, error) {
+		var current models.Job
+		if err := node.Unmarshal(&current); err != nil {
+			return nil, err
+		}
+		if current.Status != StatusRunning || current.LockedBy != lease {
+			return nil, errLeaseLost
+		}
+		if next == nil {
+			return nil, nil
+		}
+		return next, nil
+	})
+}
+
+func run(ctx context.Context, job models.Job) (err error) {
+	handler, ok := handlerFor(job.Type)
+	if !ok {
+		return fmt.Errorf("no handler registered for job type %q", job.Type)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("job panicked: %v", p)
+		}
+	}()
+
+	return handler(ctx, job.Payload)
+}
+
+func backoff(attempt int, opts Options) time.Duration {
+	delay := opts.BaseBackoff
+	for i := 1; i < attempt; i++ {
+		delay *= 2
+		if delay >= opts.MaxBackoff {
+			return opts.MaxBackoff
+		}
+	}
+	return delay
+}
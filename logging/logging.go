@@ -0,0 +1,98 @@
+// Package logging sets up structured logging with log/slog. Each request gets
+// a logger carrying its request ID, stored in the request context; handlers
+// log through From(r.Context()) so that every line of a request can be
+// correlated.
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+type (
+	loggerKey struct{}
+	fieldsKey struct{}
+)
+
+// Options select the minimum level (debug, info, warn or error; info by
+// default) and the format (text or json; text by default) of a logger
+type Options struct {
+	Level  string
+	Format string
+}
+
+// New returns a logger writing to w. Sensitive values are redacted, see
+// Redact.
+func New(w io.Writer, options Options) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: level(options.Level), ReplaceAttr: Redact}
+	if strings.EqualFold(options.Format, "json") {
+		return slog.New(slog.NewJSONHandler(w, opts))
+	}
+	return slog.New(slog.NewTextHandler(w, opts))
+}
+
+// Setup installs a logger writing to os.Stderr as the default logger. Output
+// of the log package is routed through it as well.
+func Setup(options Options) *slog.Logger {
+	logger := New(os.Stderr, options)
+	slog.SetDefault(logger)
+	return logger
+}
+
+func level(name string) slog.Level {
+	switch strings.ToLower(name) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	}
+	return slog.LevelInfo
+}
+
+// From returns the logger of the request, or the default logger outside of
+// a request
+func From(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return slog.Default()
+}
+
+// WithLogger stores logger in the context
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// With adds attributes, given as for slog.Logger.With, to the request's
+// logger and to its access log line
+func With(ctx context.Context, args ...any) context.Context {
+	if f, ok := ctx.Value(fieldsKey{}).(*fields); ok {
+		f.add(args)
+	}
+	return WithLogger(ctx, From(ctx).With(args...))
+}
+
+// fields collects attributes added while a request is handled, for the
+// access log line written when it finishes
+type fields struct {
+	mu   sync.Mutex
+	args []any
+}
+
+func (f *fields) add(args []any) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.args = append(f.args, args...)
+}
+
+func (f *fields) list() []any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]any(nil), f.args...)
+}
@@ -0,0 +1,71 @@
+package logging
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"golang-firebase-backend/response"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the ID that correlates a request with its logs
+const RequestIDHeader = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// validRequestID limits IDs accepted from clients, so that they cannot
+// inject arbitrary text into the logs
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID returns the ID of the request being handled, or ""
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// Middleware assigns every request an ID, taken from the X-Request-ID
+// header when the client sent a valid one, echoes it in the response, and
+// gives the handler a logger carrying it. When the request finishes, one
+// access log line reports its status, size and latency together with any
+// attributes added through With, such as the route and the uid.
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		logger := slog.Default().With("request_id", id)
+		f := &fields{}
+		ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+		ctx = context.WithValue(ctx, fieldsKey{}, f)
+		ctx = WithLogger(ctx, logger)
+
+		rw := response.Wrap(w)
+		next.ServeHTTP(rw, r.WithContext(ctx))
+
+		status := rw.Status()
+		level := slog.LevelInfo
+		switch {
+		case status >= 500:
+			level = slog.LevelError
+		case status >= 400:
+			level = slog.LevelWarn
+		}
+
+		logger.With(f.list()...).LogAttrs(ctx, level, "request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", status),
+			slog.Int("bytes", rw.Bytes()),
+			slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+		)
+	})
+}
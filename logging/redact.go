@@ -0,0 +1,89 @@
+package logging
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys are attribute keys whose values are never logged
+var sensitiveKeys = []string{
+	"authorization", "token", "password", "secret", "server_key", "client_key",
+	"api_key", "cookie", "email", "card", "cvv", "phone",
+}
+
+var (
+	bearerPattern = regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9._~+/=-]+`)
+	jwtPattern    = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9-]+(\.[A-Za-z0-9-]+)+`)
+	cardPattern   = regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`)
+)
+
+// Redact is a slog ReplaceAttr function that hides ID tokens, email
+// addresses and payment card numbers. Values of attributes with a sensitive
+// key are replaced entirely; other strings, including the message, are
+// scrubbed.
+func Redact(groups []string, a slog.Attr) slog.Attr {
+	if isSensitive(a.Key) && !a.Value.Equal(slog.StringValue("")) {
+		return slog.String(a.Key, redacted)
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Scrub(a.Value.String()))
+	case slog.KindAny:
+		// Structs and maps may hold anything, so they are logged as scrubbed text
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, Scrub(err.Error()))
+		}
+		return slog.String(a.Key, Scrub(fmt.Sprintf("%+v", a.Value.Any())))
+	}
+	return a
+}
+
+// Scrub replaces tokens, email addresses and card numbers in s
+func Scrub(s string) string {
+	s = bearerPattern.ReplaceAllString(s, "Bearer "+redacted)
+	s = jwtPattern.ReplaceAllString(s, redacted)
+	s = emailPattern.ReplaceAllString(s, "[EMAIL]")
+	return cardPattern.ReplaceAllStringFunc(s, func(match string) string {
+		if luhn(match) {
+			return "[CARD]"
+		}
+		return match
+	})
+}
+
+func isSensitive(key string) bool {
+	key = strings.ToLower(key)
+	for _, sensitive := range sensitiveKeys {
+		if strings.Contains(key, sensitive) {
+			return true
+		}
+	}
+	return false
+}
+
+// luhn reports whether the digits of s pass the Luhn checksum used by card
+// numbers, which keeps timestamps and IDs from being redacted
+func luhn(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			if d *= 2; d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -1,138 +1,258 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"golang-firebase-backend/alumni"
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/auth"
+	"golang-firebase-backend/campus"
 	"golang-firebase-backend/config"
 	"golang-firebase-backend/controllers"
-	"golang-firebase-backend/handlers"
-	"golang-firebase-backend/middleware"
-	"log"
+	"golang-firebase-backend/cors"
+	"golang-firebase-backend/health"
+	"golang-firebase-backend/idcards"
+	"golang-firebase-backend/jobs"
+	"golang-firebase-backend/logging"
+	"golang-firebase-backend/metrics"
+	"golang-firebase-backend/notify"
+	"golang-firebase-backend/ratelimit"
+	"golang-firebase-backend/routes"
+	"golang-firebase-backend/sessions"
+	"golang-firebase-backend/tracing"
+	"golang-firebase-backend/utils"
+	"log/slog"
 	"net/http"
-	"os" // Import the gorilla mux package
-
-	"github.com/joho/godotenv"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
 )
 
 func main() {
-	// Load environment variables
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found")
-	}
-
-	// Initialize Firebase
-	config.InitializeFirebaseApp()
-	config.LoadMidtransConfig() // Pastikan ini dipanggil!
-
-	// CORS middleware
-	corsMiddleware := func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			// Set CORS headers
-			w.Header().Set("Access-Control-Allow-Origin", "http://localhost:8100") // Replace with your frontend's origin
-			w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-			w.Header().Set("Access-Control-Allow-Headers", "Origin, Content-Type, X-Auth-Token, Authorization")
-
-			// Handle preflight (OPTIONS) requests
-			if r.Method == http.MethodOptions {
-				w.WriteHeader(http.StatusOK)
-				return
-			}
-
-			// Call the next handler
-			next.ServeHTTP(w, r)
-		})
-	}
-
-	// Create a new ServeMux
-	mux := http.NewServeMux()
-
-	// Define routes
-	mux.HandleFunc("/login/google", controllers.LoginWithGoogle)
-	mux.HandleFunc("/logout", controllers.Logout)
-
-	//user update
-	mux.Handle("/user/update", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.UpdateUser)))
-	mux.Handle("/user", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.FetchUserByUID)))
-	mux.Handle("/user-search", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.SearchUsersByName)))
-
-	mux.Handle("/update-aboutme", middleware.FirebaseAuthMiddleware(http.HandlerFunc(handlers.HandleUpdateAboutMe)))
-	mux.Handle("/seller-byid", middleware.FirebaseAuthMiddleware(http.HandlerFunc(handlers.HandleGetUserAndSellerDataByQuery)))
-
-	// message route
-	mux.Handle("/searchAll", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.SearchController)))
-
-	mux.Handle("/messages", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.FetchMessages)))           // Fetch all messages
-	mux.Handle("/messages-send", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.SendMessage)))        // Fetch all messages
-	mux.Handle("/conversations", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.FetchConversations))) // Fetch all conversations
-	mux.Handle("/new-chatroom", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.CreateChatRoom)))
-
-	//skill route
-	mux.HandleFunc("/skills/fetch", controllers.FetchSkills)
-	mux.HandleFunc("/skills/view", controllers.ShowSkill)
-	//skillroute for admin
-	mux.HandleFunc("/skills/admincreate", controllers.CreateSkill)
-	mux.HandleFunc("/skills/adminupdate", controllers.UpdateSkill)
-	mux.HandleFunc("/skills/admindelete", controllers.DeleteSkill)
-	//userskill routes
-	mux.Handle("/skills/add", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.AddUserSkill)))
-	mux.Handle("/user/portfolios/view", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.ViewUserPortfolios)))
-	mux.Handle("/user/portfolios/view-specific", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.ViewSpecificUserPortfolios)))
-	mux.Handle("/user/portfolios/create", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.CreatePortfolio)))
-	mux.Handle("/user/portfolios/update", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.UpdatePortfolio)))
-	mux.Handle("/user/portfolios/delete", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.DeletePortfolio)))
-	mux.Handle("/user/portfolios/view-uid", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.ViewPortfoliosByUID)))
-
-	// Major routes
-	mux.Handle("/majors", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.FetchMajors)))             // Fetch all majors
-	mux.Handle("/majors/admincreate", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.CreateMajor))) // Create a new major
-	mux.Handle("/majors/adminshow", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.ShowMajor)))     // Update a major
-	mux.Handle("/majors/admindelete", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.DeleteMajor))) // Delete a major
-	mux.Handle("/majors/adminupdate", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.UpdateMajor))) // Update a major
-
-	// Major routes
-	mux.Handle("/services", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.FetchServices)))             // Fetch all majors
-	mux.Handle("/services/adminshow", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.ShowService)))     // Create a new major
-	mux.Handle("/services/admincreate", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.CreateService))) // Update a major
-	mux.Handle("/services/admindelete", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.DeleteService))) // Delete a major
-	mux.Handle("/services/adminupdate", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.UpdateService))) // Update a service
-
-	mux.Handle("/category", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.FetchCategories)))              // Fetch all majors
-	mux.Handle("/category/adminshow", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.ShowCategory)))       // Create a new major
-	mux.Handle("/category/admincreate", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.CreateCategory)))   // Update a major
-	mux.Handle("/category/admindelete", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.DeleteCategory)))   // Delete a major
-	mux.Handle("/categories/adminupdate", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.UpdateCategory))) // Update a category
-
-	mux.Handle("/products", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.FetchProducts)))
-	mux.Handle("/products/view", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.ViewProduct)))
-	mux.Handle("/products/viewid", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.ViewProductByID)))
-	mux.Handle("/products/view-seller-product", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.FetchProductsByUserID)))
-	mux.Handle("/products/create", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.CreateProduct)))
-	mux.Handle("/products/update", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.UpdateProduct)))
-	mux.Handle("/products/delete", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.DeleteProduct)))
-
-	//search
-	mux.Handle("/products/search", (http.HandlerFunc(controllers.SearchProducts)))
-
-	mux.Handle("/user/request-seller", middleware.FirebaseAuthMiddleware(http.HandlerFunc(handlers.HandleRequestSeller)))
-	mux.Handle("/admin/verify-seller", middleware.FirebaseAuthMiddleware(http.HandlerFunc(handlers.HandleAdminVerifySeller)))
-	mux.Handle("/user/request-seller-status", middleware.FirebaseAuthMiddleware(http.HandlerFunc(handlers.GetRegisterSellerStatus)))
-	mux.Handle("/user/change-role", middleware.FirebaseAuthMiddleware(http.HandlerFunc(handlers.HandleChangeRole)))
-
-	mux.Handle("/user/user-seller-data", middleware.FirebaseAuthMiddleware(http.HandlerFunc(handlers.HandleGetUserAndSellerData)))
-	mux.Handle("/admin/regsiterSeller", middleware.FirebaseAuthMiddleware(http.HandlerFunc(handlers.HandleGetAllSellers)))
-
-	//transaction
-	mux.Handle("/api/transactions", middleware.FirebaseAuthMiddleware(http.HandlerFunc(controllers.CreateTransaction)))
-	//tambahin role admin, seller, buyer sebagai middleware
-
-	// Wrap ServeMux with CORS middleware
-	handler := corsMiddleware(mux)
-
-	// Get server port from environment
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8080" // Default port
+	// Load and validate the configuration of the selected profile
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	logging.Setup(logging.Options{Level: cfg.Log.Level, Format: cfg.Log.Format})
+	slog.Info("configuration loaded", "env", cfg.Env)
+	slog.Debug("configuration", "config", cfg.String())
+
+	// Export traces as configured by OTEL_TRACES_EXPORTER
+	shutdownTracing, err := tracing.Setup(context.Background())
+	if err != nil {
+		slog.Error("failed to set up tracing", "error", err)
+		os.Exit(1)
+	}
+
+	// Initialize Firebase and Midtrans
+	app, err := config.InitializeFirebaseApp(context.Background(), cfg.Firebase)
+	if err != nil {
+		slog.Error("failed to initialize Firebase", "error", err)
+		os.Exit(1)
+	}
+	midtrans, err := config.InitializeMidtrans(cfg.Midtrans)
+	if err != nil {
+		slog.Error("failed to initialize Midtrans", "error", err)
+		os.Exit(1)
+	}
+
+	// Verify ID tokens with a single Auth client and cache the results
+	authService, err := auth.NewService(context.Background(), app, auth.DefaultCacheSize)
+	if err != nil {
+		slog.Error("failed to initialize Firebase Auth", "error", err)
+		os.Exit(1)
+	}
+	// Tokens of sessions signed out from the session registry are rejected
+	authService.SetSessionCheck(sessions.Check)
+	auth.SetDefault(authService)
+
+	// Push notifications through FCM
+	if pusher, err := notify.NewFCMPusher(context.Background(), app); err != nil && cfg.Env == config.Development {
+		// Offline development keeps pushes in memory instead
+		slog.Warn("recording push notifications instead of sending them", "error", err)
+		notify.SetPusher(&notify.RecordingPusher{})
+	} else if err != nil {
+		slog.Warn("push notifications disabled", "error", err)
+	} else {
+		notify.SetPusher(pusher)
+	}
+
+	// Mail server of notification and verification emails
+	utils.SetupEmail(cfg.SMTP)
+
+	// Campus email domains of seller applicants
+	campus.Setup(cfg.Campus)
+
+	// Graduation policy of sellers
+	alumni.Setup(cfg.Alumni)
+
+	// Signed URLs and retention of student ID cards
+	idcards.Setup(cfg.IDCards, cfg.Firebase.StorageBucket)
+
+	// Start background workers for the job outbox
+	jobs.Start(jobs.Options{})
+
+	// Daily check for sellers past their graduation date
+	if err := alumni.Schedule(context.Background()); err != nil {
+		slog.Warn("failed to schedule alumni sweep", "error", err)
+	}
+
+	// Rate limit buckets, shared between replicas when kept in Firebase
+	rateLimits := ratelimit.Options{TrustedProxies: cfg.RateLimit.TrustedProxies}
+	if cfg.RateLimit.Store == config.RateLimitFirebase {
+		rateLimits.Store = ratelimit.NewFirebaseStore(config.Database)
+	}
+	ratelimit.Setup(rateLimits)
+	clientLimit := ratelimit.Policy{Name: "client", Limit: cfg.RateLimit.PerMinute, Period: time.Minute}
+
+	// Register the v1 API and the deprecated legacy paths
+	mux := routes.New(routes.Dependencies{
+		Payments: &controllers.Payments{Midtrans: midtrans, Database: config.Database},
+	})
+	if _, err := routes.Spec(mux); err != nil {
+		slog.Error("API documentation is out of date", "error", err)
+		os.Exit(1)
+	}
+
+	crossOrigin, err := corsPolicy(cfg.CORS)
+	if err != nil {
+		slog.Error("invalid CORS policy", "error", err)
+		os.Exit(1)
+	}
+
+	// Wrap the router with request logging, tracing, metrics, CORS, rate limiting and recover from handler panics
+	api := logging.Middleware(tracing.Middleware(mux.Match, metrics.Middleware(mux.Match, crossOrigin.Middleware(mux.Match, ratelimit.Limit(clientLimit, apierror.Recover(mux))))))
+
+	// Readiness checks
+	health.Register("firebase", config.PingDatabase)
+	health.Register("midtrans", func(context.Context) error {
+		if midtrans.SnapClient == nil {
+			return errors.New("Snap client is not configured")
+		}
+		return nil
+	})
+	health.Register("jobs", func(context.Context) error {
+		if !jobs.Running() {
+			return errors.New("job workers are not running")
+		}
+		return nil
+	})
+
+	// Metrics and probes are served outside of the API and its middleware
+	handler := http.NewServeMux()
+	handler.Handle("GET /metrics", metrics.Handler(cfg.Metrics.Token.Value()))
+	handler.HandleFunc("GET /healthz", health.Live)
+	handler.HandleFunc("GET /readyz", health.Ready)
+	handler.Handle("/", api)
+
+	server := &http.Server{
+		Addr:              ":" + cfg.Server.Port,
+		Handler:           allowStreams(handler),
+		ReadHeaderTimeout: cfg.Server.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
 	}
 
 	// Start server
-	log.Printf("Server running on port %s", port)
-	log.Fatal(http.ListenAndServe(":"+port, handler))
+	serveErr := make(chan error, 1)
+	go func() {
+		slog.Info("server running", "port", cfg.Server.Port)
+		serveErr <- server.ListenAndServe()
+	}()
+
+	// Wait for SIGTERM from the platform or Ctrl+C
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	defer stop()
+
+	select {
+	case err := <-serveErr:
+		slog.Error("server stopped", "error", err)
+		shutdownTracing(context.Background())
+		os.Exit(1)
+	case <-ctx.Done():
+	}
+
+	if err := shutdown(server, cfg.Server.ShutdownTimeout, shutdownTracing); err != nil {
+		slog.Error("shutdown did not complete", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("server stopped")
+}
+
+// shutdown drains the server within timeout: readiness fails first, open
+// notification streams are closed so that their handlers return, in-flight
+// requests finish, and the outbox workers complete the jobs they hold
+func shutdown(server *http.Server, timeout time.Duration, shutdownTracing func(context.Context) error) error {
+	slog.Info("shutting down", "timeout", timeout.String())
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	health.Drain()
+	notify.CloseStreams()
+
+	var errs []error
+	if err := server.Shutdown(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("draining requests: %w", err))
+	}
+	if err := jobs.Stop(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("stopping job workers: %w", err))
+	}
+	if err := shutdownTracing(ctx); err != nil {
+		errs = append(errs, fmt.Errorf("flushing traces: %w", err))
+	}
+	return errors.Join(errs...)
+}
+
+// allowStreams lifts the write timeout for server-sent event streams, which
+// stay open for as long as the client listens
+func allowStreams(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+			http.NewResponseController(w).SetWriteDeadline(time.Time{})
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// corsPolicy applies the configured CORS settings to the API. The API
+// documentation can be read from any origin.
+func corsPolicy(cfg config.CORSConfig) (*cors.CORS, error) {
+	policy := cors.Policy{
+		AllowedOrigins:   cfg.AllowedOrigins,
+		AllowCredentials: cfg.AllowCredentials,
+		MaxAge:           cfg.MaxAge,
+	}
+	c, err := cors.New(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	docs := policy
+	docs.AllowedOrigins, docs.AllowCredentials = []string{"*"}, false
+	for _, path := range []string{"/api/openapi.json", "/api/docs"} {
+		if err := c.Route(path, docs); err != nil {
+			return nil, err
+		}
+	}
+
+	for path, override := range cfg.Routes {
+		route := policy
+		if len(override.AllowedOrigins) > 0 {
+			route.AllowedOrigins = override.AllowedOrigins
+		}
+		if override.AllowCredentials != nil {
+			route.AllowCredentials = *override.AllowCredentials
+		}
+		if err := c.Route(path, route); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
 }
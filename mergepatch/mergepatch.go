@@ -0,0 +1,193 @@
+// Package mergepatch implements JSON Merge Patch (RFC 7396) for partial
+// updates. A field set to null in the patch is removed, a field left out is
+// unchanged, and anything else replaces the stored value.
+package mergepatch
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/validate"
+)
+
+// ContentType is the media type of merge patch request bodies
+const ContentType = "application/merge-patch+json"
+
+// Patch is a decoded merge patch document
+type Patch map[string]interface{}
+
+// Requested reports whether the request body is a merge patch
+func Requested(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == ContentType
+}
+
+// Decode reads a merge patch from the request body. Only the listed top-level
+// fields may appear in it; any other field is reported as a validation error.
+func Decode(w http.ResponseWriter, r *http.Request, allowed ...string) (Patch, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, validate.MaxBodyBytes)
+
+	var patch Patch
+	if err := json.NewDecoder(r.Body).Decode(&patch); err != nil {
+		var tooLargeErr *http.MaxBytesError
+		switch {
+		case errors.Is(err, io.EOF):
+			return nil, apierror.BadRequest("Request body is empty")
+		case errors.As(err, &tooLargeErr):
+			return nil, apierror.FromStatus(http.StatusRequestEntityTooLarge, "Request body is too large")
+		}
+		return nil, apierror.BadRequest("Merge patch must be a JSON object")
+	}
+	if patch == nil {
+		return nil, apierror.BadRequest("Merge patch must be a JSON object")
+	}
+
+	permitted := make(map[string]bool, len(allowed))
+	for _, field := range allowed {
+		permitted[field] = true
+	}
+
+	var rejected *apierror.Error
+	for _, field := range patch.fields() {
+		if !permitted[field] {
+			if rejected == nil {
+				rejected = apierror.Validation("Invalid input")
+			}
+			rejected.WithField(field, "cannot be changed")
+		}
+	}
+	if rejected != nil {
+		return nil, rejected
+	}
+
+	return patch, nil
+}
+
+func (p Patch) fields() []string {
+	fields := make([]string, 0, len(p))
+	for field := range p {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// Has reports whether the patch touches the field
+func (p Patch) Has(field string) bool {
+	_, ok := p[field]
+	return ok
+}
+
+// Merge applies patch to target following RFC 7396 and returns the result
+func Merge(target, patch interface{}) interface{} {
+	patchObject, ok := patch.(map[string]interface{})
+	if !ok {
+		if p, isPatch := patch.(Patch); isPatch {
+			patchObject, ok = p, true
+		}
+	}
+	if !ok {
+		return patch
+	}
+
+	targetObject, ok := target.(map[string]interface{})
+	if !ok {
+		targetObject = map[string]interface{}{}
+	}
+
+	result := make(map[string]interface{}, len(targetObject))
+	for key, value := range targetObject {
+		result[key] = value
+	}
+	for key, value := range patchObject {
+		if value == nil {
+			delete(result, key)
+			continue
+		}
+		result[key] = Merge(result[key], value)
+	}
+	return result
+}
+
+// ApplyTo patches the struct v points to through its JSON representation and
+// validates the patched fields, so that a patch cannot make a model invalid.
+// Problems with fields the patch leaves alone are not reported, as the
+// client could not fix them in the same patch. Removed fields end up with
+// their zero value.
+func ApplyTo(v interface{}, patch Patch) error {
+	current, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	var document interface{}
+	if err := json.Unmarshal(current, &document); err != nil {
+		return err
+	}
+
+	merged, err := json.Marshal(Merge(document, patch))
+	if err != nil {
+		return err
+	}
+
+	value := reflect.ValueOf(v).Elem()
+	value.Set(reflect.Zero(value.Type()))
+	if err := json.Unmarshal(merged, v); err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if errors.As(err, &typeErr) && typeErr.Field != "" {
+			return apierror.Validation("Invalid input").WithField(typeErr.Field, "has the wrong type")
+		}
+		return apierror.BadRequest("Invalid merge patch")
+	}
+
+	return patch.only(validate.Struct(v))
+}
+
+// only drops the problems with fields outside the patch from a validation
+// error, and returns nil when none are left
+func (p Patch) only(err error) error {
+	var apiErr *apierror.Error
+	if !errors.As(err, &apiErr) {
+		return err
+	}
+
+	var fields []apierror.FieldError
+	for _, field := range apiErr.Fields {
+		name, _, _ := strings.Cut(field.Field, ".")
+		if p.Has(name) {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	patched := *apiErr
+	patched.Fields = fields
+	return &patched
+}
+
+// Updates turns the patch into a Realtime Database multi-path update relative
+// to the patched node. Nested objects are merged path by path and null
+// values delete the field.
+func Updates(patch Patch) map[string]interface{} {
+	updates := map[string]interface{}{}
+	flatten(updates, "", patch)
+	return updates
+}
+
+func flatten(updates map[string]interface{}, prefix string, patch map[string]interface{}) {
+	for key, value := range patch {
+		path := prefix + key
+		if object, ok := value.(map[string]interface{}); ok && len(object) > 0 {
+			flatten(updates, path+"/", object)
+			continue
+		}
+		updates[path] = value
+	}
+}
@@ -0,0 +1,251 @@
+package mergepatch
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang-firebase-backend/apierror"
+)
+
+// parse decodes a JSON document for the tests
+func parse(t *testing.T, document string) interface{} {
+	t.Helper()
+	var v interface{}
+	if err := json.Unmarshal([]byte(document), &v); err != nil {
+		t.Fatalf("Unmarshal %s: %v", document, err)
+	}
+	return v
+}
+
+// The cases come from the examples of RFC 7396, appendix A
+func TestMerge(t *testing.T) {
+	tests := []struct {
+		target string
+		patch  string
+		want   string
+	}{
+		{`{"a":"b"}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"b"}`, `{"b":"c"}`, `{"a":"b","b":"c"}`},
+		{`{"a":"b"}`, `{"a":null}`, `{}`},
+		{`{"a":"b","b":"c"}`, `{"a":null}`, `{"b":"c"}`},
+		{`{"a":["b"]}`, `{"a":"c"}`, `{"a":"c"}`},
+		{`{"a":"c"}`, `{"a":["b"]}`, `{"a":["b"]}`},
+		{`{"a":{"b":"c"}}`, `{"a":{"b":"d","c":null}}`, `{"a":{"b":"d"}}`},
+		{`{"a":[{"b":"c"}]}`, `{"a":[1]}`, `{"a":[1]}`},
+		{`["a","b"]`, `["c","d"]`, `["c","d"]`},
+		{`{"a":"b"}`, `["c"]`, `["c"]`},
+		{`{"a":"foo"}`, `null`, `null`},
+		{`{"a":"foo"}`, `"bar"`, `"bar"`},
+		{`{"e":null}`, `{"a":1}`, `{"e":null,"a":1}`},
+		{`[1,2]`, `{"a":"b","c":null}`, `{"a":"b"}`},
+		{`{}`, `{"a":{"bb":{"ccc":null}}}`, `{"a":{"bb":{}}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.target+" "+tt.patch, func(t *testing.T) {
+			got := Merge(parse(t, tt.target), parse(t, tt.patch))
+			if want := parse(t, tt.want); !reflect.DeepEqual(got, want) {
+				t.Errorf("Merge = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestMergeLeavesTheTargetUnchanged(t *testing.T) {
+	target := map[string]interface{}{"a": "b", "c": map[string]interface{}{"d": "e"}}
+	Merge(target, Patch{"a": nil, "c": map[string]interface{}{"d": "f"}})
+	want := map[string]interface{}{"a": "b", "c": map[string]interface{}{"d": "e"}}
+	if !reflect.DeepEqual(target, want) {
+		t.Errorf("target = %v, want %v", target, want)
+	}
+}
+
+func TestUpdates(t *testing.T) {
+	tests := []struct {
+		name  string
+		patch string
+		want  map[string]interface{}
+	}{
+		{"fields", `{"title":"Desain","price":"150.000"}`, map[string]interface{}{"title": "Desain", "price": "150.000"}},
+		{"null deletes", `{"photo_url":null}`, map[string]interface{}{"photo_url": nil}},
+		{
+			"nested objects merge path by path",
+			`{"address":{"city":"Depok","zip":null}}`,
+			map[string]interface{}{"address/city": "Depok", "address/zip": nil},
+		},
+		{
+			"deeply nested",
+			`{"a":{"b":{"c":1}}}`,
+			map[string]interface{}{"a/b/c": float64(1)},
+		},
+		{"empty objects replace the field", `{"address":{}}`, map[string]interface{}{"address": map[string]interface{}{}}},
+		{"arrays replace the field", `{"tags":["a","b"]}`, map[string]interface{}{"tags": []interface{}{"a", "b"}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch Patch
+			if err := json.Unmarshal([]byte(tt.patch), &patch); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			if got := Updates(patch); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Updates = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+type category struct {
+	Title    string `json:"title" validate:"required,max=10"`
+	PhotoUrl string `json:"photo_url" validate:"url"`
+	IdMajor  string `json:"id_major" validate:"required"`
+}
+
+func TestApplyTo(t *testing.T) {
+	tests := []struct {
+		name       string
+		stored     category
+		patch      string
+		want       category
+		wantFields []string // reported fields, nil when the patch applies
+	}{
+		{
+			name:   "replace a field",
+			stored: category{Title: "Desain", IdMajor: "m1"},
+			patch:  `{"title":"Musik"}`,
+			want:   category{Title: "Musik", IdMajor: "m1"},
+		},
+		{
+			name:   "remove an optional field",
+			stored: category{Title: "Desain", PhotoUrl: "https://example.com/a.png", IdMajor: "m1"},
+			patch:  `{"photo_url":null}`,
+			want:   category{Title: "Desain", IdMajor: "m1"},
+		},
+		{
+			name:       "remove a required field",
+			stored:     category{Title: "Desain", IdMajor: "m1"},
+			patch:      `{"id_major":null}`,
+			wantFields: []string{"id_major"},
+		},
+		{
+			name:       "invalid patched field",
+			stored:     category{Title: "Desain", IdMajor: "m1"},
+			patch:      `{"title":"Desain Grafis dan Multimedia"}`,
+			wantFields: []string{"title"},
+		},
+		{
+			name:   "invalid stored fields outside the patch are not reported",
+			stored: category{Title: "Desain", PhotoUrl: "not a url"},
+			patch:  `{"title":"Musik"}`,
+			want:   category{Title: "Musik", PhotoUrl: "not a url"},
+		},
+		{
+			name:       "only the patched fields are reported",
+			stored:     category{Title: "Desain", PhotoUrl: "not a url"},
+			patch:      `{"title":null}`,
+			wantFields: []string{"title"},
+		},
+		{
+			name:       "wrong type",
+			stored:     category{Title: "Desain", IdMajor: "m1"},
+			patch:      `{"title":5}`,
+			wantFields: []string{"title"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch Patch
+			if err := json.Unmarshal([]byte(tt.patch), &patch); err != nil {
+				t.Fatalf("Unmarshal: %v", err)
+			}
+			got := tt.stored
+			err := ApplyTo(&got, patch)
+
+			if tt.wantFields == nil {
+				if err != nil {
+					t.Fatalf("ApplyTo = %v, want it applied", err)
+				}
+				if got != tt.want {
+					t.Errorf("patched = %+v, want %+v", got, tt.want)
+				}
+				return
+			}
+			var apiErr *apierror.Error
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("ApplyTo = %v, want a validation error", err)
+			}
+			var fields []string
+			for _, field := range apiErr.Fields {
+				fields = append(fields, field.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("fields = %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int // 0 when the patch is accepted
+		wantFields []string
+	}{
+		{"allowed fields", `{"title":"Musik","photo_url":null}`, 0, nil},
+		{"other fields", `{"title":"Musik","uid":"u2","role":"admin"}`, http.StatusUnprocessableEntity, []string{"role", "uid"}},
+		{"not an object", `["title"]`, http.StatusBadRequest, nil},
+		{"null", `null`, http.StatusBadRequest, nil},
+		{"empty", ``, http.StatusBadRequest, nil},
+		{"too large", `{"title":"` + strings.Repeat("a", 1<<20) + `"}`, http.StatusRequestEntityTooLarge, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPatch, "/", strings.NewReader(tt.body))
+			r.Header.Set("Content-Type", ContentType)
+			_, err := Decode(httptest.NewRecorder(), r, "title", "photo_url")
+
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("Decode = %v, want it accepted", err)
+				}
+				return
+			}
+			var apiErr *apierror.Error
+			if !errors.As(err, &apiErr) || apiErr.Status != tt.wantStatus {
+				t.Fatalf("Decode = %v, want status %d", err, tt.wantStatus)
+			}
+			var fields []string
+			for _, field := range apiErr.Fields {
+				fields = append(fields, field.Field)
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("fields = %v, want %v", fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestRequested(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        bool
+	}{
+		{"application/merge-patch+json", true},
+		{"application/merge-patch+json; charset=utf-8", true},
+		{"application/json", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPatch, "/", nil)
+			r.Header.Set("Content-Type", tt.contentType)
+			if got := Requested(r); got != tt.want {
+				t.Errorf("Requested = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Transport instruments the HTTP calls the Firebase SDK makes to the
+// Realtime Database REST API. Calls to other hosts are passed through.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &firebaseTransport{base: base}
+}
+
+type firebaseTransport struct {
+	base http.RoundTripper
+}
+
+func (t *firebaseTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isDatabaseHost(req.URL.Hostname()) {
+		return t.base.RoundTrip(req)
+	}
+
+	node := topLevelNode(req.URL.Path)
+	operation := "write"
+	if req.Method == http.MethodGet {
+		operation = "read"
+	}
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	firebaseDuration.WithLabelValues(node, operation).Observe(time.Since(start).Seconds())
+
+	result := "error"
+	if err == nil {
+		result = strconv.Itoa(resp.StatusCode)
+	}
+	firebaseRequests.WithLabelValues(node, operation, result).Inc()
+	return resp, err
+}
+
+func isDatabaseHost(host string) bool {
+	return strings.HasSuffix(host, ".firebaseio.com") || strings.HasSuffix(host, ".firebasedatabase.app")
+}
+
+// topLevelNode turns "/products/uid/id.json" into "products"
+func topLevelNode(path string) string {
+	path = strings.TrimSuffix(strings.TrimPrefix(path, "/"), ".json")
+	node, _, _ := strings.Cut(path, "/")
+	if node == "" {
+		return "root"
+	}
+	return node
+}
@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang-firebase-backend/response"
+)
+
+// Middleware counts requests and measures their latency. route returns the
+// pattern that handles the request, which keeps raw paths with IDs out of
+// the labels; requests no route matches are labelled "unmatched".
+func Middleware(route func(*http.Request) string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		pattern := route(r)
+		if pattern == "" {
+			pattern = "unmatched"
+		}
+
+		httpInFlight.Inc()
+		defer httpInFlight.Dec()
+
+		rw := response.Wrap(w)
+		next.ServeHTTP(rw, r)
+
+		labels := []string{pattern, r.Method, strconv.Itoa(rw.Status())}
+		httpRequests.WithLabelValues(labels...).Inc()
+		httpDuration.WithLabelValues(labels...).Observe(time.Since(start).Seconds())
+	})
+}
@@ -0,0 +1,127 @@
+// Package metrics exposes Prometheus metrics for HTTP requests, Firebase
+// Realtime Database calls, Midtrans payments, emails, rate limiting and
+// notification streams on /metrics.
+package metrics
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "skillx"
+
+// Registry holds every metric of the service together with the Go runtime
+// and process collectors
+var Registry = prometheus.NewRegistry()
+
+var (
+	httpRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "http", Name: "requests_total",
+		Help: "HTTP requests by route pattern, method and status code.",
+	}, []string{"route", "method", "status"})
+	httpDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Subsystem: "http", Name: "request_duration_seconds",
+		Help:    "HTTP request latency by route pattern, method and status code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "method", "status"})
+	httpInFlight = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace, Subsystem: "http", Name: "requests_in_flight",
+		Help: "HTTP requests currently being handled.",
+	})
+
+	firebaseRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "firebase", Name: "requests_total",
+		Help: "Realtime Database calls by top-level node, operation (read or write) and result.",
+	}, []string{"node", "operation", "result"})
+	firebaseDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace, Subsystem: "firebase", Name: "request_duration_seconds",
+		Help:    "Realtime Database call latency by top-level node and operation.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"node", "operation"})
+
+	snapRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "midtrans", Name: "snap_requests_total",
+		Help: "Midtrans Snap transaction requests by outcome.",
+	}, []string{"outcome"})
+	snapDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Namespace: namespace, Subsystem: "midtrans", Name: "snap_request_duration_seconds",
+		Help:    "Midtrans Snap transaction request latency.",
+		Buckets: prometheus.DefBuckets,
+	})
+
+	emails = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "email", Name: "sent_total",
+		Help: "Emails sent through SMTP by result.",
+	}, []string{"result"})
+
+	rateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace, Subsystem: "ratelimit", Name: "rejected_total",
+		Help: "Requests rejected with 429 by rate limit policy.",
+	}, []string{"policy"})
+
+	// Streams counts open server-sent event streams. They are the API's only
+	// long-lived connections; there are no WebSocket endpoints.
+	Streams = prometheus.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace, Name: "open_streams",
+		Help: "Open notification streams (server-sent events).",
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		httpRequests, httpDuration, httpInFlight,
+		firebaseRequests, firebaseDuration,
+		snapRequests, snapDuration,
+		emails,
+		rateLimited,
+		Streams,
+	)
+}
+
+// Handler serves the metrics in the Prometheus text format. When token is
+// not empty, scrapers must send it as a bearer token.
+func Handler(token string) http.Handler {
+	metrics := promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+	if token == "" {
+		return metrics
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer "+token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		metrics.ServeHTTP(w, r)
+	})
+}
+
+// ObserveSnap records the outcome of a Midtrans Snap request started at start
+func ObserveSnap(start time.Time, err error) {
+	snapDuration.Observe(time.Since(start).Seconds())
+	snapRequests.WithLabelValues(outcome(err)).Inc()
+}
+
+// ObserveEmail records the result of sending an email
+func ObserveEmail(err error) {
+	emails.WithLabelValues(outcome(err)).Inc()
+}
+
+// ObserveRateLimited records a request rejected by a rate limit policy
+func ObserveRateLimited(policy string) {
+	rateLimited.WithLabelValues(policy).Inc()
+}
+
+func outcome(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "success"
+}
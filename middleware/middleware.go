@@ -2,46 +2,76 @@ package middleware
 
 import (
 	"context"
+	"errors"
 	"net/http"
-	"strings"
 
-	"golang-firebase-backend/config"
-	"golang-firebase-backend/utils"
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/auth"
+	"golang-firebase-backend/logging"
 )
 
+// FirebaseAuthMiddleware verifies the Firebase ID token of the request and
+// puts the user's auth.Principal into the context
 func FirebaseAuthMiddleware(next http.Handler) http.Handler {
+	return authenticate(next, (*auth.Service).Verify)
+}
+
+// FirebaseAuthCheckRevokedMiddleware is FirebaseAuthMiddleware for sensitive
+// routes: tokens of revoked sessions and disabled users are rejected even
+// though they have not expired yet
+func FirebaseAuthCheckRevokedMiddleware(next http.Handler) http.Handler {
+	return authenticate(next, (*auth.Service).VerifyAndCheckRevoked)
+}
+
+func authenticate(next http.Handler, verify func(*auth.Service, context.Context, string) (*auth.Principal, error)) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Extract the Authorization header
-		authHeader := r.Header.Get("Authorization")
-		if !strings.HasPrefix(authHeader, "Bearer ") {
-			utils.RespondError(w, http.StatusUnauthorized, "Missing or invalid token")
+		// Extract the bearer token from the Authorization header
+		idToken, err := auth.BearerToken(r)
+		if err != nil {
+			apierror.Respond(w, r, http.StatusUnauthorized, "Missing or invalid token")
 			return
 		}
 
-		idToken := strings.TrimPrefix(authHeader, "Bearer ")
-		if idToken == "" {
-			utils.RespondError(w, http.StatusUnauthorized, "Token is required")
+		service := auth.Default()
+		if service == nil {
+			apierror.Respond(w, r, http.StatusInternalServerError, "Failed to initialize Firebase Auth")
 			return
 		}
 
-		// Initialize Firebase Auth client
-		ctx := context.Background()
-		client, err := config.FirebaseApp.Auth(ctx)
-		if err != nil {
-			utils.RespondError(w, http.StatusInternalServerError, "Failed to initialize Firebase Auth")
+		// Verify the ID token
+		principal, err := verify(service, r.Context(), idToken)
+		if errors.Is(err, auth.ErrSessionSignedOut) {
+			apierror.Respond(w, r, http.StatusUnauthorized, "Session was signed out")
+			return
+		}
+		if errors.Is(err, auth.ErrSessionUnavailable) {
+			logging.From(r.Context()).Error("failed to check session", "error", err)
+			apierror.Respond(w, r, http.StatusServiceUnavailable, "Could not check the session, try again later")
 			return
 		}
-
-		// Verify the ID token
-		token, err := client.VerifyIDToken(ctx, idToken)
 		if err != nil {
-			utils.RespondError(w, http.StatusUnauthorized, "Invalid ID Token")
+			logging.From(r.Context()).Info("rejected ID token", "error", err)
+			apierror.Respond(w, r, http.StatusUnauthorized, "Invalid ID Token")
 			return
 		}
 
-		// Add UID to context and pass it to the next handler
-		uid := token.UID
-		ctx = context.WithValue(r.Context(), "uid", uid)
+		// Add the principal to the context and pass it to the next handler
+		ctx := auth.WithPrincipal(r.Context(), principal)
+		ctx = logging.With(ctx, "uid", principal.UID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// RequireAdmin lets only users with the admin role claim through. It must be
+// wrapped in one of the authentication middlewares. Admins are appointed
+// with cmd/admin.
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := auth.FromContext(r.Context())
+		if !ok || !principal.IsAdmin() {
+			apierror.Write(w, r, apierror.Forbidden("This route requires the admin role"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
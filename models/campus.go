@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// CampusVerification is the state of the verification of a seller
+// applicant's campus email
+type CampusVerification struct {
+	Email          string     `json:"email"`
+	Organization   string     `json:"organization,omitempty"`
+	OrganizationID string     `json:"organization_id,omitempty"` // when the domain is registered to one
+	Verified       bool       `json:"verified"`
+	VerifiedAt     *time.Time `json:"verified_at,omitempty"`
+	ExpiresAt      *time.Time `json:"expires_at,omitempty"`   // of the pending code
+	ResendAfter    *time.Time `json:"resend_after,omitempty"` // earliest time for a new code
+	AttemptsLeft   int        `json:"attempts_left"`
+}
+
+// CampusEmailInput is the body of a request for a verification code
+type CampusEmailInput struct {
+	Email string `json:"email" validate:"required,email,max=254"`
+}
+
+// CampusCodeInput is the body of a request confirming a verification code
+type CampusCodeInput struct {
+	Code string `json:"code" validate:"required,numeric,min=6,max=6"`
+}
@@ -2,7 +2,7 @@ package models
 
 type Category struct {
 	IdCategory string `json:"id_category,omitempty"`
-	Title      string `json:"title"`
-	PhotoUrl   string `json:"photo_url"`
-	IdMajor    string `json:"id_major"`
+	Title      string `json:"title" validate:"required,max=100"`
+	PhotoUrl   string `json:"photo_url" validate:"url"`
+	IdMajor    string `json:"id_major" validate:"required"`
 }
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// DeviceToken is an FCM registration token of one of a user's devices
+type DeviceToken struct {
+	Token      string    `json:"token" validate:"required,max=4096"`
+	Platform   string    `json:"platform" validate:"required,oneof=android ios web"`
+	DeviceName string    `json:"device_name,omitempty" validate:"max=100"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
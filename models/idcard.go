@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// IDCard is the student ID card photo of a seller applicant. The photo is
+// kept in private storage and only reachable through URL, which expires.
+type IDCard struct {
+	ContentType  string     `json:"content_type"`
+	Size         int64      `json:"size"`
+	UploadedAt   time.Time  `json:"uploaded_at"`
+	DeleteAt     *time.Time `json:"delete_at,omitempty"` // set once the application is decided
+	URL          string     `json:"url"`
+	URLExpiresAt time.Time  `json:"url_expires_at"`
+}
@@ -2,6 +2,6 @@ package models
 
 type Major struct {
 	IdMajor    string `json:"idMajor"`
-	TitleMajor string `json:"titleMajor"`
-	IconUrl    string `json:"iconUrl"`
+	TitleMajor string `json:"titleMajor" validate:"required,max=100"`
+	IconUrl    string `json:"iconUrl" validate:"required,url"`
 }
@@ -5,8 +5,8 @@ import "time"
 type Message struct {
 	ID             string    `json:"id"`
 	SenderID       string    `json:"senderID"`
-	ReceiverID     string    `json:"receiverID"`
-	MessageContent string    `json:"messageContent"`
+	ReceiverID     string    `json:"receiverID" validate:"required"`
+	MessageContent string    `json:"messageContent" validate:"required,max=2000"`
 	IsRead         bool      `json:"isRead"`
 	Timestamp      time.Time `json:"timestamp"`
 }
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// Notification is an entry in a user's in-app inbox
+type Notification struct {
+	ID        string            `json:"id"`
+	Type      string            `json:"type"` // see notify.Type* constants
+	Title     string            `json:"title"`
+	Body      string            `json:"body"`
+	Data      map[string]string `json:"data,omitempty"` // IDs the client needs to open the related screen
+	Read      bool              `json:"read"`
+	CreatedAt time.Time         `json:"created_at"`
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// ChannelPreferences says where notifications of one event type are delivered
+type ChannelPreferences struct {
+	Email bool `json:"email"`
+	InApp bool `json:"in_app"`
+	Push  bool `json:"push"`
+}
+
+// QuietHours is a daily window, in the user's timezone, during which
+// emails are held back and pushes are not sent
+type QuietHours struct {
+	Enabled  bool   `json:"enabled"`
+	Start    string `json:"start"`    // "22:00"
+	End      string `json:"end"`      // "07:00"
+	Timezone string `json:"timezone"` // IANA name, e.g. "Asia/Jakarta"
+}
+
+// NotificationSettings holds a user's notification preferences
+type NotificationSettings struct {
+	Events      map[string]ChannelPreferences `json:"events"` // keyed by notification type
+	QuietHours  QuietHours                    `json:"quiet_hours"`
+	DailyDigest bool                          `json:"daily_digest"` // batch non-urgent emails into one per day; on by default
+	DigestTime  string                        `json:"digest_time"`  // "08:00" in the quiet hours timezone
+	UpdatedAt   time.Time                     `json:"updated_at"`
+}
@@ -0,0 +1,55 @@
+package models
+
+import "time"
+
+// Organization is a university or another campus users belong to
+type Organization struct {
+	ID           string    `json:"id"`
+	Name         string    `json:"name"`
+	ShortName    string    `json:"short_name,omitempty"` // e.g. "UI"
+	LogoURL      string    `json:"logo_url,omitempty"`
+	EmailDomains []string  `json:"email_domains,omitempty"` // subdomains match as well
+	Aliases      []string  `json:"aliases,omitempty"`       // other spellings of the name
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+// OrganizationInput is the body of a create or update organization request
+type OrganizationInput struct {
+	Name         string   `json:"name" validate:"required,max=150"`
+	ShortName    string   `json:"short_name" validate:"max=30"`
+	LogoURL      string   `json:"logo_url" validate:"url"`
+	EmailDomains []string `json:"email_domains" validate:"maxitems=20,max=100"`
+	Aliases      []string `json:"aliases" validate:"maxitems=50,max=150"`
+}
+
+// OrganizationMatch is an organization found for a free-text name. Score is
+// 1 for an exact match of the name, short name or an alias.
+type OrganizationMatch struct {
+	Organization
+	Score float64 `json:"score"`
+}
+
+// OrganizationMigrationInput maps free-text organization values the
+// automatic matching cannot resolve to organization IDs
+type OrganizationMigrationInput struct {
+	Mappings map[string]string `json:"mappings"`
+	DryRun   bool              `json:"dry_run"`
+}
+
+// OrganizationMigrationReport is the result of mapping free-text
+// organization values onto the registry
+type OrganizationMigrationReport struct {
+	DryRun    bool                         `json:"dry_run"`
+	Users     int                          `json:"users"`     // users mapped
+	Sellers   int                          `json:"sellers"`   // seller requests mapped
+	Mapped    map[string]string            `json:"mapped"`    // value to organization ID
+	Unmatched []OrganizationMigrationValue `json:"unmatched"` // most frequent first
+}
+
+// OrganizationMigrationValue is a free-text value left unmatched
+type OrganizationMigrationValue struct {
+	Value       string              `json:"value"`
+	Count       int                 `json:"count"`
+	Suggestions []OrganizationMatch `json:"suggestions,omitempty"`
+}
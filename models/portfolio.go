@@ -3,13 +3,13 @@ package models
 type Portfolio struct {
 	ID          string `json:"id" gorm:"primaryKey"`
 	UserID      string `json:"user_id" gorm:"index"`
-	Title       string `json:"title"`
-	Description string `json:"description"`
-	Link        string `json:"link"`
-	Photo       string `json:"photo"`
-	Type        string `json:"type"`
-	Status      string `json:"status"`
-	DateCreated string `json:"date_created"`
-	DateEnd     string `json:"date_end"`
+	Title       string `json:"title" validate:"max=100"`
+	Description string `json:"description" validate:"max=2000"`
+	Link        string `json:"link" validate:"url"`
+	Photo       string `json:"photo" validate:"url"`
+	Type        string `json:"type" validate:"max=50"`
+	Status      string `json:"status" validate:"max=50"`
+	DateCreated string `json:"date_created" validate:"date"`
+	DateEnd     string `json:"date_end" validate:"date,after=date_created"`
 	IsPresent   bool   `json:"is_present"`
 }
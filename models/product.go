@@ -4,13 +4,13 @@ import "time"
 
 type Product struct {
 	UID         string    `json:"uid"`
-	NameProduct string    `json:"nameProduct"`
-	Description string    `json:"description"`
-	PhotoURL    []string  `json:"photo_url"` // Ganti string dengan []string
-	Price       string    `json:"price"`
+	NameProduct string    `json:"nameProduct" validate:"required,max=100"`
+	Description string    `json:"description" validate:"max=2000"`
+	PhotoURL    []string  `json:"photo_url" validate:"maxitems=10,url"` // Ganti string dengan []string
+	Price       string    `json:"price" validate:"required,price"`
 	Major       string    `json:"major"` //jurusannya
-	IdCategory  string    `json:"idCategory"`
-	IdService   string    `json:"idService"`
+	IdCategory  string    `json:"idCategory" validate:"required"`
+	IdService   string    `json:"idService" validate:"required"`
 	CreatedAt   time.Time `json:"created_at"`
 	UpdatedAt   time.Time `json:"updated_at"`
 }
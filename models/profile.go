@@ -0,0 +1,126 @@
+package models
+
+import "time"
+
+// View decides which fields of a profile a client gets to see
+type View int
+
+const (
+	// ViewPublic is what any signed-in user sees of someone else
+	ViewPublic View = iota
+	// ViewSelf is what users see of themselves
+	ViewSelf
+	// ViewAdmin is what administrators see of any user
+	ViewAdmin
+)
+
+// UserProfile is the User sent to clients. Only the listed fields are ever
+// serialized; the contact and sign-in details are left out of the public view.
+type UserProfile struct {
+	UID            string    `json:"uid"`
+	Name           string    `json:"name"`
+	Organization   string    `json:"organization"`
+	OrganizationID string    `json:"organization_id,omitempty"`
+	Major          string    `json:"major"`
+	PhotoURL       string    `json:"photo_url"`
+	Verified       bool      `json:"verified"`
+	Alumni         bool      `json:"alumni,omitempty"`
+	Role           string    `json:"role"`
+	CreatedAt      time.Time `json:"created_at"`
+
+	*UserDetails // nil in the public view
+}
+
+// UserDetails are the fields of a UserProfile only the user and admins see
+type UserDetails struct {
+	Email      string     `json:"email"`
+	Language   string     `json:"language"`
+	LastSignIn *time.Time `json:"last_sign_in"` // null before the first sign-in
+}
+
+// Profile returns the fields of the user the view may see
+func (u User) Profile(view View) UserProfile {
+	p := UserProfile{
+		UID:            u.UID,
+		Name:           u.Name,
+		Organization:   u.Organization,
+		OrganizationID: u.OrganizationID,
+		Major:          u.Major,
+		PhotoURL:       u.PhotoURL,
+		Verified:       u.Verified,
+		Alumni:         u.Alumni,
+		Role:           u.Role,
+		CreatedAt:      u.CreatedAt,
+	}
+	if view == ViewPublic {
+		return p
+	}
+	p.UserDetails = &UserDetails{Email: u.Email, Language: u.Language}
+	if !u.LastSignIn.IsZero() {
+		lastSignIn := u.LastSignIn
+		p.LastSignIn = &lastSignIn
+	}
+	return p
+}
+
+// SellerProfile is the RegisterSeller sent to clients. The link to the
+// student ID card is never part of it, see package idcards.
+type SellerProfile struct {
+	UID            string    `json:"uid"`
+	Name           string    `json:"name"`
+	Organization   string    `json:"organization"`
+	OrganizationID string    `json:"organization_id,omitempty"`
+	Major          string    `json:"major"`
+	AboutMe        string    `json:"about_me"`
+	Verified       bool      `json:"verified"`
+	Alumni         bool      `json:"alumni,omitempty"` // shown as a badge
+	CreatedAt      time.Time `json:"created_at"`
+
+	*SellerDetails // nil in the public view
+}
+
+// SellerDetails are the fields of a SellerProfile only the applicant and
+// admins see
+type SellerDetails struct {
+	Status                 string     `json:"status"`
+	Email                  string     `json:"email"`
+	EmailVerified          bool       `json:"email_verified"`
+	GraduationMonth        string     `json:"graduation_month,omitempty"`
+	GraduationYear         int        `json:"graduation_year,omitempty"`
+	AlumniStatus           string     `json:"alumni_status,omitempty"`
+	AlumniFlaggedAt        *time.Time `json:"alumni_flagged_at,omitempty"`
+	AlumniSince            *time.Time `json:"alumni_since,omitempty"`
+	ReverificationRequired bool       `json:"reverification_required,omitempty"`
+	UpdatedAt              time.Time  `json:"updated_at"`
+}
+
+// Profile returns the fields of the seller request the view may see
+func (s RegisterSeller) Profile(view View) SellerProfile {
+	p := SellerProfile{
+		UID:            s.UID,
+		Name:           s.Name,
+		Organization:   s.Organization,
+		OrganizationID: s.OrganizationID,
+		Major:          s.Major,
+		AboutMe:        s.AboutMe,
+		Verified:       s.Verified,
+		Alumni:         s.AlumniStatus == "alumni",
+		CreatedAt:      s.CreatedAt,
+	}
+	if view == ViewPublic {
+		return p
+	}
+	p.SellerDetails = &SellerDetails{
+		Status:                 s.Status,
+		Email:                  s.Email,
+		EmailVerified:          s.EmailVerified,
+		GraduationMonth:        s.GraduationMonth,
+		GraduationYear:         s.GraduationYear,
+		AlumniStatus:           s.AlumniStatus,
+		AlumniFlaggedAt:        s.AlumniFlaggedAt,
+		AlumniSince:            s.AlumniSince,
+		ReverificationRequired: s.ReverificationRequired,
+		UpdatedAt:              s.UpdatedAt,
+	}
+	return p
+}
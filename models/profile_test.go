@@ -0,0 +1,125 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+// fields marshals v and returns its top-level JSON object
+func fields(t *testing.T, v interface{}) map[string]interface{} {
+	t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var out map[string]interface{}
+	if err := json.Unmarshal(data, &out); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	return out
+}
+
+func checkFields(t *testing.T, got map[string]interface{}, present, absent []string) {
+	t.Helper()
+	for _, key := range present {
+		if _, ok := got[key]; !ok {
+			t.Errorf("%s is missing from %v", key, got)
+		}
+	}
+	for _, key := range absent {
+		if value, ok := got[key]; ok {
+			t.Errorf("%s = %v, want it left out", key, value)
+		}
+	}
+}
+
+func TestUserProfile(t *testing.T) {
+	user := User{
+		UID:          "u1",
+		Name:         "Budi",
+		Email:        "budi@example.com",
+		Organization: "Universitas Indonesia",
+		Major:        "Ilmu Komputer",
+		Language:     "id",
+		Password:     "hunter2",
+		PhotoURL:     "https://example.com/budi.jpg",
+		Role:         "seller",
+		CreatedAt:    time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC),
+		LastSignIn:   time.Date(2025, 3, 10, 8, 0, 0, 0, time.UTC),
+	}
+	public := []string{"uid", "name", "organization", "major", "photo_url", "verified", "role", "created_at"}
+	private := []string{"email", "language", "last_sign_in"}
+
+	tests := []struct {
+		name    string
+		view    View
+		present []string
+		absent  []string
+	}{
+		{"public", ViewPublic, public, append(private, "password")},
+		{"self", ViewSelf, append(public, private...), []string{"password"}},
+		{"admin", ViewAdmin, append(public, private...), []string{"password"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fields(t, user.Profile(tt.view))
+			checkFields(t, got, tt.present, tt.absent)
+			if tt.view != ViewPublic && got["email"] != user.Email {
+				t.Errorf("email = %v, want %s", got["email"], user.Email)
+			}
+		})
+	}
+}
+
+func TestUserProfileBeforeFirstSignIn(t *testing.T) {
+	got := fields(t, User{UID: "u1"}.Profile(ViewSelf))
+	if value, ok := got["last_sign_in"]; !ok || value != nil {
+		t.Errorf("last_sign_in = %v, want null", value)
+	}
+}
+
+func TestSellerProfile(t *testing.T) {
+	flagged := time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)
+	seller := RegisterSeller{
+		UID:             "u1",
+		Name:            "Budi",
+		Status:          "accepted",
+		Email:           "budi@ui.ac.id",
+		EmailVerified:   true,
+		Organization:    "Universitas Indonesia",
+		Major:           "Ilmu Komputer",
+		PhotoURL:        "https://storage.example.com/id-cards/u1.jpg",
+		Verified:        true,
+		GraduationMonth: "January",
+		GraduationYear:  2025,
+		AboutMe:         "Jasa desain",
+		AlumniStatus:    "alumni",
+		AlumniFlaggedAt: &flagged,
+		CreatedAt:       time.Date(2024, 8, 1, 0, 0, 0, 0, time.UTC),
+		UpdatedAt:       time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+	}
+	public := []string{"uid", "name", "organization", "major", "about_me", "verified", "alumni", "created_at"}
+	private := []string{"status", "email", "email_verified", "graduation_month", "graduation_year", "alumni_status", "alumni_flagged_at", "updated_at"}
+
+	tests := []struct {
+		name    string
+		view    View
+		present []string
+		absent  []string
+	}{
+		// The link to the student ID card is never sent, see package idcards
+		{"public", ViewPublic, public, append(private, "photo_url")},
+		{"self", ViewSelf, append(public, private...), []string{"photo_url"}},
+		{"admin", ViewAdmin, append(public, private...), []string{"photo_url"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fields(t, seller.Profile(tt.view))
+			checkFields(t, got, tt.present, tt.absent)
+			if got["alumni"] != true {
+				t.Errorf("alumni = %v, want true for alumni_status alumni", got["alumni"])
+			}
+		})
+	}
+}
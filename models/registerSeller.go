@@ -4,16 +4,25 @@ import "time"
 
 type RegisterSeller struct {
 	UID             string    `json:"uid"`
-	Name            string    `json:"name"`         // nama mahasiswa
-	Status          string    `json:"status"`       // "pending", "denied", "accepted"
-	Email           string    `json:"email"`        // email mahasiswa
-	Organization    string    `json:"organization"` // asal kampus
-	Major           string    `json:"major"`        //jurusannya
-	PhotoURL        string    `json:"photo_url"`    //foto id card mahasiswa
+	Name            string    `json:"name"`           // nama mahasiswa
+	Status          string    `json:"status"`         // "pending", "denied", "accepted"
+	Email           string    `json:"email"`          // email mahasiswa
+	EmailVerified   bool      `json:"email_verified"` // email kampus sudah diverifikasi
+	Organization    string    `json:"organization"`   // asal kampus
+	OrganizationID  string    `json:"organization_id,omitempty"`
+	OrganizationRaw string    `json:"organization_raw,omitempty"` // teks asli sebelum migrasi organisasi
+	Major           string    `json:"major"`                      //jurusannya
+	PhotoURL        string    `json:"photo_url,omitempty"`        //link lama foto id card mahasiswa, lihat package idcards
 	Verified        bool      `json:"verified"`
 	GraduationMonth string    `json:"graduation_month,omitempty"`
 	GraduationYear  int       `json:"graduation_year,omitempty"`
 	CreatedAt       time.Time `json:"created_at"`
 	UpdatedAt       time.Time `json:"updated_at"`
 	AboutMe         string    `json:"about_me"`
+
+	// Set once the graduation date has passed, see package alumni
+	AlumniStatus           string     `json:"alumni_status,omitempty"` // "pending_confirmation", "alumni"
+	AlumniFlaggedAt        *time.Time `json:"alumni_flagged_at,omitempty"`
+	AlumniSince            *time.Time `json:"alumni_since,omitempty"`
+	ReverificationRequired bool       `json:"reverification_required,omitempty"`
 }
@@ -0,0 +1,127 @@
+package models
+
+// Request bodies accepted by the API that do not map directly onto a stored model
+
+// ProductInput is the body of a create product request
+type ProductInput struct {
+	NameProduct string   `json:"nameProduct" validate:"required,max=100"`
+	Description string   `json:"description" validate:"max=2000"`
+	PhotoURL    []string `json:"photo_url" validate:"maxitems=10,url"`
+	Price       string   `json:"price" validate:"required,price"`
+	IdCategory  string   `json:"idCategory" validate:"required"`
+	IdService   string   `json:"idService" validate:"required"`
+}
+
+// ProductUpdateInput is the body of an update product request; empty fields are left unchanged
+type ProductUpdateInput struct {
+	Description string   `json:"description,omitempty" validate:"max=2000"`
+	PhotoURL    []string `json:"photo_url,omitempty" validate:"maxitems=10,url"`
+	Price       string   `json:"price,omitempty" validate:"price"`
+	IdCategory  string   `json:"idCategory,omitempty"`
+	IdService   string   `json:"idService,omitempty"`
+}
+
+// MajorUpdateInput is the body of an update major request
+type MajorUpdateInput struct {
+	TitleMajor string `json:"title_major,omitempty" validate:"max=100"`
+	IconUrl    string `json:"icon_url,omitempty" validate:"url"`
+}
+
+// ServiceInput is the body of a create service request
+type ServiceInput struct {
+	TitleService  string `json:"title_service" validate:"required,max=100"`
+	IconUrl       string `json:"icon_url" validate:"required,url"`
+	TitleCategory string `json:"title_category" validate:"required"`
+}
+
+// ServiceUpdateInput is the body of an update service request
+type ServiceUpdateInput struct {
+	TitleService  string `json:"title_service,omitempty" validate:"max=100"`
+	IconUrl       string `json:"icon_url,omitempty" validate:"url"`
+	TitleCategory string `json:"title_category,omitempty"`
+}
+
+// CategoryInput is the body of a create category request
+type CategoryInput struct {
+	Title      string `json:"title" validate:"required,max=100"`
+	PhotoUrl   string `json:"photo_url" validate:"required,url"`
+	TitleMajor string `json:"title_major" validate:"required"`
+}
+
+// CategoryUpdateInput is the body of an update category request
+type CategoryUpdateInput struct {
+	Title    string `json:"title,omitempty" validate:"max=100"`
+	PhotoUrl string `json:"photo_url,omitempty" validate:"url"`
+	IdMajor  string `json:"id_major,omitempty"`
+}
+
+// TransactionInput is the body of a create transaction request
+type TransactionInput struct {
+	UserId    string `json:"user_id"` // seller of the product
+	ProductId string `json:"product_id" validate:"required"`
+	Quantity  int    `json:"quantity" validate:"required,min=1,max=100"`
+}
+
+// PaymentNotification is the part of the HTTP notification Midtrans sends
+// when the status of a payment changes that the server uses
+type PaymentNotification struct {
+	OrderID           string `json:"order_id" validate:"required"`
+	StatusCode        string `json:"status_code" validate:"required"`
+	GrossAmount       string `json:"gross_amount" validate:"required"`
+	SignatureKey      string `json:"signature_key" validate:"required"`
+	TransactionStatus string `json:"transaction_status" validate:"required"`
+	FraudStatus       string `json:"fraud_status"`
+	PaymentType       string `json:"payment_type"`
+}
+
+// ChatRoomInput is the body of a new chat room request
+type ChatRoomInput struct {
+	ParticipantID string `json:"participantID" validate:"required"`
+}
+
+// AboutMeInput is the body of an update about me request
+type AboutMeInput struct {
+	AboutMe string `json:"about_me" validate:"max=1000"`
+}
+
+// SellerRequestInput is the body of a request to become a seller
+type SellerRequestInput struct {
+	Name            string `json:"name" validate:"required,max=100"`
+	Email           string `json:"email" validate:"email"` // the verified campus email when set
+	Organization    string `json:"organization" validate:"required,max=100"`
+	Major           string `json:"major" validate:"required,max=100"`
+	GraduationMonth string `json:"graduation_month,omitempty" validate:"month"`
+	GraduationYear  int    `json:"graduation_year,omitempty" validate:"min=1950,max=2100"`
+}
+
+// AlumniConfirmationInput is a seller's answer to whether they graduated.
+// Sellers still studying give their new graduation date.
+type AlumniConfirmationInput struct {
+	Status          string `json:"status" validate:"required,oneof=graduated studying"`
+	GraduationMonth string `json:"graduation_month,omitempty" validate:"month"`
+	GraduationYear  int    `json:"graduation_year,omitempty" validate:"min=1950,max=2100"`
+}
+
+// SellerDecisionInput is the body of an admin decision on a seller request
+type SellerDecisionInput struct {
+	UID    string `json:"uid"`
+	Status string `json:"status" validate:"required,oneof=accepted denied"`
+}
+
+// RoleChangeInput is the body of a change role request
+type RoleChangeInput struct {
+	Role string `json:"role" validate:"required,oneof=buyer seller"`
+}
+
+// PortfolioInput is the body of a create portfolio request
+type PortfolioInput struct {
+	Title       string `json:"title" validate:"required,max=100"`
+	Description string `json:"description" validate:"max=2000"`
+	Link        string `json:"link" validate:"url"`
+	Photo       string `json:"photo" validate:"url"`
+	Type        string `json:"type" validate:"max=50"`
+	Status      string `json:"status" validate:"max=50"`
+	DateCreated string `json:"date_created" validate:"date"`
+	DateEnd     string `json:"date_end" validate:"date,after=date_created"`
+	IsPresent   bool   `json:"is_present"`
+}
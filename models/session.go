@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// Session is one sign-in of a user on a device. Tokens refreshed from the
+// sign-in belong to the same session until it is signed out.
+type Session struct {
+	ID          string     `json:"id"`
+	DeviceName  string     `json:"device_name,omitempty"`
+	Platform    string     `json:"platform,omitempty"`
+	IP          string     `json:"ip,omitempty"`
+	UserAgent   string     `json:"user_agent,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastSeenAt  time.Time  `json:"last_seen_at"`
+	SignedOutAt *time.Time `json:"signed_out_at,omitempty"`
+	// Current marks the session of the request; it is not stored
+	Current bool `json:"current,omitempty"`
+}
+
+// LoginInput describes the device signing in. The body of the login request
+// is optional.
+type LoginInput struct {
+	DeviceName string `json:"device_name" validate:"max=100"`
+	Platform   string `json:"platform" validate:"oneof=android ios web"`
+}
@@ -2,5 +2,5 @@ package models
 
 type Skill struct {
 	IdSkill     string `json:"idSkill"`
-	TitleSkills string `json:"titleSkills"`
+	TitleSkills string `json:"titleSkills" validate:"required,max=100"`
 }
@@ -2,20 +2,24 @@ package models
 
 import "time"
 
-// User represents a user entity stored in Firebase
+// User represents a user entity stored in Firebase. Responses send its
+// Profile instead.
 type User struct {
-	UID          string    `json:"uid"`
-	Name         string    `json:"name"`
-	Email        string    `json:"email"`
-	Organization string    `json:"organization"`
-	Major        string    `json:"major"`
-	Language     string    `json:"language"`
-	Password     string    `json:"password"`  // Password is not serialized to JSON
-	PhotoURL     string    `json:"photo_url"` // Optional
-	Verified     bool      `json:"verified"`
-	Role         string    `json:"role"`
-	CreatedAt    time.Time `json:"created_at"`
-	LastSignIn   time.Time `json:"last_sign_in,omitempty"`
+	UID             string    `json:"uid"`
+	Name            string    `json:"name" validate:"required,max=100"`
+	Email           string    `json:"email"`
+	Organization    string    `json:"organization" validate:"max=100"` // name, or free text for unregistered campuses
+	OrganizationID  string    `json:"organization_id,omitempty"`       // in the organization registry
+	OrganizationRaw string    `json:"organization_raw,omitempty"`      // free text the migration replaced
+	Major           string    `json:"major" validate:"max=100"`
+	Language        string    `json:"language" validate:"max=50"`
+	Password        string    `json:"-"`         // never serialized to JSON
+	PhotoURL        string    `json:"photo_url"` // Optional
+	Verified        bool      `json:"verified"`
+	Alumni          bool      `json:"alumni,omitempty"` // graduated seller, shown as a badge
+	Role            string    `json:"role"`
+	CreatedAt       time.Time `json:"created_at"`
+	LastSignIn      time.Time `json:"last_sign_in,omitempty"`
 }
 
 // NewBuyer creates a new User with the buyer role
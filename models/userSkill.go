@@ -2,5 +2,5 @@ package models
 
 type UserSkill struct {
 	UserId  string `json:"UserId"`
-	IdSkill string `json:"IdSkill"`
+	IdSkill string `json:"IdSkill" validate:"required"`
 }
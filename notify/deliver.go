@@ -0,0 +1,240 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"log"
+	"strings"
+	"time"
+
+	"golang-firebase-backend/config"
+	"golang-firebase-backend/jobs"
+	"golang-firebase-backend/models"
+
+	"firebase.google.com/go/db"
+)
+
+// Job types used to deliver notifications in the background
+const (
+	typeDeliver = "notify.deliver"
+	typeEmail   = "notify.email"
+	typePush    = "notify.push"
+	typeDigest  = "notify.digest"
+)
+
+// deliverPayload is a notification to publish, or, for the email and push
+// jobs, one that still has to go out on that channel
+type deliverPayload struct {
+	UID          string              `json:"uid"`
+	Notification models.Notification `json:"notification"`
+}
+
+type digestPayload struct {
+	UID string `json:"uid"`
+}
+
+func init() {
+	jobs.Register(typeDeliver, deliver)
+	jobs.Register(typeEmail, deliverEmail)
+	jobs.Register(typePush, deliverPush)
+	jobs.Register(typeDigest, sendDigest)
+}
+
+// Stage adds the delivery of a notification to a multi-path update, so that it
+// is published only if, and as soon as, the state change that caused it is committed
+func Stage(updates map[string]interface{}, uid string, notification models.Notification) error {
+	_, err := jobs.Stage(updates, typeDeliver, deliverPayload{UID: uid, Notification: notification})
+	return err
+}
+
+func deliver(ctx context.Context, payload json.RawMessage) error {
+	var p deliverPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("invalid notification payload: %v", err)
+	}
+
+	_, err := Publish(ctx, p.UID, p.Notification)
+	return err
+}
+
+func deliverEmail(ctx context.Context, payload json.RawMessage) error {
+	var p deliverPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("invalid notification payload: %v", err)
+	}
+
+	settings, err := LoadSettings(ctx, p.UID)
+	if err != nil {
+		return err
+	}
+	if err := email(ctx, p.UID, p.Notification, settings); err != nil {
+		return fmt.Errorf("error emailing notification: %v", err)
+	}
+	return nil
+}
+
+func deliverPush(ctx context.Context, payload json.RawMessage) error {
+	var p deliverPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("invalid notification payload: %v", err)
+	}
+
+	if err := push(ctx, p.UID, p.Notification); err != nil {
+		return fmt.Errorf("error pushing notification: %v", err)
+	}
+	return nil
+}
+
+func digestPath(uid string) string {
+	return "notification_digests/" + uid
+}
+
+// email queues the notification for the user's email address, holding it
+// back during quiet hours or batching it into the daily digest
+func email(ctx context.Context, uid string, notification models.Notification, settings models.NotificationSettings) error {
+	address, err := emailAddress(ctx, uid)
+	if err != nil || address == "" {
+		return err
+	}
+
+	if digested(settings, notification.Type) {
+		return addToDigest(ctx, uid, notification, settings)
+	}
+
+	var opts []jobs.Option
+	if until, quiet := quietUntil(settings, time.Now()); quiet {
+		opts = append(opts, jobs.At(until))
+	}
+
+	_, err = jobs.Enqueue(ctx, jobs.TypeSendEmail, jobs.EmailPayload{
+		To:      address,
+		Subject: notification.Title,
+		Body:    fmt.Sprintf("<p>%s</p>", html.EscapeString(notification.Body)),
+	}, opts...)
+	return err
+}
+
+func emailAddress(ctx context.Context, uid string) (string, error) {
+	client, err := config.Database(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	var address string
+	if err := client.NewRef("users/"+uid+"/email").Get(ctx, &address); err != nil {
+		return "", fmt.Errorf("error fetching email of %s: %v", uid, err)
+	}
+	return address, nil
+}
+
+func addToDigest(ctx context.Context, uid string, notification models.Notification, settings models.NotificationSettings) error {
+	client, err := config.Database(ctx)
+	if err != nil {
+		return err
+	}
+
+	if _, err := client.NewRef(digestPath(uid)+"/items").Push(ctx, &notification); err != nil {
+		return fmt.Errorf("error adding notification to digest: %v", err)
+	}
+
+	return scheduleDigest(ctx, client, uid, settings)
+}
+
+// scheduleDigest enqueues the digest job unless one is already waiting
+func scheduleDigest(ctx context.Context, client *db.Client, uid string, settings models.NotificationSettings) error {
+	flag := client.NewRef(digestPath(uid) + "/scheduled")
+
+	var schedule bool
+	if err := flag.Transaction(ctx, func(node db.TransactionNode) (interface{}, error) {
+		var scheduled bool
+		if err := node.Unmarshal(&scheduled); err != nil {
+			return nil, err
+		}
+		schedule = !scheduled
+		return true, nil
+	}); err != nil {
+		return fmt.Errorf("error scheduling digest: %v", err)
+	}
+	if !schedule {
+		return nil
+	}
+
+	digestAt := time.Now()
+	if minute, ok := minuteOfDay(settings.DigestTime); ok {
+		digestAt = nextClock(digestAt, location(settings), minute)
+	}
+
+	if _, err := jobs.Enqueue(ctx, typeDigest, digestPayload{UID: uid}, jobs.At(digestAt)); err != nil {
+		flag.Delete(ctx)
+		return err
+	}
+	return nil
+}
+
+func sendDigest(ctx context.Context, payload json.RawMessage) error {
+	var p digestPayload
+	if err := json.Unmarshal(payload, &p); err != nil {
+		return fmt.Errorf("invalid digest payload: %v", err)
+	}
+
+	client, err := config.Database(ctx)
+	if err != nil {
+		return err
+	}
+
+	var items map[string]models.Notification
+	if err := client.NewRef(digestPath(p.UID)+"/items").Get(ctx, &items); err != nil {
+		return fmt.Errorf("error fetching digest: %v", err)
+	}
+
+	// The email job and the removal of what it contains are written in one
+	// update, so a failure never sends the same items twice. Anything added
+	// meanwhile goes into the next digest.
+	updates := map[string]interface{}{digestPath(p.UID) + "/scheduled": nil}
+	for id := range items {
+		updates[digestPath(p.UID)+"/items/"+id] = nil
+	}
+
+	if len(items) > 0 {
+		address, err := emailAddress(ctx, p.UID)
+		if err != nil {
+			return err
+		}
+
+		if address != "" {
+			var body strings.Builder
+			body.WriteString("<p>Here is what happened on SkillX since your last digest:</p><ul>")
+			for _, item := range items {
+				fmt.Fprintf(&body, "<li><strong>%s</strong>: %s</li>", html.EscapeString(item.Title), html.EscapeString(item.Body))
+			}
+			body.WriteString("</ul>")
+
+			if _, err := jobs.Stage(updates, jobs.TypeSendEmail, jobs.EmailPayload{
+				To:      address,
+				Subject: fmt.Sprintf("Your SkillX daily digest (%d updates)", len(items)),
+				Body:    body.String(),
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := client.NewRef("").Update(ctx, updates); err != nil {
+		return fmt.Errorf("error sending digest: %v", err)
+	}
+	jobs.Notify()
+
+	var remaining map[string]interface{}
+	if err := client.NewRef(digestPath(p.UID)+"/items").GetShallow(ctx, &remaining); err != nil {
+		log.Printf("Failed to check remaining digest items of %s: %v", p.UID, err)
+		return nil
+	}
+	if len(remaining) > 0 {
+		settings, _ := LoadSettings(ctx, p.UID)
+		return scheduleDigest(ctx, client, p.UID, settings)
+	}
+
+	return nil
+}
@@ -0,0 +1,237 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"time"
+
+	"golang-firebase-backend/config"
+	"golang-firebase-backend/jobs"
+	"golang-firebase-backend/models"
+)
+
+// Notification types
+const (
+	TypeSellerApplicationDecided = "seller_application_decided"
+	TypeOrderStatusChanged       = "order_status_changed"
+	TypePaymentReceived          = "payment_received"
+	TypeNewMessage               = "new_message"
+)
+
+var ErrNotFound = errors.New("notification not found")
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+)
+
+func inboxPath(uid string) string {
+	return "notifications/" + uid
+}
+
+// Publish delivers a notification to the user on every channel their
+// settings enable: the in-app inbox, from which open streams on every
+// replica pick it up, email and push.
+// The inbox entry and the email and push jobs are written in one update, so
+// each channel is retried on its own and a retry never duplicates the
+// inbox entry.
+func Publish(ctx context.Context, uid string, notification models.Notification) (*models.Notification, error) {
+	if uid == "" {
+		return nil, fmt.Errorf("notification has no recipient")
+	}
+
+	settings, err := LoadSettings(ctx, uid)
+	if err != nil {
+		log.Printf("Failed to load notification settings of %s, using defaults: %v", uid, err)
+	}
+	notification.Read = false
+	notification.CreatedAt = time.Now()
+	prefs := channels(settings, notification.Type, notification.CreatedAt)
+
+	updates := map[string]interface{}{}
+	if prefs.InApp {
+		// Keys are ordered by creation time, which the cursor in List relies on
+		notification.ID = inboxKey(notification.CreatedAt)
+		updates[inboxPath(uid)+"/"+notification.ID] = &notification
+	}
+	channel := deliverPayload{UID: uid, Notification: notification}
+	if prefs.Email {
+		if _, err := jobs.Stage(updates, typeEmail, channel); err != nil {
+			return nil, err
+		}
+	}
+	if prefs.Push {
+		if _, err := jobs.Stage(updates, typePush, channel); err != nil {
+			return nil, err
+		}
+	}
+	if len(updates) == 0 {
+		return &notification, nil
+	}
+
+	client, err := config.Database(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.NewRef("").Update(ctx, updates); err != nil {
+		return nil, fmt.Errorf("error saving notification: %v", err)
+	}
+	jobs.Notify()
+
+	return &notification, nil
+}
+
+// channels returns the channels a notification of the type goes out on at
+// now. Pushes are dropped during quiet hours, while emails are held back
+// until they end.
+func channels(settings models.NotificationSettings, notificationType string, now time.Time) models.ChannelPreferences {
+	prefs := settings.Events[notificationType]
+	if _, quiet := quietUntil(settings, now); quiet {
+		prefs.Push = false
+	}
+	return prefs
+}
+
+// pushChars are the characters of Firebase push IDs, in ascending order
+const pushChars = "-0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZ_abcdefghijklmnopqrstuvwxyz"
+
+// inboxKey generates a key like those of Ref.Push, which sort by creation
+// time, so that entries can be written as part of a multi-path update
+func inboxKey(t time.Time) string {
+	var key [20]byte
+	ms := t.UnixMilli()
+	for i := 7; i >= 0; i-- {
+		key[i] = pushChars[ms%64]
+		ms /= 64
+	}
+	for i := 8; i < len(key); i++ {
+		key[i] = pushChars[rand.IntN(len(pushChars))]
+	}
+	return string(key[:])
+}
+
+// List returns one page of notifications, newest first. The returned cursor
+// is passed back to fetch the next page and is empty on the last page.
+func List(ctx context.Context, uid, cursor string, limit int) ([]models.Notification, string, error) {
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
+	}
+
+	client, err := config.Database(ctx)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// EndAt is inclusive, so fetch one extra entry for the cursor itself and
+	// one more to know whether another page exists
+	fetch := limit + 1
+	query := client.NewRef(inboxPath(uid)).OrderByKey()
+	if cursor != "" {
+		query = query.EndAt(cursor)
+		fetch++
+	}
+
+	nodes, err := query.LimitToLast(fetch).GetOrdered(ctx)
+	if err != nil {
+		return nil, "", fmt.Errorf("error fetching notifications: %v", err)
+	}
+
+	notifications := make([]models.Notification, 0, len(nodes))
+	for i := len(nodes) - 1; i >= 0; i-- {
+		if nodes[i].Key() == cursor {
+			continue
+		}
+		var notification models.Notification
+		if err := nodes[i].Unmarshal(&notification); err != nil {
+			return nil, "", fmt.Errorf("error decoding notification %s: %v", nodes[i].Key(), err)
+		}
+		notification.ID = nodes[i].Key()
+		notifications = append(notifications, notification)
+	}
+
+	next := ""
+	if len(notifications) > limit {
+		notifications = notifications[:limit]
+		next = notifications[limit-1].ID
+	}
+
+	return notifications, next, nil
+}
+
+// MarkRead marks a single notification as read
+func MarkRead(ctx context.Context, uid, id string) error {
+	client, err := config.Database(ctx)
+	if err != nil {
+		return err
+	}
+
+	ref := client.NewRef(inboxPath(uid) + "/" + id)
+	var existing models.Notification
+	if err := ref.Get(ctx, &existing); err != nil {
+		return fmt.Errorf("error fetching notification: %v", err)
+	}
+	if existing.Type == "" {
+		return ErrNotFound
+	}
+
+	return ref.Update(ctx, map[string]interface{}{"read": true})
+}
+
+// MarkAllRead marks every unread notification of the user as read and
+// returns how many were changed
+func MarkAllRead(ctx context.Context, uid string) (int, error) {
+	unread, err := unreadIDs(ctx, uid)
+	if err != nil || len(unread) == 0 {
+		return 0, err
+	}
+
+	client, err := config.Database(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	updates := make(map[string]interface{}, len(unread))
+	for _, id := range unread {
+		updates[id+"/read"] = true
+	}
+	if err := client.NewRef(inboxPath(uid)).Update(ctx, updates); err != nil {
+		return 0, fmt.Errorf("error marking notifications as read: %v", err)
+	}
+
+	return len(unread), nil
+}
+
+// UnreadCount returns the number of unread notifications of the user
+func UnreadCount(ctx context.Context, uid string) (int, error) {
+	unread, err := unreadIDs(ctx, uid)
+	return len(unread), err
+}
+
+func unreadIDs(ctx context.Context, uid string) ([]string, error) {
+	client, err := config.Database(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var notifications map[string]struct {
+		Read bool `json:"read"`
+	}
+	if err := client.NewRef(inboxPath(uid)).Get(ctx, &notifications); err != nil {
+		return nil, fmt.Errorf("error fetching notifications: %v", err)
+	}
+
+	var ids []string
+	for id, notification := range notifications {
+		if !notification.Read {
+			ids = append(ids, id)
+		}
+	}
+
+	return ids, nil
+}
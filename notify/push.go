@@ -0,0 +1,259 @@
+package notify
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"golang-firebase-backend/config"
+	"golang-firebase-backend/models"
+
+	firebase "firebase.google.com/go"
+	"firebase.google.com/go/messaging"
+)
+
+// PushMessage is the content of a push notification
+type PushMessage struct {
+	Title string
+	Body  string
+	Data  map[string]string
+}
+
+// Pusher sends push messages to device tokens. It returns the tokens the
+// push service rejected as no longer valid so that they can be pruned.
+type Pusher interface {
+	Send(ctx context.Context, tokens []string, message PushMessage) (invalid []string, err error)
+}
+
+var (
+	pusherMu sync.RWMutex
+	pusher   Pusher
+)
+
+// SetPusher sets the push channel. Pushes are skipped while it is nil.
+func SetPusher(p Pusher) {
+	pusherMu.Lock()
+	defer pusherMu.Unlock()
+	pusher = p
+}
+
+func currentPusher() Pusher {
+	pusherMu.RLock()
+	defer pusherMu.RUnlock()
+	return pusher
+}
+
+// FCMPusher sends pushes through Firebase Cloud Messaging
+type FCMPusher struct {
+	client *messaging.Client
+}
+
+// NewFCMPusher creates a Pusher backed by the app's FCM client
+func NewFCMPusher(ctx context.Context, app *firebase.App) (*FCMPusher, error) {
+	if app == nil {
+		return nil, fmt.Errorf("firebase app is not initialized")
+	}
+
+	client, err := app.Messaging(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error initializing Firebase Messaging: %v", err)
+	}
+
+	return &FCMPusher{client: client}, nil
+}
+
+func (p *FCMPusher) Send(ctx context.Context, tokens []string, message PushMessage) ([]string, error) {
+	var invalid []string
+	var lastErr error
+	sent := 0
+
+	for _, token := range tokens {
+		_, err := p.client.Send(ctx, &messaging.Message{
+			Token: token,
+			Notification: &messaging.Notification{
+				Title: message.Title,
+				Body:  message.Body,
+			},
+			Data: message.Data,
+		})
+		switch {
+		case err == nil:
+			sent++
+		case deadToken(err):
+			invalid = append(invalid, token)
+		default:
+			lastErr = err
+		}
+	}
+
+	if sent == 0 && lastErr != nil {
+		return invalid, lastErr
+	}
+	return invalid, nil
+}
+
+// deadToken reports whether FCM rejected a send because of the token itself.
+// Invalid arguments are also reported for oversized or malformed messages,
+// which must not cost the user their devices.
+func deadToken(err error) bool {
+	if messaging.IsRegistrationTokenNotRegistered(err) {
+		return true
+	}
+	return messaging.IsInvalidArgument(err) && namesToken(err.Error())
+}
+
+// namesToken reports whether an FCM error message blames the registration token
+func namesToken(message string) bool {
+	return strings.Contains(strings.ToLower(message), "registration token")
+}
+
+// RecordedPush is a push captured by RecordingPusher
+type RecordedPush struct {
+	Tokens  []string
+	Message PushMessage
+}
+
+// RecordingPusher is an in-memory Pusher that records every push instead of
+// sending it, for running offline
+type RecordingPusher struct {
+	mu      sync.Mutex
+	Pushes  []RecordedPush
+	Invalid map[string]bool // tokens reported back as invalid
+}
+
+func (p *RecordingPusher) Send(ctx context.Context, tokens []string, message PushMessage) ([]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var valid, invalid []string
+	for _, token := range tokens {
+		if p.Invalid[token] {
+			invalid = append(invalid, token)
+			continue
+		}
+		valid = append(valid, token)
+	}
+	if len(valid) > 0 {
+		p.Pushes = append(p.Pushes, RecordedPush{Tokens: valid, Message: message})
+	}
+
+	return invalid, nil
+}
+
+// Sent returns a copy of the pushes recorded so far
+func (p *RecordingPusher) Sent() []RecordedPush {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]RecordedPush(nil), p.Pushes...)
+}
+
+func devicesPath(uid string) string {
+	return "device_tokens/" + uid
+}
+
+// deviceKey turns a registration token into a database key; tokens are long
+// and not guaranteed to be valid keys
+func deviceKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:16])
+}
+
+// RegisterDevice stores a device token for the user, refreshing it if it
+// was registered before
+func RegisterDevice(ctx context.Context, uid string, device models.DeviceToken) (*models.DeviceToken, error) {
+	client, err := config.Database(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ref := client.NewRef(devicesPath(uid) + "/" + deviceKey(device.Token))
+	var existing models.DeviceToken
+	if err := ref.Get(ctx, &existing); err != nil {
+		return nil, fmt.Errorf("error fetching device: %v", err)
+	}
+
+	now := time.Now()
+	device.CreatedAt = existing.CreatedAt
+	if device.CreatedAt.IsZero() {
+		device.CreatedAt = now
+	}
+	device.UpdatedAt = now
+
+	if err := ref.Set(ctx, &device); err != nil {
+		return nil, fmt.Errorf("error saving device: %v", err)
+	}
+	return &device, nil
+}
+
+// UnregisterDevice removes a device token of the user
+func UnregisterDevice(ctx context.Context, uid, token string) error {
+	client, err := config.Database(ctx)
+	if err != nil {
+		return err
+	}
+
+	return client.NewRef(devicesPath(uid) + "/" + deviceKey(token)).Delete(ctx)
+}
+
+func push(ctx context.Context, uid string, notification models.Notification) error {
+	p := currentPusher()
+	if p == nil {
+		return nil
+	}
+
+	client, err := config.Database(ctx)
+	if err != nil {
+		return err
+	}
+
+	var devices map[string]models.DeviceToken
+	if err := client.NewRef(devicesPath(uid)).Get(ctx, &devices); err != nil {
+		return fmt.Errorf("error fetching devices: %v", err)
+	}
+	if len(devices) == 0 {
+		return nil
+	}
+
+	prune, err := sendToDevices(ctx, p, devices, notification)
+	if len(prune) > 0 {
+		if pruneErr := client.NewRef(devicesPath(uid)).Update(ctx, prune); pruneErr != nil {
+			log.Printf("Failed to prune %d invalid device tokens of %s: %v", len(prune), uid, pruneErr)
+		}
+	}
+
+	return err
+}
+
+// sendToDevices pushes the notification to the devices and returns the
+// update that removes the devices whose tokens were rejected
+func sendToDevices(ctx context.Context, p Pusher, devices map[string]models.DeviceToken, notification models.Notification) (map[string]interface{}, error) {
+	tokens := make([]string, 0, len(devices))
+	for _, device := range devices {
+		tokens = append(tokens, device.Token)
+	}
+
+	data := map[string]string{"type": notification.Type}
+	if notification.ID != "" {
+		data["notification_id"] = notification.ID
+	}
+	for key, value := range notification.Data {
+		data[key] = value
+	}
+
+	invalid, err := p.Send(ctx, tokens, PushMessage{
+		Title: notification.Title,
+		Body:  notification.Body,
+		Data:  data,
+	})
+
+	prune := make(map[string]interface{}, len(invalid))
+	for _, token := range invalid {
+		prune[deviceKey(token)] = nil
+	}
+	return prune, err
+}
@@ -0,0 +1,82 @@
+package notify
+
+import (
+	"context"
+	"testing"
+
+	"golang-firebase-backend/models"
+)
+
+func TestNamesToken(t *testing.T) {
+	tests := []struct {
+		message string
+		want    bool
+	}{
+		{"http error status: 400; reason: The registration token is not a valid FCM registration token; code: invalid-argument", true},
+		{"http error status: 400; reason: Request contains an invalid argument.; code: invalid-argument", false},
+		{"http error status: 400; reason: Message is too big; code: invalid-argument", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := namesToken(tt.message); got != tt.want {
+			t.Errorf("namesToken(%q) = %v, want %v", tt.message, got, tt.want)
+		}
+	}
+}
+
+func TestSendToDevicesPrunesRejectedTokens(t *testing.T) {
+	pusher := &RecordingPusher{Invalid: map[string]bool{"expired-token": true}}
+	devices := map[string]models.DeviceToken{
+		deviceKey("phone-token"):   {Token: "phone-token", Platform: "android"},
+		deviceKey("expired-token"): {Token: "expired-token", Platform: "ios"},
+	}
+	notification := models.Notification{
+		ID:    "n1",
+		Type:  TypeNewMessage,
+		Title: "Budi",
+		Body:  "Halo",
+		Data:  map[string]string{"conversation_id": "c1"},
+	}
+
+	prune, err := sendToDevices(context.Background(), pusher, devices, notification)
+	if err != nil {
+		t.Fatalf("sendToDevices: %v", err)
+	}
+
+	if len(prune) != 1 {
+		t.Fatalf("prune = %v, want only the expired token", prune)
+	}
+	if value, ok := prune[deviceKey("expired-token")]; !ok || value != nil {
+		t.Errorf("prune = %v, want %s removed", prune, deviceKey("expired-token"))
+	}
+
+	sent := pusher.Sent()
+	if len(sent) != 1 || len(sent[0].Tokens) != 1 || sent[0].Tokens[0] != "phone-token" {
+		t.Fatalf("sent = %+v, want one push to phone-token", sent)
+	}
+	data := sent[0].Message.Data
+	for key, want := range map[string]string{"type": TypeNewMessage, "notification_id": "n1", "conversation_id": "c1"} {
+		if data[key] != want {
+			t.Errorf("data[%q] = %q, want %q", key, data[key], want)
+		}
+	}
+}
+
+func TestSendToDevicesKeepsTokensWhenAllValid(t *testing.T) {
+	pusher := &RecordingPusher{}
+	devices := map[string]models.DeviceToken{
+		deviceKey("a"): {Token: "a"},
+		deviceKey("b"): {Token: "b"},
+	}
+
+	prune, err := sendToDevices(context.Background(), pusher, devices, models.Notification{Type: TypeOrderStatusChanged})
+	if err != nil {
+		t.Fatalf("sendToDevices: %v", err)
+	}
+	if len(prune) != 0 {
+		t.Errorf("prune = %v, want nothing", prune)
+	}
+	if sent := pusher.Sent(); len(sent) != 1 || len(sent[0].Tokens) != 2 {
+		t.Errorf("sent = %+v, want one push to both tokens", sent)
+	}
+}
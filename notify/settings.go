@@ -0,0 +1,244 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+	_ "time/tzdata" // quiet hours need timezones even on hosts without zoneinfo
+
+	"golang-firebase-backend/config"
+	"golang-firebase-backend/models"
+)
+
+const (
+	defaultTimezone   = "Asia/Jakarta"
+	defaultDigestTime = "08:00"
+	clockLayout       = "15:04"
+)
+
+// Types lists every notification type users can configure
+var Types = []string{
+	TypeSellerApplicationDecided,
+	TypeOrderStatusChanged,
+	TypePaymentReceived,
+	TypeNewMessage,
+}
+
+// urgent types are always emailed right away instead of going into the digest
+var urgent = map[string]bool{
+	TypeSellerApplicationDecided: true,
+	TypeOrderStatusChanged:       true,
+	TypePaymentReceived:          true,
+}
+
+// digested reports whether emails of the type wait for the daily digest
+func digested(settings models.NotificationSettings, notificationType string) bool {
+	return settings.DailyDigest && !urgent[notificationType]
+}
+
+func settingsPath(uid string) string {
+	return "notification_settings/" + uid
+}
+
+// defaultChannels are used for types the user has not configured. Chat
+// messages already have their own inbox, so they are pushed and collected
+// in the daily digest instead of being added to the notification inbox.
+var defaultChannels = map[string]models.ChannelPreferences{
+	TypeNewMessage: {Email: true, Push: true},
+}
+
+// DefaultSettings enables every channel for every type, except where
+// defaultChannels says otherwise, and batches non-urgent emails into the
+// daily digest, with no quiet hours
+func DefaultSettings() models.NotificationSettings {
+	settings := models.NotificationSettings{
+		Events: map[string]models.ChannelPreferences{},
+		QuietHours: models.QuietHours{
+			Start:    "22:00",
+			End:      "07:00",
+			Timezone: defaultTimezone,
+		},
+		DailyDigest: true,
+		DigestTime:  defaultDigestTime,
+	}
+	for _, notificationType := range Types {
+		prefs, ok := defaultChannels[notificationType]
+		if !ok {
+			prefs = models.ChannelPreferences{Email: true, InApp: true, Push: true}
+		}
+		settings.Events[notificationType] = prefs
+	}
+	return settings
+}
+
+// LoadSettings returns the user's settings, filling in defaults for anything
+// the user never configured
+func LoadSettings(ctx context.Context, uid string) (models.NotificationSettings, error) {
+	settings := DefaultSettings()
+
+	client, err := config.Database(ctx)
+	if err != nil {
+		return settings, err
+	}
+
+	var stored models.NotificationSettings
+	if err := client.NewRef(settingsPath(uid)).Get(ctx, &stored); err != nil {
+		return settings, fmt.Errorf("error fetching notification settings: %v", err)
+	}
+
+	for notificationType, prefs := range stored.Events {
+		settings.Events[notificationType] = prefs
+	}
+	if stored.QuietHours.Timezone != "" {
+		settings.QuietHours = stored.QuietHours
+	}
+	if stored.DigestTime != "" {
+		settings.DigestTime = stored.DigestTime
+	}
+	// Only settings the user saved have a say; the digest is on by default
+	if !stored.UpdatedAt.IsZero() {
+		settings.DailyDigest = stored.DailyDigest
+	}
+	settings.UpdatedAt = stored.UpdatedAt
+
+	return settings, nil
+}
+
+// SaveSettings validates and stores the user's settings
+func SaveSettings(ctx context.Context, uid string, settings models.NotificationSettings) (models.NotificationSettings, error) {
+	if err := ValidateSettings(settings); err != nil {
+		return settings, err
+	}
+
+	merged := DefaultSettings()
+	for notificationType, prefs := range settings.Events {
+		merged.Events[notificationType] = prefs
+	}
+	merged.QuietHours = settings.QuietHours
+	if merged.QuietHours.Timezone == "" {
+		merged.QuietHours.Timezone = defaultTimezone
+	}
+	merged.DailyDigest = settings.DailyDigest
+	if settings.DigestTime != "" {
+		merged.DigestTime = settings.DigestTime
+	}
+	merged.UpdatedAt = time.Now()
+
+	client, err := config.Database(ctx)
+	if err != nil {
+		return merged, err
+	}
+	if err := client.NewRef(settingsPath(uid)).Set(ctx, &merged); err != nil {
+		return merged, fmt.Errorf("error saving notification settings: %v", err)
+	}
+
+	return merged, nil
+}
+
+// SettingsError describes an invalid value in submitted settings
+type SettingsError struct {
+	Field   string
+	Message string
+}
+
+func (e *SettingsError) Error() string {
+	return e.Field + ": " + e.Message
+}
+
+// ValidateSettings checks event types, clock times and the timezone
+func ValidateSettings(settings models.NotificationSettings) error {
+	for notificationType := range settings.Events {
+		if !knownType(notificationType) {
+			return &SettingsError{Field: "events." + notificationType, Message: "unknown notification type"}
+		}
+	}
+
+	if settings.QuietHours.Timezone != "" {
+		if _, err := time.LoadLocation(settings.QuietHours.Timezone); err != nil {
+			return &SettingsError{Field: "quiet_hours.timezone", Message: "unknown timezone"}
+		}
+	}
+	if settings.QuietHours.Enabled {
+		if _, err := time.Parse(clockLayout, settings.QuietHours.Start); err != nil {
+			return &SettingsError{Field: "quiet_hours.start", Message: "must be a time formatted as HH:MM"}
+		}
+		if _, err := time.Parse(clockLayout, settings.QuietHours.End); err != nil {
+			return &SettingsError{Field: "quiet_hours.end", Message: "must be a time formatted as HH:MM"}
+		}
+	}
+	if settings.DigestTime != "" {
+		if _, err := time.Parse(clockLayout, settings.DigestTime); err != nil {
+			return &SettingsError{Field: "digest_time", Message: "must be a time formatted as HH:MM"}
+		}
+	}
+
+	return nil
+}
+
+func knownType(notificationType string) bool {
+	for _, t := range Types {
+		if t == notificationType {
+			return true
+		}
+	}
+	return false
+}
+
+func location(settings models.NotificationSettings) *time.Location {
+	loc, err := time.LoadLocation(settings.QuietHours.Timezone)
+	if err != nil {
+		loc, _ = time.LoadLocation(defaultTimezone)
+	}
+	return loc
+}
+
+// minuteOfDay parses "HH:MM" into minutes since midnight
+func minuteOfDay(clock string) (int, bool) {
+	t, err := time.Parse(clockLayout, clock)
+	if err != nil {
+		return 0, false
+	}
+	return t.Hour()*60 + t.Minute(), true
+}
+
+// nextClock returns the first time at or after now when the wall clock in
+// loc reads the given minute of day
+func nextClock(now time.Time, loc *time.Location, minute int) time.Time {
+	local := now.In(loc)
+	next := time.Date(local.Year(), local.Month(), local.Day(), minute/60, minute%60, 0, 0, loc)
+	if next.Before(local) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// quietUntil reports whether now falls inside the user's quiet hours and,
+// if so, when they end
+func quietUntil(settings models.NotificationSettings, now time.Time) (time.Time, bool) {
+	if !settings.QuietHours.Enabled {
+		return time.Time{}, false
+	}
+
+	start, okStart := minuteOfDay(settings.QuietHours.Start)
+	end, okEnd := minuteOfDay(settings.QuietHours.End)
+	if !okStart || !okEnd || start == end {
+		return time.Time{}, false
+	}
+
+	loc := location(settings)
+	local := now.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+
+	var quiet bool
+	if start < end {
+		quiet = minute >= start && minute < end
+	} else {
+		// Window wraps around midnight, e.g. 22:00-07:00
+		quiet = minute >= start || minute < end
+	}
+	if !quiet {
+		return time.Time{}, false
+	}
+
+	return nextClock(now, loc, end), true
+}
@@ -0,0 +1,160 @@
+package notify
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"golang-firebase-backend/models"
+)
+
+func jakarta(t *testing.T, clock string) time.Time {
+	t.Helper()
+	loc, err := time.LoadLocation("Asia/Jakarta")
+	if err != nil {
+		t.Fatal(err)
+	}
+	at, err := time.ParseInLocation("2006-01-02 15:04", "2025-03-10 "+clock, loc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return at
+}
+
+func quietSettings(start, end string) models.NotificationSettings {
+	settings := DefaultSettings()
+	settings.QuietHours = models.QuietHours{Enabled: true, Start: start, End: end, Timezone: "Asia/Jakarta"}
+	return settings
+}
+
+func TestQuietUntil(t *testing.T) {
+	tests := []struct {
+		name      string
+		settings  models.NotificationSettings
+		now       string
+		wantQuiet bool
+		wantUntil string // clock in Jakarta, the next day when before now
+	}{
+		{"overnight window, late evening", quietSettings("22:00", "07:00"), "23:30", true, "07:00"},
+		{"overnight window, early morning", quietSettings("22:00", "07:00"), "06:59", true, "07:00"},
+		{"overnight window, at the end", quietSettings("22:00", "07:00"), "07:00", false, ""},
+		{"overnight window, daytime", quietSettings("22:00", "07:00"), "12:00", false, ""},
+		{"daytime window", quietSettings("13:00", "15:00"), "14:00", true, "15:00"},
+		{"daytime window, at the start", quietSettings("13:00", "15:00"), "13:00", true, "15:00"},
+		{"empty window", quietSettings("10:00", "10:00"), "10:00", false, ""},
+		{"disabled", DefaultSettings(), "23:30", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := jakarta(t, tt.now)
+			until, quiet := quietUntil(tt.settings, now)
+			if quiet != tt.wantQuiet {
+				t.Fatalf("quiet = %v, want %v", quiet, tt.wantQuiet)
+			}
+			if !quiet {
+				return
+			}
+			want := jakarta(t, tt.wantUntil)
+			if want.Before(now) {
+				want = want.AddDate(0, 0, 1)
+			}
+			if !until.Equal(want) {
+				t.Errorf("until = %s, want %s", until, want)
+			}
+		})
+	}
+}
+
+func TestChannels(t *testing.T) {
+	quiet := quietSettings("22:00", "07:00")
+	quiet.Events[TypeOrderStatusChanged] = models.ChannelPreferences{InApp: true, Email: true, Push: true}
+
+	tests := []struct {
+		name     string
+		settings models.NotificationSettings
+		typ      string
+		now      string
+		want     models.ChannelPreferences
+	}{
+		{"defaults enable every channel", DefaultSettings(), TypeOrderStatusChanged, "12:00", models.ChannelPreferences{InApp: true, Email: true, Push: true}},
+		{"chat messages are pushed and emailed by default", DefaultSettings(), TypeNewMessage, "12:00", models.ChannelPreferences{Email: true, Push: true}},
+		{"no push during quiet hours", quiet, TypeOrderStatusChanged, "23:00", models.ChannelPreferences{InApp: true, Email: true}},
+		{"push after quiet hours", quiet, TypeOrderStatusChanged, "08:00", models.ChannelPreferences{InApp: true, Email: true, Push: true}},
+		{"unknown types go nowhere", DefaultSettings(), "new_review", "12:00", models.ChannelPreferences{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := channels(tt.settings, tt.typ, jakarta(t, tt.now)); got != tt.want {
+				t.Errorf("channels = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDigested(t *testing.T) {
+	noDigest := DefaultSettings()
+	noDigest.DailyDigest = false
+
+	tests := []struct {
+		name     string
+		settings models.NotificationSettings
+		typ      string
+		want     bool
+	}{
+		{"chat messages go into the default digest", DefaultSettings(), TypeNewMessage, true},
+		{"urgent types are emailed right away", DefaultSettings(), TypePaymentReceived, false},
+		{"order updates are urgent", DefaultSettings(), TypeOrderStatusChanged, false},
+		{"without the digest everything is emailed right away", noDigest, TypeNewMessage, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := digested(tt.settings, tt.typ); got != tt.want {
+				t.Errorf("digested = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSettings(t *testing.T) {
+	tests := []struct {
+		name      string
+		settings  models.NotificationSettings
+		wantField string // empty when valid
+	}{
+		{"defaults", DefaultSettings(), ""},
+		{"quiet hours", quietSettings("22:00", "07:00"), ""},
+		{
+			"unknown type",
+			models.NotificationSettings{Events: map[string]models.ChannelPreferences{"new_review": {Email: true}}},
+			"events.new_review",
+		},
+		{
+			"unknown timezone",
+			models.NotificationSettings{QuietHours: models.QuietHours{Timezone: "Mars/Olympus"}},
+			"quiet_hours.timezone",
+		},
+		{"bad start", quietSettings("10pm", "07:00"), "quiet_hours.start"},
+		{"bad end", quietSettings("22:00", "25:00"), "quiet_hours.end"},
+		{"bad digest time", models.NotificationSettings{DigestTime: "8"}, "digest_time"},
+		{
+			"clock times are not checked while quiet hours are off",
+			models.NotificationSettings{QuietHours: models.QuietHours{Start: "nope"}},
+			"",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateSettings(tt.settings)
+			if tt.wantField == "" {
+				if err != nil {
+					t.Fatalf("ValidateSettings: %v", err)
+				}
+				return
+			}
+			var settingsErr *SettingsError
+			if !errors.As(err, &settingsErr) || settingsErr.Field != tt.wantField {
+				t.Errorf("ValidateSettings = %v, want an error for %s", err, tt.wantField)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"golang-firebase-backend/config"
+	"golang-firebase-backend/models"
+)
+
+// streamBuffer is how many notifications a slow stream may fall behind
+// before new ones are dropped for it; they remain available through List
+const streamBuffer = 16
+
+// Streams are fed from the inbox rather than from Publish, so that they get
+// notifications published by any replica. Every instance polls the inbox of
+// each user with an open stream; entries are looked for up to streamLag
+// before the last poll to catch writes that took a while to land.
+const (
+	streamPollInterval = 2 * time.Second
+	streamLag          = 10 * time.Second
+)
+
+// watcher polls the inbox of one user for the streams open on this instance
+type watcher struct {
+	subscribers map[chan models.Notification]struct{}
+	stop        context.CancelFunc
+}
+
+var (
+	streamsMu sync.Mutex
+	streams   = map[string]*watcher{}
+	closed    bool
+)
+
+// Subscribe opens a live stream of new notifications for the user. The
+// returned function must be called when the stream is no longer read.
+// The channel is closed when the stream is cancelled or on shutdown.
+func Subscribe(uid string) (<-chan models.Notification, func()) {
+	ch := make(chan models.Notification, streamBuffer)
+
+	streamsMu.Lock()
+	defer streamsMu.Unlock()
+	if closed {
+		close(ch)
+		return ch, func() {}
+	}
+	w := streams[uid]
+	if w == nil {
+		ctx, stop := context.WithCancel(context.Background())
+		w = &watcher{subscribers: map[chan models.Notification]struct{}{}, stop: stop}
+		streams[uid] = w
+		go poll(ctx, uid, time.Now())
+	}
+	w.subscribers[ch] = struct{}{}
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			streamsMu.Lock()
+			defer streamsMu.Unlock()
+			if _, ok := w.subscribers[ch]; !ok {
+				return
+			}
+			delete(w.subscribers, ch)
+			if len(w.subscribers) == 0 {
+				w.stop()
+				delete(streams, uid)
+			}
+			close(ch)
+		})
+	}
+}
+
+// CloseStreams ends every open stream and rejects new subscriptions
+func CloseStreams() {
+	streamsMu.Lock()
+	defer streamsMu.Unlock()
+	closed = true
+	for uid, w := range streams {
+		w.stop()
+		for ch := range w.subscribers {
+			close(ch)
+		}
+		delete(streams, uid)
+	}
+}
+
+// poll sends the inbox entries created since start to the streams of uid
+// until ctx is done
+func poll(ctx context.Context, uid string, start time.Time) {
+	ticker := time.NewTicker(streamPollInterval)
+	defer ticker.Stop()
+
+	seen := map[string]time.Time{}
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		from := time.Now().Add(-streamLag)
+		if from.Before(start) {
+			from = start
+		}
+		notifications, err := since(ctx, uid, from)
+		if err != nil {
+			if ctx.Err() == nil {
+				log.Printf("Failed to poll notifications of %s: %v", uid, err)
+			}
+			continue
+		}
+		for _, notification := range notifications {
+			if _, ok := seen[notification.ID]; ok {
+				continue
+			}
+			seen[notification.ID] = notification.CreatedAt
+			broadcast(uid, notification)
+		}
+		for id, createdAt := range seen {
+			if createdAt.Before(from) {
+				delete(seen, id)
+			}
+		}
+	}
+}
+
+// since returns the inbox entries of uid created at from or later, oldest first
+func since(ctx context.Context, uid string, from time.Time) ([]models.Notification, error) {
+	client, err := config.Database(ctx)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := client.NewRef(inboxPath(uid)).OrderByKey().StartAt(keyAt(from)).GetOrdered(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	notifications := make([]models.Notification, 0, len(nodes))
+	for _, node := range nodes {
+		var notification models.Notification
+		if err := node.Unmarshal(&notification); err != nil {
+			log.Printf("Failed to decode notification %s of %s: %v", node.Key(), uid, err)
+			continue
+		}
+		notification.ID = node.Key()
+		notifications = append(notifications, notification)
+	}
+	return notifications, nil
+}
+
+// keyAt returns the smallest inbox key of an entry created at t
+func keyAt(t time.Time) string {
+	return inboxKey(t)[:8] + strings.Repeat(pushChars[:1], 12)
+}
+
+func broadcast(uid string, notification models.Notification) {
+	streamsMu.Lock()
+	defer streamsMu.Unlock()
+	w := streams[uid]
+	if w == nil {
+		return
+	}
+	for ch := range w.subscribers {
+		select {
+		case ch <- notification:
+		default:
+		}
+	}
+}
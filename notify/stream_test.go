@@ -0,0 +1,61 @@
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"golang-firebase-backend/models"
+)
+
+func TestKeyAt(t *testing.T) {
+	at := time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+	bound := keyAt(at)
+
+	for i := 0; i < 100; i++ {
+		if key := inboxKey(at); key < bound {
+			t.Fatalf("inboxKey(at) = %s sorts before keyAt(at) = %s", key, bound)
+		}
+		if key := inboxKey(at.Add(-time.Millisecond)); key >= bound {
+			t.Fatalf("inboxKey(at-1ms) = %s sorts after keyAt(at) = %s", key, bound)
+		}
+	}
+	if later := keyAt(at.Add(time.Millisecond)); later <= bound {
+		t.Errorf("keyAt(at+1ms) = %s, want it after %s", later, bound)
+	}
+}
+
+func TestBroadcastReachesOpenStreams(t *testing.T) {
+	first, cancelFirst := Subscribe("stream-user")
+	second, cancelSecond := Subscribe("stream-user")
+	other, cancelOther := Subscribe("other-user")
+	defer cancelOther()
+
+	broadcast("stream-user", models.Notification{ID: "n1"})
+	for _, ch := range []<-chan models.Notification{first, second} {
+		if got := <-ch; got.ID != "n1" {
+			t.Errorf("got %s, want n1", got.ID)
+		}
+	}
+	select {
+	case got := <-other:
+		t.Errorf("other user got %s", got.ID)
+	default:
+	}
+
+	cancelFirst()
+	if _, open := <-first; open {
+		t.Error("cancelled stream is still open")
+	}
+	broadcast("stream-user", models.Notification{ID: "n2"})
+	if got := <-second; got.ID != "n2" {
+		t.Errorf("got %s, want n2", got.ID)
+	}
+
+	cancelSecond()
+	streamsMu.Lock()
+	_, watched := streams["stream-user"]
+	streamsMu.Unlock()
+	if watched {
+		t.Error("the inbox is still polled after the last stream closed")
+	}
+}
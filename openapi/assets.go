@@ -0,0 +1,59 @@
+package openapi
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+	"path"
+	"strings"
+
+	"golang-firebase-backend/apierror"
+)
+
+//go:generate sh swagger-ui/fetch.sh
+
+// swaggerUI holds the Swagger UI release fetched by swagger-ui/fetch.sh and
+// the script that starts it
+//
+//go:embed swagger-ui
+var swaggerUI embed.FS
+
+// assetTypes are the files Assets serves, by extension
+var assetTypes = map[string]string{
+	".js":  "text/javascript; charset=utf-8",
+	".css": "text/css; charset=utf-8",
+}
+
+func swaggerUIVersion() string {
+	version, _ := fs.ReadFile(swaggerUI, "swagger-ui/VERSION")
+	return strings.TrimSpace(string(version))
+}
+
+// bundled reports whether the Swagger UI release is embedded in the binary
+func bundled() bool {
+	_, err := fs.Stat(swaggerUI, "swagger-ui/swagger-ui-bundle.js")
+	return err == nil
+}
+
+// Assets serves the scripts and styles of the docs page from the binary. The
+// file name is the "file" path value.
+func Assets() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("file")
+		contentType, ok := assetTypes[path.Ext(name)]
+		if !ok || strings.Contains(name, "/") {
+			apierror.Respond(w, r, http.StatusNotFound, "Not found")
+			return
+		}
+		data, err := fs.ReadFile(swaggerUI, "swagger-ui/"+name)
+		if err != nil {
+			apierror.Respond(w, r, http.StatusNotFound, "Not found")
+			return
+		}
+
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Cache-Control", "public, max-age=86400")
+		w.WriteHeader(http.StatusOK)
+		w.Write(data)
+	})
+}
@@ -0,0 +1,50 @@
+package openapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestAssets(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.Handle("GET /assets/{file}", Assets())
+
+	tests := []struct {
+		file        string
+		status      int
+		contentType string
+	}{
+		{"docs.js", http.StatusOK, "text/javascript; charset=utf-8"},
+		{"fetch.sh", http.StatusNotFound, ""},
+		{"VERSION", http.StatusNotFound, ""},
+		{"missing.css", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/assets/"+tt.file, nil))
+		if rec.Code != tt.status {
+			t.Errorf("%s: status = %d, want %d", tt.file, rec.Code, tt.status)
+		}
+		if tt.contentType != "" && rec.Header().Get("Content-Type") != tt.contentType {
+			t.Errorf("%s: Content-Type = %q, want %q", tt.file, rec.Header().Get("Content-Type"), tt.contentType)
+		}
+	}
+}
+
+func TestUIHasNoInlineScript(t *testing.T) {
+	rec := httptest.NewRecorder()
+	UI("/api/openapi.json", "/api/docs/assets").ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/docs", nil))
+
+	page := rec.Body.String()
+	if !strings.Contains(page, `src="/api/docs/assets/docs.js"`) || !strings.Contains(page, `data-spec="/api/openapi.json"`) {
+		t.Errorf("page does not start Swagger UI from the local script:\n%s", page)
+	}
+	if strings.Contains(page, "<script>") {
+		t.Errorf("page has an inline script:\n%s", page)
+	}
+	if bundled() && strings.Contains(page, "unpkg.com") {
+		t.Errorf("page loads Swagger UI from the CDN although it is bundled:\n%s", page)
+	}
+}
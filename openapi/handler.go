@@ -0,0 +1,61 @@
+package openapi
+
+import (
+	_ "embed"
+	"html/template"
+	"log"
+	"net/http"
+	"sync"
+
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/utils"
+)
+
+//go:embed docs.html
+var docsPage string
+
+var docsTemplate = template.Must(template.New("docs").Parse(docsPage))
+
+// Handler serves the document returned by build. It is built on the first
+// request, once every route has been registered.
+func Handler(build func() (*Document, error)) http.Handler {
+	var (
+		once sync.Once
+		doc  *Document
+	)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		once.Do(func() {
+			var err error
+			if doc, err = build(); err != nil {
+				log.Printf("OpenAPI document is incomplete: %v", err)
+			}
+		})
+		if doc == nil {
+			apierror.Respond(w, r, http.StatusInternalServerError, "Failed to build API documentation")
+			return
+		}
+		utils.RespondJSON(w, http.StatusOK, doc)
+	})
+}
+
+// UI serves a Swagger UI page for the document at specURL. Its scripts and
+// styles are served by Assets under assetsURL.
+func UI(specURL, assetsURL string) http.Handler {
+	page := map[string]string{
+		"SpecURL":   specURL,
+		"Assets":    assetsURL,
+		"SwaggerUI": assetsURL,
+	}
+	if !bundled() {
+		// Until the release is fetched with go generate, Swagger UI itself
+		// comes from the CDN
+		page["SwaggerUI"] = "https://unpkg.com/swagger-ui-dist@" + swaggerUIVersion()
+		log.Printf("Swagger UI %s is not bundled, the API docs page loads it from unpkg.com; run go generate ./openapi", swaggerUIVersion())
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := docsTemplate.Execute(w, page); err != nil {
+			log.Printf("Failed to render API docs page: %v", err)
+		}
+	})
+}
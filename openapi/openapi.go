@@ -0,0 +1,275 @@
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/router"
+)
+
+// Document is an OpenAPI 3.1 document
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps lower case HTTP methods to operations
+type PathItem map[string]*Operation
+
+type Operation struct {
+	OperationID string                   `json:"operationId"`
+	Summary     string                   `json:"summary,omitempty"`
+	Description string                   `json:"description,omitempty"`
+	Tags        []string                 `json:"tags,omitempty"`
+	Deprecated  bool                     `json:"deprecated,omitempty"`
+	Parameters  []Parameter              `json:"parameters,omitempty"`
+	RequestBody *RequestBody             `json:"requestBody,omitempty"`
+	Responses   map[string]interface{}   `json:"responses"`
+	Security    []map[string]interface{} `json:"security"`
+}
+
+type Parameter struct {
+	Name        string `json:"name"`
+	In          string `json:"in"` // "path", "query" or "header"
+	Description string `json:"description,omitempty"`
+	Required    bool   `json:"required"`
+	Schema      Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema Schema `json:"schema"`
+}
+
+type Components struct {
+	Schemas         map[string]Schema      `json:"schemas"`
+	Responses       map[string]interface{} `json:"responses"`
+	SecuritySchemes map[string]interface{} `json:"securitySchemes"`
+}
+
+// Endpoint documents one route. Body and Response are example values whose
+// types give the request and response schemas.
+type Endpoint struct {
+	Summary     string
+	Description string
+	Tag         string
+	Public      bool              // no Firebase ID token required
+	Admin       bool              // requires the admin role claim, 403 otherwise
+	Query       map[string]string // query parameter name to description
+	Body        interface{}
+	Upload      []string // media types of a raw request body, instead of a JSON Body
+	Status      int      // success status, 200 when zero
+	Response    interface{}
+	Stream      bool // responds with server-sent events of Response
+	MergePatch  bool // Body is also accepted as a JSON merge patch
+	ETag        bool // responds with an ETag; writes honor If-Match
+	RateLimited bool // has a rate limit of its own and may answer 429
+}
+
+const securityScheme = "firebase"
+
+// rateLimitHeaders are sent by rate limited endpoints
+var rateLimitHeaders = map[string]string{
+	"RateLimit-Limit":     "Requests allowed in a burst",
+	"RateLimit-Remaining": "Requests left before the limit is reached",
+	"RateLimit-Reset":     "Seconds until the full burst is available again",
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// Build describes every route of the router. Legacy aliases are listed as
+// deprecated operations sharing the documentation of their successor. It
+// fails when a route has no Endpoint or an Endpoint has no route, so that the
+// document cannot drift away from what the server actually serves.
+func Build(info Info, routes []router.Route, endpoints map[string]Endpoint) (*Document, error) {
+	gen := newSchemas()
+	doc := &Document{
+		OpenAPI: "3.1.0",
+		Info:    info,
+		Paths:   map[string]PathItem{},
+	}
+
+	var missing []string
+	documented := map[string]bool{}
+	for _, route := range routes {
+		key, method := route.Pattern(), route.Method
+		if route.Deprecated {
+			key = route.Successor
+			method, _, _ = strings.Cut(route.Successor, " ")
+		}
+
+		endpoint, ok := endpoints[key]
+		if !ok {
+			missing = append(missing, route.Pattern())
+			continue
+		}
+		documented[key] = true
+
+		item := doc.Paths[route.Path]
+		if item == nil {
+			item = PathItem{}
+			doc.Paths[route.Path] = item
+		}
+		item[strings.ToLower(method)] = gen.operation(route, method, endpoint)
+	}
+
+	var stale []string
+	for key := range endpoints {
+		if !documented[key] {
+			stale = append(stale, key)
+		}
+	}
+
+	doc.Components = Components{
+		Schemas: gen.components,
+		Responses: map[string]interface{}{
+			"Error": map[string]interface{}{
+				"description": "Error in the standard envelope",
+				"content": map[string]MediaType{
+					"application/json": {Schema: gen.of(Object{"success": false, "error": apierror.Error{}})},
+				},
+			},
+		},
+		SecuritySchemes: map[string]interface{}{
+			securityScheme: map[string]string{
+				"type":         "http",
+				"scheme":       "bearer",
+				"bearerFormat": "Firebase ID token",
+			},
+		},
+	}
+
+	switch {
+	case len(missing) > 0:
+		sort.Strings(missing)
+		return doc, fmt.Errorf("routes missing from the OpenAPI document: %s", strings.Join(missing, ", "))
+	case len(stale) > 0:
+		sort.Strings(stale)
+		return doc, fmt.Errorf("documented endpoints that are not registered: %s", strings.Join(stale, ", "))
+	}
+	return doc, nil
+}
+
+func (s *schemas) operation(route router.Route, method string, endpoint Endpoint) *Operation {
+	op := &Operation{
+		OperationID: operationID(method, route.Path, route.Deprecated),
+		Summary:     endpoint.Summary,
+		Description: endpoint.Description,
+		Deprecated:  route.Deprecated,
+		Responses:   map[string]interface{}{},
+		Security:    []map[string]interface{}{},
+	}
+	if endpoint.Tag != "" {
+		op.Tags = []string{endpoint.Tag}
+	}
+	if route.Deprecated {
+		op.Description = strings.TrimSpace(fmt.Sprintf("Deprecated alias of `%s`; IDs are passed in the query or the body. %s", route.Successor, endpoint.Description))
+	}
+	if !endpoint.Public {
+		op.Security = append(op.Security, map[string]interface{}{securityScheme: []string{}})
+	}
+
+	for _, match := range pathParam.FindAllStringSubmatch(route.Path, -1) {
+		op.Parameters = append(op.Parameters, Parameter{Name: match[1], In: "path", Required: true, Schema: Schema{"type": "string"}})
+	}
+	names := make([]string, 0, len(endpoint.Query))
+	for name := range endpoint.Query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		op.Parameters = append(op.Parameters, Parameter{Name: name, In: "query", Description: endpoint.Query[name], Schema: Schema{"type": "string"}})
+	}
+	if endpoint.ETag && method != http.MethodGet {
+		op.Parameters = append(op.Parameters, Parameter{
+			Name: "If-Match", In: "header", Schema: Schema{"type": "string"},
+			Description: "ETag from an earlier response; the write fails with 412 if the resource has changed since",
+		})
+	}
+
+	if endpoint.Body != nil {
+		op.RequestBody = &RequestBody{
+			Required: true,
+			Content:  map[string]MediaType{"application/json": {Schema: s.of(endpoint.Body)}},
+		}
+		if endpoint.MergePatch {
+			op.RequestBody.Content["application/merge-patch+json"] = MediaType{Schema: s.of(endpoint.Body)}
+		}
+	}
+	if len(endpoint.Upload) > 0 {
+		op.RequestBody = &RequestBody{Required: true, Content: map[string]MediaType{}}
+		for _, mediaType := range endpoint.Upload {
+			op.RequestBody.Content[mediaType] = MediaType{Schema: Schema{"type": "string", "contentMediaType": mediaType}}
+		}
+	}
+
+	status := endpoint.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	response := map[string]interface{}{"description": http.StatusText(status)}
+	if endpoint.Response != nil {
+		mediaType := "application/json"
+		if endpoint.Stream {
+			mediaType = "text/event-stream"
+		}
+		response["content"] = map[string]MediaType{mediaType: {Schema: s.of(endpoint.Response)}}
+	}
+	headers := map[string]interface{}{}
+	if endpoint.ETag {
+		headers["ETag"] = map[string]interface{}{"description": "Version of the stored resource", "schema": Schema{"type": "string"}}
+		if method != http.MethodGet {
+			op.Responses[strconv.Itoa(http.StatusPreconditionFailed)] = map[string]string{"$ref": "#/components/responses/Error"}
+		}
+	}
+	if endpoint.Admin {
+		op.Responses[strconv.Itoa(http.StatusForbidden)] = map[string]string{"$ref": "#/components/responses/Error"}
+	}
+	if endpoint.RateLimited {
+		for name, description := range rateLimitHeaders {
+			headers[name] = map[string]interface{}{"description": description, "schema": Schema{"type": "integer"}}
+		}
+		op.Responses[strconv.Itoa(http.StatusTooManyRequests)] = map[string]string{"$ref": "#/components/responses/Error"}
+	}
+	if len(headers) > 0 {
+		response["headers"] = headers
+	}
+	op.Responses[strconv.Itoa(status)] = response
+	op.Responses["default"] = map[string]string{"$ref": "#/components/responses/Error"}
+
+	return op
+}
+
+// operationID derives a stable identifier such as "get_api_v1_products_id"
+func operationID(method, path string, legacy bool) string {
+	id := strings.ToLower(method) + "_" + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		case r == '{' || r == '}':
+			return -1
+		}
+		return '_'
+	}, strings.Trim(path, "/"))
+	if legacy {
+		id = "legacy_" + id
+	}
+	return id
+}
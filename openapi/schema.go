@@ -0,0 +1,192 @@
+package openapi
+
+import (
+	"encoding/json"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema is a JSON Schema object as used by OpenAPI 3.1
+type Schema map[string]interface{}
+
+// Object describes an ad-hoc JSON object, such as the maps handlers build
+// for their responses. Each value is an example whose type gives the schema
+// of that property.
+type Object map[string]interface{}
+
+// Data describes the usual {"success", "data", "message"} response
+func Data(data interface{}) Object {
+	return Object{"success": true, "data": data, "message": ""}
+}
+
+// Message describes a response that only carries a message
+func Message() Object {
+	return Object{"success": true, "message": ""}
+}
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage{})
+)
+
+// schemas turns Go values into schemas, collecting named struct types as
+// reusable components
+type schemas struct {
+	components map[string]Schema
+}
+
+func newSchemas() *schemas {
+	return &schemas{components: map[string]Schema{}}
+}
+
+func (s *schemas) of(v interface{}) Schema {
+	switch value := v.(type) {
+	case nil:
+		return Schema{}
+	case Object:
+		properties := Schema{}
+		for name, example := range value {
+			properties[name] = s.of(example)
+		}
+		return Schema{"type": "object", "properties": properties}
+	case []Object:
+		items := Schema{"type": "object"}
+		if len(value) > 0 {
+			items = s.of(value[0])
+		}
+		return Schema{"type": "array", "items": items}
+	}
+	return s.ofType(reflect.TypeOf(v))
+}
+
+func (s *schemas) ofType(t reflect.Type) Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return Schema{"type": "string", "format": "date-time"}
+	case rawMessageType:
+		return Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return Schema{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Schema{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return Schema{"type": "number"}
+	case reflect.String:
+		return Schema{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return Schema{"type": "string", "contentEncoding": "base64"}
+		}
+		return Schema{"type": "array", "items": s.ofType(t.Elem())}
+	case reflect.Map:
+		return Schema{"type": "object", "additionalProperties": s.ofType(t.Elem())}
+	case reflect.Struct:
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		if _, ok := s.components[t.Name()]; !ok {
+			// Reserve the name first so that recursive types terminate
+			s.components[t.Name()] = Schema{}
+			s.components[t.Name()] = s.structSchema(t)
+		}
+		return Schema{"$ref": "#/components/schemas/" + t.Name()}
+	}
+
+	return Schema{}
+}
+
+func (s *schemas) structSchema(t reflect.Type) Schema {
+	properties := Schema{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			embedded := field.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if field.Anonymous && embedded.Kind() == reflect.Struct {
+				embedded := s.structSchema(embedded)
+				for key, value := range embedded["properties"].(Schema) {
+					properties[key] = value
+				}
+				continue
+			}
+			name = field.Name
+		}
+
+		property := s.ofType(field.Type)
+		if rules := field.Tag.Get("validate"); rules != "" {
+			if constrain(property, rules) {
+				required = append(required, name)
+			}
+		}
+		properties[name] = property
+	}
+
+	schema := Schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// constrain describes the validate tag rules of a field in its schema and
+// reports whether the field is required
+func constrain(property Schema, rules string) bool {
+	target := property
+	if items, ok := property["items"].(Schema); ok {
+		target = items
+	}
+
+	required := false
+	for _, rule := range strings.Split(rules, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		limit, _ := strconv.Atoi(arg)
+		switch name {
+		case "required":
+			required = true
+		case "min", "max":
+			key := map[string]string{"min": "minimum", "max": "maximum"}[name]
+			if target["type"] == "string" {
+				key = map[string]string{"min": "minLength", "max": "maxLength"}[name]
+			}
+			target[key] = limit
+		case "minitems":
+			property["minItems"] = limit
+		case "maxitems":
+			property["maxItems"] = limit
+		case "email":
+			target["format"] = "email"
+		case "url":
+			target["format"] = "uri"
+		case "numeric":
+			target["pattern"] = "^[0-9]+$"
+		case "price":
+			target["pattern"] = `^[0-9]{1,3}([.,]?[0-9]{3})*$`
+		case "oneof":
+			target["enum"] = strings.Fields(arg)
+		case "date":
+			target["format"] = "date"
+		}
+	}
+	return required
+}
@@ -0,0 +1,10 @@
+// Starts Swagger UI for the document named by the data-spec attribute. Kept
+// out of docs.html so that the page needs no inline script.
+window.addEventListener("load", function () {
+  var script = document.getElementById("swagger-init");
+  window.ui = SwaggerUIBundle({
+    url: script.dataset.spec,
+    dom_id: "#swagger-ui",
+    persistAuthorization: true,
+  });
+});
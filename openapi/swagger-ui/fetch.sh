@@ -0,0 +1,15 @@
+#!/bin/sh
+# Downloads the Swagger UI release named in VERSION into this directory, so
+# that /api/docs serves it from the binary instead of a CDN. Run through
+# `go generate ./openapi` and commit the result.
+set -eu
+cd "$(dirname "$0")"
+
+version=$(cat VERSION)
+tmp=$(mktemp -d)
+trap 'rm -rf "$tmp"' EXIT
+
+curl -fsSL "https://registry.npmjs.org/swagger-ui-dist/-/swagger-ui-dist-$version.tgz" | tar -xz -C "$tmp"
+for file in swagger-ui-bundle.js swagger-ui.css LICENSE; do
+	cp "$tmp/package/$file" .
+done
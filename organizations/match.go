@@ -0,0 +1,161 @@
+package organizations
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"golang-firebase-backend/models"
+)
+
+// minScore is the lowest score a suggestion may have
+const minScore = 0.5
+
+// synonyms maps words spelled in several ways to one spelling, so that
+// "Univ. Indonesia" and "University of Indonesia" compare equal to
+// "Universitas Indonesia"
+var synonyms = map[string]string{
+	"univ":        "universitas",
+	"university":  "universitas",
+	"universiti":  "universitas",
+	"institute":   "institut",
+	"inst":        "institut",
+	"polytechnic": "politeknik",
+	"poltek":      "politeknik",
+	"poli":        "politeknik",
+	"state":       "negeri",
+	"of":          "",
+	"the":         "",
+}
+
+// normalize lowercases s, drops punctuation and unifies common words
+func normalize(s string) string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	words := fields[:0]
+	for _, word := range fields {
+		if synonym, ok := synonyms[word]; ok {
+			word = synonym
+		}
+		if word != "" {
+			words = append(words, word)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// names returns the normalized names an organization is known by
+func names(org models.Organization) []string {
+	all := make([]string, 0, len(org.Aliases)+2)
+	for _, name := range append([]string{org.Name, org.ShortName}, org.Aliases...) {
+		if name = normalize(name); name != "" {
+			all = append(all, name)
+		}
+	}
+	return all
+}
+
+// score rates how well query, a normalized name, matches name
+func score(query, name string) float64 {
+	switch {
+	case query == name:
+		return 1
+	case strings.HasPrefix(name, query) && len(query) >= 3:
+		return 0.85
+	case containsWords(name, query):
+		return 0.8
+	}
+	similarity := 1 - float64(distance(query, name))/float64(max(len([]rune(query)), len([]rune(name))))
+	return similarity
+}
+
+// containsWords reports whether every word of query starts a word of name
+func containsWords(name, query string) bool {
+	words := strings.Fields(name)
+	for _, q := range strings.Fields(query) {
+		found := false
+		for _, word := range words {
+			if strings.HasPrefix(word, q) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return query != ""
+}
+
+// distance is the Levenshtein distance between a and b
+func distance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// rank scores every organization against text and returns those scoring at
+// least minScore, best first
+func rank(orgs []models.Organization, text string) []models.OrganizationMatch {
+	query := normalize(text)
+	if query == "" {
+		return nil
+	}
+
+	var matches []models.OrganizationMatch
+	for _, org := range orgs {
+		best := 0.0
+		for _, name := range names(org) {
+			best = max(best, score(query, name))
+		}
+		if best >= minScore {
+			matches = append(matches, models.OrganizationMatch{Organization: org, Score: best})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Name < matches[j].Name
+	})
+	return matches
+}
+
+// resolve returns the only organization whose name, short name or an alias
+// equals text once normalized. Similar names are only suggested, since
+// "Universitas Negeri Malang" is one letter away from another campus.
+func resolve(orgs []models.Organization, text string) (*models.Organization, bool) {
+	query := normalize(text)
+	if query == "" {
+		return nil, false
+	}
+	var found *models.Organization
+	for i := range orgs {
+		if !slices.Contains(names(orgs[i]), query) {
+			continue
+		}
+		if found != nil {
+			// Ambiguous, e.g. an alias shared by two campuses
+			return nil, false
+		}
+		found = &orgs[i]
+	}
+	return found, found != nil
+}
@@ -0,0 +1,136 @@
+package organizations
+
+import (
+	"math"
+	"testing"
+
+	"golang-firebase-backend/models"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"Universitas Indonesia", "universitas indonesia"},
+		{"  UNIVERSITAS   indonesia ", "universitas indonesia"},
+		{"Univ. Indonesia", "universitas indonesia"},
+		{"University of Indonesia", "universitas indonesia"},
+		{"Institut Teknologi Bandung (ITB)", "institut teknologi bandung itb"},
+		{"Politeknik Negeri Jakarta", "politeknik negeri jakarta"},
+		{"State Polytechnic of Jakarta", "negeri politeknik jakarta"},
+		{"UIN Syarif-Hidayatullah", "uin syarif hidayatullah"},
+		{"Universitas 17 Agustus 1945", "universitas 17 agustus 1945"},
+		{"the of", ""},
+		{"...", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := normalize(tt.value); got != tt.want {
+				t.Errorf("normalize(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		value string
+		want  float64
+	}{
+		{"exact", "universitas indonesia", "universitas indonesia", 1},
+		{"prefix", "universitas indo", "universitas indonesia", 0.85},
+		{"short prefixes only match words", "un", "universitas indonesia", 0.8},
+		{"words", "indonesia universitas", "universitas indonesia", 0.8},
+		{"word prefixes", "univ indo", "universitas indonesia", 0.8},
+		{"one letter off", "universitas negeri malang", "universitas negeri malag", 0.96},
+		{"unrelated", "itb", "universitas indonesia", 1 - 19.0/21},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := score(tt.query, tt.value); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("score(%q, %q) = %v, want %v", tt.query, tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+		{"malang", "malag", 1},
+		{"bogor", "bogor", 0},
+		{"ü", "u", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			if got := distance(tt.a, tt.b); got != tt.want {
+				t.Errorf("distance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolve(t *testing.T) {
+	orgs := []models.Organization{
+		{ID: "ui", Name: "Universitas Indonesia", ShortName: "UI", Aliases: []string{"Kampus Depok"}},
+		{ID: "um", Name: "Universitas Negeri Malang", ShortName: "UM"},
+		{ID: "unm", Name: "Universitas Negeri Makassar", ShortName: "UNM"},
+		{ID: "ipb", Name: "IPB University", ShortName: "IPB", Aliases: []string{"Kampus Bogor"}},
+		{ID: "unpak", Name: "Universitas Pakuan", Aliases: []string{"Kampus Bogor"}},
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want string // organization ID, empty when unresolved
+	}{
+		{"name", "Universitas Indonesia", "ui"},
+		{"spelling of the name", "University of Indonesia", "ui"},
+		{"short name", "ui", "ui"},
+		{"alias", "kampus depok", "ui"},
+		{"one letter off is not resolved", "Universitas Negeri Malag", ""},
+		{"prefix is not resolved", "Universitas Negeri Ma", ""},
+		{"words are not resolved", "Indonesia", ""},
+		{"ambiguous alias", "Kampus Bogor", ""},
+		{"unknown", "Universitas Gadjah Mada", ""},
+		{"empty", "  ", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			org, ok := resolve(orgs, tt.text)
+			got := ""
+			if ok {
+				got = org.ID
+			}
+			if got != tt.want {
+				t.Errorf("resolve(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRank(t *testing.T) {
+	orgs := []models.Organization{
+		{ID: "um", Name: "Universitas Negeri Malang"},
+		{ID: "unm", Name: "Universitas Negeri Makassar"},
+		{ID: "itb", Name: "Institut Teknologi Bandung"},
+	}
+	matches := rank(orgs, "Univ Negeri Malag")
+	if len(matches) != 2 || matches[0].ID != "um" || matches[1].ID != "unm" {
+		t.Fatalf("rank = %v, want um then unm", matches)
+	}
+	if matches[0].Score >= 1 {
+		t.Errorf("score = %v, want below an exact match", matches[0].Score)
+	}
+	if got := rank(orgs, "..."); got != nil {
+		t.Errorf("rank(...) = %v, want nil", got)
+	}
+}
@@ -0,0 +1,134 @@
+package organizations
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang-firebase-backend/config"
+	"golang-firebase-backend/models"
+)
+
+// updateBatch bounds the paths written by one multi-path update
+const updateBatch = 500
+
+// reference is the organization of a user or a seller request
+type reference struct {
+	Organization   string `json:"organization"`
+	OrganizationID string `json:"organization_id"`
+}
+
+// Migrate maps the free-text organization of users and seller requests that
+// have no organization ID yet onto the registry. Values equal to the name,
+// short name or an alias of one organization are mapped automatically;
+// input.Mappings maps the others and adds them as aliases. The free text is
+// kept as organization_raw. Nothing is written on a dry run.
+func Migrate(ctx context.Context, input models.OrganizationMigrationInput) (*models.OrganizationMigrationReport, error) {
+	orgs, err := List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	byID := map[string]*models.Organization{}
+	for i := range orgs {
+		byID[orgs[i].ID] = &orgs[i]
+	}
+	explicit := map[string]*models.Organization{}
+	for value, id := range input.Mappings {
+		org, ok := byID[id]
+		if !ok {
+			return nil, fmt.Errorf("%w: mapping of %q names unknown organization %q", ErrInvalidInput, value, id)
+		}
+		explicit[normalize(value)] = org
+	}
+
+	client, err := config.Database(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.OrganizationMigrationReport{DryRun: input.DryRun, Mapped: map[string]string{}}
+	updates := map[string]interface{}{}
+	unmatched := map[string]*models.OrganizationMigrationValue{}
+	aliases := map[string]string{} // value to organization ID
+
+	for _, path := range []string{"users", "registerSellers"} {
+		var refs map[string]reference
+		if err := client.NewRef(path).Get(ctx, &refs); err != nil {
+			return nil, fmt.Errorf("error fetching %s: %v", path, err)
+		}
+
+		for key, ref := range refs {
+			value := strings.TrimSpace(ref.Organization)
+			if ref.OrganizationID != "" || value == "" {
+				continue
+			}
+
+			org, ok := explicit[normalize(value)]
+			if ok {
+				aliases[value] = org.ID
+			} else if org, ok = resolve(orgs, value); !ok {
+				if entry, seen := unmatched[normalize(value)]; seen {
+					entry.Count++
+				} else {
+					unmatched[normalize(value)] = &models.OrganizationMigrationValue{Value: value, Count: 1}
+				}
+				continue
+			}
+
+			report.Mapped[value] = org.ID
+			updates[path+"/"+key+"/organization_id"] = org.ID
+			updates[path+"/"+key+"/organization"] = org.Name
+			updates[path+"/"+key+"/organization_raw"] = ref.Organization
+			if path == "users" {
+				report.Users++
+			} else {
+				report.Sellers++
+			}
+		}
+	}
+
+	report.Unmatched = make([]models.OrganizationMigrationValue, 0, len(unmatched))
+	for _, entry := range unmatched {
+		suggestions := rank(orgs, entry.Value)
+		if len(suggestions) > 3 {
+			suggestions = suggestions[:3]
+		}
+		entry.Suggestions = suggestions
+		report.Unmatched = append(report.Unmatched, *entry)
+	}
+	sort.Slice(report.Unmatched, func(i, j int) bool {
+		if report.Unmatched[i].Count != report.Unmatched[j].Count {
+			return report.Unmatched[i].Count > report.Unmatched[j].Count
+		}
+		return report.Unmatched[i].Value < report.Unmatched[j].Value
+	})
+
+	if input.DryRun {
+		return report, nil
+	}
+
+	batch := map[string]interface{}{}
+	for path, value := range updates {
+		batch[path] = value
+		if len(batch) == updateBatch {
+			if err := client.NewRef("").Update(ctx, batch); err != nil {
+				return nil, fmt.Errorf("error updating organizations: %v", err)
+			}
+			batch = map[string]interface{}{}
+		}
+	}
+	if len(batch) > 0 {
+		if err := client.NewRef("").Update(ctx, batch); err != nil {
+			return nil, fmt.Errorf("error updating organizations: %v", err)
+		}
+	}
+
+	// Later signups with the same spelling then match on their own
+	for value, id := range aliases {
+		if err := AddAlias(ctx, id, value); err != nil {
+			return nil, fmt.Errorf("error adding alias %q: %v", value, err)
+		}
+	}
+	return report, nil
+}
@@ -0,0 +1,251 @@
+// Package organizations keeps the registry of universities and other
+// campuses, so that users, seller requests and products refer to one
+// organization instead of free-text spellings of its name. Free text is
+// matched against the names, short names and aliases of the registry.
+package organizations
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"golang-firebase-backend/config"
+	"golang-firebase-backend/models"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrNotFound     = errors.New("organization not found")
+	ErrNameTaken    = errors.New("organization name is already registered")
+	ErrDomainTaken  = errors.New("email domain belongs to another organization")
+	ErrInvalidInput = errors.New("invalid organization")
+)
+
+const collection = "organizations"
+
+func organizationPath(id string) string {
+	return collection + "/" + id
+}
+
+// List returns every organization sorted by name
+func List(ctx context.Context) ([]models.Organization, error) {
+	client, err := config.Database(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var stored map[string]models.Organization
+	if err := client.NewRef(collection).Get(ctx, &stored); err != nil {
+		return nil, fmt.Errorf("error fetching organizations: %v", err)
+	}
+
+	orgs := make([]models.Organization, 0, len(stored))
+	for id, org := range stored {
+		org.ID = id
+		orgs = append(orgs, org)
+	}
+	sort.Slice(orgs, func(i, j int) bool {
+		return orgs[i].Name < orgs[j].Name
+	})
+	return orgs, nil
+}
+
+// Get returns an organization by ID
+func Get(ctx context.Context, id string) (*models.Organization, error) {
+	if id == "" || strings.ContainsAny(id, "/.#$[]") {
+		return nil, ErrNotFound
+	}
+	client, err := config.Database(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var org models.Organization
+	if err := client.NewRef(organizationPath(id)).Get(ctx, &org); err != nil {
+		return nil, fmt.Errorf("error fetching organization: %v", err)
+	}
+	if org.Name == "" {
+		return nil, ErrNotFound
+	}
+	org.ID = id
+	return &org, nil
+}
+
+// Create registers a new organization
+func Create(ctx context.Context, input models.OrganizationInput) (*models.Organization, error) {
+	now := time.Now()
+	org := models.Organization{ID: uuid.New().String(), CreatedAt: now}
+	return save(ctx, org, input, now)
+}
+
+// Update replaces the details of an organization
+func Update(ctx context.Context, id string, input models.OrganizationInput) (*models.Organization, error) {
+	org, err := Get(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	return save(ctx, *org, input, time.Now())
+}
+
+func save(ctx context.Context, org models.Organization, input models.OrganizationInput, now time.Time) (*models.Organization, error) {
+	org.Name = strings.TrimSpace(input.Name)
+	org.ShortName = strings.TrimSpace(input.ShortName)
+	org.LogoURL = input.LogoURL
+	org.Aliases = cleanList(input.Aliases, strings.TrimSpace)
+	org.EmailDomains = cleanList(input.EmailDomains, func(domain string) string {
+		return strings.TrimPrefix(strings.ToLower(strings.TrimSpace(domain)), "@")
+	})
+	org.UpdatedAt = now
+	for _, domain := range org.EmailDomains {
+		if !strings.Contains(domain, ".") || strings.ContainsAny(domain, "@/*: ") {
+			return nil, fmt.Errorf("%w: email domain %q must be a domain such as ui.ac.id", ErrInvalidInput, domain)
+		}
+	}
+
+	orgs, err := List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	name := normalize(org.Name)
+	for _, other := range orgs {
+		if other.ID == org.ID {
+			continue
+		}
+		if normalize(other.Name) == name {
+			return nil, ErrNameTaken
+		}
+		for _, domain := range org.EmailDomains {
+			if contains(other.EmailDomains, domain) {
+				return nil, fmt.Errorf("%w: %s", ErrDomainTaken, domain)
+			}
+		}
+	}
+
+	client, err := config.Database(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := client.NewRef(organizationPath(org.ID)).Set(ctx, &org); err != nil {
+		return nil, fmt.Errorf("error saving organization: %v", err)
+	}
+	return &org, nil
+}
+
+// Delete removes an organization. Users keep the name of the organization
+// they had.
+func Delete(ctx context.Context, id string) error {
+	if _, err := Get(ctx, id); err != nil {
+		return err
+	}
+	client, err := config.Database(ctx)
+	if err != nil {
+		return err
+	}
+	return client.NewRef(organizationPath(id)).Delete(ctx)
+}
+
+// AddAlias records another spelling of the name of an organization
+func AddAlias(ctx context.Context, id, alias string) error {
+	org, err := Get(ctx, id)
+	if err != nil {
+		return err
+	}
+	alias = strings.TrimSpace(alias)
+	for _, name := range append([]string{org.Name, org.ShortName}, org.Aliases...) {
+		if normalize(name) == normalize(alias) {
+			return nil
+		}
+	}
+
+	client, err := config.Database(ctx)
+	if err != nil {
+		return err
+	}
+	return client.NewRef(organizationPath(id)).Update(ctx, map[string]interface{}{
+		"aliases":    append(org.Aliases, alias),
+		"updated_at": time.Now(),
+	})
+}
+
+// Match returns the organizations text may refer to, best first
+func Match(ctx context.Context, text string, limit int) ([]models.OrganizationMatch, error) {
+	orgs, err := List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matches := rank(orgs, text)
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	if matches == nil {
+		matches = []models.OrganizationMatch{}
+	}
+	return matches, nil
+}
+
+// Resolve returns the organization with the ID text, or the one named text
+// by its name, short name or an alias. ok is false when text matches none or
+// several.
+func Resolve(ctx context.Context, text string) (org *models.Organization, ok bool, err error) {
+	orgs, err := List(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	for i := range orgs {
+		if orgs[i].ID == text {
+			return &orgs[i], true, nil
+		}
+	}
+	org, ok = resolve(orgs, text)
+	return org, ok, nil
+}
+
+// ByEmail returns the organization a domain of email is registered to.
+// Subdomains such as student.ui.ac.id belong to the organization of ui.ac.id.
+func ByEmail(ctx context.Context, email string) (*models.Organization, bool, error) {
+	_, domain, found := strings.Cut(strings.ToLower(strings.TrimSpace(email)), "@")
+	if !found || domain == "" {
+		return nil, false, nil
+	}
+	orgs, err := List(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for {
+		for i := range orgs {
+			if contains(orgs[i].EmailDomains, domain) {
+				return &orgs[i], true, nil
+			}
+		}
+		_, parent, ok := strings.Cut(domain, ".")
+		if !ok || !strings.Contains(parent, ".") {
+			return nil, false, nil
+		}
+		domain = parent
+	}
+}
+
+// cleanList applies clean to every item and drops empty and repeated ones
+func cleanList(items []string, clean func(string) string) []string {
+	var cleaned []string
+	for _, item := range items {
+		if item = clean(item); item != "" && !contains(cleaned, item) {
+			cleaned = append(cleaned, item)
+		}
+	}
+	return cleaned
+}
+
+func contains(items []string, item string) bool {
+	for _, existing := range items {
+		if existing == item {
+			return true
+		}
+	}
+	return false
+}
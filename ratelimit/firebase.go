@@ -0,0 +1,91 @@
+package ratelimit
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync/atomic"
+	"time"
+
+	"golang-firebase-backend/logging"
+
+	"firebase.google.com/go/db"
+)
+
+const (
+	// firebasePath is the Realtime Database node holding the buckets
+	firebasePath = "ratelimits"
+	// pruneAfter is how long a bucket must be idle before it is deleted.
+	// Every policy refills its bucket well within it.
+	pruneAfter    = 24 * time.Hour
+	pruneInterval = 10 * time.Minute
+	pruneBatch    = 500
+)
+
+// FirebaseStore keeps buckets in the Realtime Database, so that replicas
+// share them. Every request runs a transaction on its bucket. Idle buckets
+// are found through the index on "updated" declared in database.rules.json,
+// which is deployed with `firebase deploy --only database`; without it the
+// database refuses the query and buckets are never pruned.
+type FirebaseStore struct {
+	client func(ctx context.Context) (*db.Client, error)
+	pruned atomic.Int64 // Unix milliseconds of the last prune
+}
+
+// NewFirebaseStore returns a store using the database returned by client
+func NewFirebaseStore(client func(ctx context.Context) (*db.Client, error)) *FirebaseStore {
+	return &FirebaseStore{client: client}
+}
+
+func (s *FirebaseStore) Take(ctx context.Context, key string, policy Policy, now time.Time) (Result, error) {
+	client, err := s.client(ctx)
+	if err != nil {
+		return Result{}, err
+	}
+
+	var result Result
+	err = client.NewRef(firebasePath+"/"+bucketKey(key)).Transaction(ctx, func(node db.TransactionNode) (interface{}, error) {
+		var b bucket
+		if err := node.Unmarshal(&b); err != nil {
+			return nil, err
+		}
+		result = b.take(policy, now)
+		return b, nil
+	})
+	if err != nil {
+		return Result{}, err
+	}
+
+	if last := s.pruned.Load(); now.UnixMilli()-last >= pruneInterval.Milliseconds() && s.pruned.CompareAndSwap(last, now.UnixMilli()) {
+		go s.prune(context.WithoutCancel(ctx), client, now.Add(-pruneAfter))
+	}
+	return result, nil
+}
+
+// prune deletes buckets that have not been used since before
+func (s *FirebaseStore) prune(ctx context.Context, client *db.Client, before time.Time) {
+	var idle map[string]bucket
+	err := client.NewRef(firebasePath).OrderByChild("updated").EndAt(before.UnixMilli()).LimitToFirst(pruneBatch).Get(ctx, &idle)
+	if err != nil {
+		logging.From(ctx).Warn("failed to prune rate limit buckets", "error", err)
+		return
+	}
+	if len(idle) == 0 {
+		return
+	}
+
+	updates := make(map[string]interface{}, len(idle))
+	for key := range idle {
+		updates[key] = nil
+	}
+	if err := client.NewRef(firebasePath).Update(ctx, updates); err != nil {
+		logging.From(ctx).Warn("failed to prune rate limit buckets", "error", err)
+	}
+}
+
+// bucketKey turns a key, which may contain characters the Realtime Database
+// does not allow in keys, into a valid one
+func bucketKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:16])
+}
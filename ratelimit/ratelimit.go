@@ -0,0 +1,205 @@
+// Package ratelimit protects the API from clients sending too many requests.
+// Every policy is a token bucket per user, or per client IP for requests
+// that are not authenticated (yet). Buckets live in a Store: in memory for a
+// single instance, or in the Realtime Database when several replicas must
+// share them.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/auth"
+	"golang-firebase-backend/logging"
+	"golang-firebase-backend/metrics"
+)
+
+// Policy allows Limit requests per Period, with bursts of up to Burst
+// requests. Requests under different policies use separate buckets; routes
+// sharing a Name share their bucket.
+type Policy struct {
+	Name   string
+	Limit  int
+	Period time.Duration
+	Burst  int // Limit when zero
+}
+
+func (p Policy) burst() int {
+	if p.Burst > 0 {
+		return p.Burst
+	}
+	return p.Limit
+}
+
+// rate returns the tokens added to a bucket per second
+func (p Policy) rate() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+// Result is the state of a bucket after a request took a token from it
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // until the bucket is full again
+	RetryAfter time.Duration // until the next request is allowed, when rejected
+}
+
+// Store keeps the buckets
+type Store interface {
+	// Take takes a token for a request at now from the bucket of key
+	Take(ctx context.Context, key string, policy Policy, now time.Time) (Result, error)
+}
+
+// bucket is the stored state of a token bucket
+type bucket struct {
+	Tokens  float64 `json:"tokens"`
+	Updated int64   `json:"updated"` // Unix milliseconds
+}
+
+// take refills the bucket for the time passed since it was last updated and
+// takes a token if one is left
+func (b *bucket) take(p Policy, now time.Time) Result {
+	capacity := float64(p.burst())
+	if b.Updated == 0 {
+		b.Tokens = capacity
+	} else if elapsed := now.Sub(time.UnixMilli(b.Updated)).Seconds(); elapsed > 0 {
+		b.Tokens = math.Min(capacity, b.Tokens+elapsed*p.rate())
+	}
+	b.Updated = now.UnixMilli()
+
+	result := Result{Limit: p.burst()}
+	if b.Tokens >= 1 {
+		b.Tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = seconds((1 - b.Tokens) / p.rate())
+	}
+	result.Remaining = int(b.Tokens)
+	result.Reset = seconds((capacity - b.Tokens) / p.rate())
+	return result
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// Options configures where buckets are kept and how clients are identified
+type Options struct {
+	Store Store // a MemoryStore when nil
+	// TrustedProxies is the number of proxies in front of the server that
+	// append the client address to X-Forwarded-For. With zero the header
+	// is ignored, since clients can set it to anything.
+	TrustedProxies int
+}
+
+var (
+	optionsMu sync.RWMutex
+	options   = Options{Store: NewMemoryStore()}
+)
+
+// Setup replaces the store and the proxy settings
+func Setup(opts Options) {
+	if opts.Store == nil {
+		opts.Store = NewMemoryStore()
+	}
+	optionsMu.Lock()
+	defer optionsMu.Unlock()
+	options = opts
+}
+
+func current() Options {
+	optionsMu.RLock()
+	defer optionsMu.RUnlock()
+	return options
+}
+
+// Limit applies policy to the requests handled by next. Behind the
+// authentication middleware the bucket is the user's; otherwise it is the
+// client IP's. Responses carry RateLimit-* headers, and rejected requests
+// get 429 with Retry-After. A policy without a limit allows everything.
+func Limit(policy Policy, next http.Handler) http.Handler {
+	if policy.Limit <= 0 || policy.Period <= 0 {
+		return next
+	}
+	header := fmt.Sprintf("%d;w=%d;burst=%d", policy.Limit, int(policy.Period.Seconds()), policy.burst())
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		opts := current()
+		key := policy.Name + ":" + subject(r, opts.TrustedProxies)
+
+		result, err := opts.Store.Take(r.Context(), key, policy, time.Now())
+		if err != nil {
+			// Fail open: an unavailable store must not take the API down
+			logging.From(r.Context()).Warn("rate limit store failed", "policy", policy.Name, "error", err)
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Set("RateLimit-Policy", header)
+		w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+		w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+		w.Header().Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+
+		if !result.Allowed {
+			metrics.ObserveRateLimited(policy.Name)
+			retryAfter := ceilSeconds(result.RetryAfter)
+			w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+			apierror.Write(w, r, apierror.New(http.StatusTooManyRequests, apierror.CodeRateLimited,
+				fmt.Sprintf("Too many requests; retry in %d seconds", retryAfter)))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// subject identifies who sent the request
+func subject(r *http.Request, trustedProxies int) string {
+	if uid, ok := auth.UID(r.Context()); ok {
+		return "uid:" + uid
+	}
+	return "ip:" + ClientIP(r, trustedProxies)
+}
+
+// RequestIP returns the address of the client with the proxy settings given
+// to Setup
+func RequestIP(r *http.Request) string {
+	return ClientIP(r, current().TrustedProxies)
+}
+
+// ClientIP returns the address of the client. With trusted proxies it is
+// taken from X-Forwarded-For, skipping the addresses the proxies appended
+// after the one of the client.
+func ClientIP(r *http.Request, trustedProxies int) string {
+	if trustedProxies > 0 {
+		var hops []string
+		for _, value := range r.Header.Values("X-Forwarded-For") {
+			for _, hop := range strings.Split(value, ",") {
+				hops = append(hops, strings.TrimSpace(hop))
+			}
+		}
+		if i := len(hops) - trustedProxies; i >= 0 && i < len(hops) {
+			if ip := net.ParseIP(hops[i]); ip != nil {
+				return ip.String()
+			}
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,218 @@
+package ratelimit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func TestTake(t *testing.T) {
+	// 60 per minute refills one token a second; bursts of 3
+	policy := Policy{Name: "test", Limit: 60, Period: time.Minute, Burst: 3}
+
+	type take struct {
+		at            time.Duration // since start
+		wantAllowed   bool
+		wantRemaining int
+		wantReset     time.Duration
+		wantRetry     time.Duration
+	}
+	tests := []struct {
+		name  string
+		takes []take
+	}{
+		{
+			name: "a new bucket starts full",
+			takes: []take{
+				{0, true, 2, time.Second, 0},
+			},
+		},
+		{
+			name: "a burst empties the bucket",
+			takes: []take{
+				{0, true, 2, time.Second, 0},
+				{0, true, 1, 2 * time.Second, 0},
+				{0, true, 0, 3 * time.Second, 0},
+				{0, false, 0, 3 * time.Second, time.Second},
+			},
+		},
+		{
+			name: "tokens refill at the policy rate",
+			takes: []take{
+				{0, true, 2, time.Second, 0},
+				{0, true, 1, 2 * time.Second, 0},
+				{0, true, 0, 3 * time.Second, 0},
+				{500 * time.Millisecond, false, 0, 2500 * time.Millisecond, 500 * time.Millisecond},
+				{time.Second, true, 0, 3 * time.Second, 0},
+				{2500 * time.Millisecond, true, 0, 2500 * time.Millisecond, 0},
+			},
+		},
+		{
+			name: "refills stop at the burst",
+			takes: []take{
+				{0, true, 2, time.Second, 0},
+				{time.Hour, true, 2, time.Second, 0},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryStore()
+			for i, take := range tt.takes {
+				result, err := store.Take(context.Background(), "ip:192.0.2.1", policy, start.Add(take.at))
+				if err != nil {
+					t.Fatalf("take %d: %v", i, err)
+				}
+				want := Result{
+					Allowed:    take.wantAllowed,
+					Limit:      3,
+					Remaining:  take.wantRemaining,
+					Reset:      take.wantReset,
+					RetryAfter: take.wantRetry,
+				}
+				if !approximately(result, want) {
+					t.Errorf("take %d at %s = %+v, want %+v", i, take.at, result, want)
+				}
+			}
+		})
+	}
+}
+
+// approximately compares results, allowing for rounding in the durations
+func approximately(got, want Result) bool {
+	near := func(a, b time.Duration) bool {
+		d := a - b
+		return d > -time.Millisecond && d < time.Millisecond
+	}
+	return got.Allowed == want.Allowed && got.Limit == want.Limit && got.Remaining == want.Remaining &&
+		near(got.Reset, want.Reset) && near(got.RetryAfter, want.RetryAfter)
+}
+
+func TestTakeUsesOneBucketPerKey(t *testing.T) {
+	policy := Policy{Name: "test", Limit: 1, Period: time.Minute}
+	store := NewMemoryStore()
+
+	for _, key := range []string{"uid:a", "uid:b"} {
+		if result, _ := store.Take(context.Background(), key, policy, start); !result.Allowed {
+			t.Errorf("first request of %s was rejected", key)
+		}
+	}
+	if result, _ := store.Take(context.Background(), "uid:a", policy, start); result.Allowed {
+		t.Error("second request of uid:a was allowed")
+	}
+}
+
+func TestMemoryStoreDropsFullBuckets(t *testing.T) {
+	policy := Policy{Name: "test", Limit: 60, Period: time.Minute}
+	store := NewMemoryStore()
+	store.Take(context.Background(), "uid:idle", policy, start)
+
+	// The next take after the sweep interval drops the refilled bucket
+	store.Take(context.Background(), "uid:busy", policy, start.Add(sweepInterval+time.Second))
+	if _, ok := store.buckets["uid:idle"]; ok {
+		t.Error("the full bucket of uid:idle was kept")
+	}
+	if _, ok := store.buckets["uid:busy"]; !ok {
+		t.Error("the bucket of uid:busy was dropped")
+	}
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name           string
+		forwardedFor   []string
+		trustedProxies int
+		want           string
+	}{
+		{"no proxies ignores the header", []string{"203.0.113.9"}, 0, "192.0.2.1"},
+		{"one proxy", []string{"203.0.113.9"}, 1, "203.0.113.9"},
+		{"one proxy ignores addresses set by the client", []string{"10.0.0.1, 203.0.113.9"}, 1, "203.0.113.9"},
+		{"two proxies", []string{"10.0.0.1, 203.0.113.9, 198.51.100.7"}, 2, "203.0.113.9"},
+		{"header repeated by proxies", []string{"203.0.113.9", "198.51.100.7"}, 2, "203.0.113.9"},
+		{"fewer hops than proxies", []string{"203.0.113.9"}, 2, "192.0.2.1"},
+		{"not an address", []string{"unknown"}, 1, "192.0.2.1"},
+		{"no header", nil, 1, "192.0.2.1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodGet, "/", nil)
+			r.RemoteAddr = "192.0.2.1:51234"
+			for _, value := range tt.forwardedFor {
+				r.Header.Add("X-Forwarded-For", value)
+			}
+			if got := ClientIP(r, tt.trustedProxies); got != tt.want {
+				t.Errorf("ClientIP = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLimit(t *testing.T) {
+	Setup(Options{TrustedProxies: 1})
+	t.Cleanup(func() { Setup(Options{}) })
+
+	policy := Policy{Name: "test", Limit: 2, Period: time.Minute, Burst: 1}
+	handler := Limit(policy, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	send := func(clientIP string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = "10.0.0.2:443" // the load balancer
+		r.Header.Set("X-Forwarded-For", clientIP)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		return w
+	}
+
+	if w := send("203.0.113.9"); w.Code != http.StatusNoContent {
+		t.Fatalf("first request: status = %d", w.Code)
+	}
+	w := send("203.0.113.9")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request: status = %d, want 429", w.Code)
+	}
+	for header, want := range map[string]string{
+		"Retry-After":         "30",
+		"RateLimit-Policy":    "2;w=60;burst=1",
+		"RateLimit-Limit":     "1",
+		"RateLimit-Remaining": "0",
+	} {
+		if got := w.Header().Get(header); got != want {
+			t.Errorf("%s = %q, want %q", header, got, want)
+		}
+	}
+
+	// Clients behind the same load balancer have buckets of their own
+	if w := send("198.51.100.7"); w.Code != http.StatusNoContent {
+		t.Errorf("other client: status = %d, want its own bucket", w.Code)
+	}
+}
+
+func TestDatabaseRulesIndexBuckets(t *testing.T) {
+	data, err := os.ReadFile("../database.rules.json")
+	if err != nil {
+		t.Fatalf("ReadFile: %v", err)
+	}
+	var rules struct {
+		Rules map[string]json.RawMessage `json:"rules"`
+	}
+	if err := json.Unmarshal(data, &rules); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	var node struct {
+		IndexOn []string `json:".indexOn"`
+	}
+	if err := json.Unmarshal(rules.Rules[firebasePath], &node); err != nil {
+		t.Fatalf("Unmarshal %s: %v", firebasePath, err)
+	}
+	if !slices.Contains(node.IndexOn, "updated") {
+		t.Errorf("%s is indexed on %v, want updated for prune", firebasePath, node.IndexOn)
+	}
+}
@@ -0,0 +1,68 @@
+// Package response wraps http.ResponseWriter for middleware that needs to
+// know what a handler sent.
+package response
+
+import "net/http"
+
+// Writer records the status and size of a response. It forwards Flush, so
+// that streaming handlers keep working behind middleware, and Unwrap for
+// http.ResponseController.
+type Writer struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+// Wrap returns a Writer that records the response sent through w
+func Wrap(w http.ResponseWriter) *Writer {
+	return &Writer{ResponseWriter: w}
+}
+
+func (w *Writer) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *Writer) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += n
+	return n, err
+}
+
+func (w *Writer) Flush() {
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		if w.status == 0 {
+			w.status = http.StatusOK
+		}
+		flusher.Flush()
+	}
+}
+
+func (w *Writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Started reports whether the status line was sent, after which the handler
+// can no longer change the response
+func (w *Writer) Started() bool {
+	return w.status != 0
+}
+
+// Status returns the status sent, or 200 when the handler sent nothing,
+// which is what net/http then answers with
+func (w *Writer) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// Bytes returns the size of the body written so far
+func (w *Writer) Bytes() int {
+	return w.bytes
+}
@@ -0,0 +1,51 @@
+package response
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestWriter(t *testing.T) {
+	tests := []struct {
+		name        string
+		handler     func(w http.ResponseWriter)
+		wantStarted bool
+		wantStatus  int
+		wantBytes   int
+	}{
+		{"nothing sent", func(w http.ResponseWriter) {}, false, http.StatusOK, 0},
+		{"status only", func(w http.ResponseWriter) { w.WriteHeader(http.StatusNoContent) }, true, http.StatusNoContent, 0},
+		{"body only", func(w http.ResponseWriter) { w.Write([]byte("hello")) }, true, http.StatusOK, 5},
+		{"status and body", func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte("not "))
+			w.Write([]byte("found"))
+		}, true, http.StatusNotFound, 9},
+		{"the first status wins", func(w http.ResponseWriter) {
+			w.WriteHeader(http.StatusCreated)
+			w.WriteHeader(http.StatusInternalServerError)
+		}, true, http.StatusCreated, 0},
+		{"flush", func(w http.ResponseWriter) { w.(http.Flusher).Flush() }, true, http.StatusOK, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rw := Wrap(httptest.NewRecorder())
+			tt.handler(rw)
+			if rw.Started() != tt.wantStarted || rw.Status() != tt.wantStatus || rw.Bytes() != tt.wantBytes {
+				t.Errorf("started %t, status %d, bytes %d, want %t, %d, %d",
+					rw.Started(), rw.Status(), rw.Bytes(), tt.wantStarted, tt.wantStatus, tt.wantBytes)
+			}
+		})
+	}
+}
+
+func TestWriterSupportsResponseController(t *testing.T) {
+	recorder := httptest.NewRecorder()
+	if err := http.NewResponseController(Wrap(recorder)).Flush(); err != nil {
+		t.Fatalf("Flush: %v", err)
+	}
+	if !recorder.Flushed {
+		t.Error("the flush did not reach the underlying writer")
+	}
+}
@@ -0,0 +1,134 @@
+package router
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"golang-firebase-backend/apierror"
+	"golang-firebase-backend/logging"
+)
+
+// Route describes one registered endpoint
+type Route struct {
+	Method     string // empty for legacy routes, which answer every method
+	Path       string // ServeMux path, e.g. "/api/v1/products/{id}"
+	Handler    http.Handler
+	Deprecated bool
+	Successor  string // route that replaces a deprecated one, e.g. "DELETE /api/v1/products/{id}"
+}
+
+// Pattern returns the ServeMux pattern of the route
+func (rt Route) Pattern() string {
+	if rt.Method == "" {
+		return rt.Path
+	}
+	return rt.Method + " " + rt.Path
+}
+
+// Router registers method-aware routes on a ServeMux and answers unknown
+// paths and wrong methods with the standard error envelope
+type Router struct {
+	mux    *http.ServeMux
+	routes []Route
+}
+
+func New() *Router {
+	return &Router{mux: http.NewServeMux()}
+}
+
+// Handle registers a handler for a method and path pattern
+func (r *Router) Handle(method, path string, handler http.Handler) {
+	r.add(Route{Method: method, Path: path, Handler: handler})
+}
+
+// HandleFunc registers a handler function for a method and path pattern
+func (r *Router) HandleFunc(method, path string, handler http.HandlerFunc) {
+	r.Handle(method, path, handler)
+}
+
+// Legacy mounts a pre-v1 path as a deprecated alias. It keeps answering
+// every method like before, and tells clients which route replaces it.
+func (r *Router) Legacy(path, successor string, handler http.Handler) {
+	successorPath := successor
+	if i := strings.IndexByte(successor, ' '); i >= 0 {
+		successorPath = successor[i+1:]
+	}
+
+	deprecated := http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+successorPath+`>; rel="successor-version"`)
+		handler.ServeHTTP(w, req)
+	})
+
+	r.add(Route{Path: path, Handler: deprecated, Deprecated: true, Successor: successor})
+}
+
+func (r *Router) add(route Route) {
+	r.mux.Handle(route.Pattern(), route.Handler)
+	r.routes = append(r.routes, route)
+}
+
+// Routes returns every registered route sorted by path and method
+func (r *Router) Routes() []Route {
+	routes := append([]Route(nil), r.routes...)
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// Match returns the pattern of the route that handles req, or "" when none does
+func (r *Router) Match(req *http.Request) string {
+	_, pattern := r.mux.Handler(req)
+	return pattern
+}
+
+func (r *Router) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if _, pattern := r.mux.Handler(req); pattern != "" {
+		r.mux.ServeHTTP(w, req.WithContext(logging.With(req.Context(), "route", pattern)))
+		return
+	}
+
+	// No pattern matched: let the mux decide between 404 and 405 (with
+	// its Allow header), then answer in the standard envelope
+	probe := &statusRecorder{header: http.Header{}}
+	r.mux.ServeHTTP(probe, req)
+
+	switch probe.status {
+	case http.StatusMethodNotAllowed:
+		w.Header().Set("Allow", probe.header.Get("Allow"))
+		apierror.Respond(w, req, http.StatusMethodNotAllowed, "Method "+req.Method+" is not allowed on "+req.URL.Path)
+	case http.StatusNotFound:
+		apierror.Respond(w, req, http.StatusNotFound, "Route not found")
+	default:
+		r.mux.ServeHTTP(w, req)
+	}
+}
+
+// statusRecorder captures the status and headers of the mux's built-in
+// error handlers and discards their plain-text body
+type statusRecorder struct {
+	header http.Header
+	status int
+}
+
+func (s *statusRecorder) Header() http.Header {
+	return s.header
+}
+
+func (s *statusRecorder) WriteHeader(status int) {
+	if s.status == 0 {
+		s.status = status
+	}
+}
+
+func (s *statusRecorder) Write(b []byte) (int, error) {
+	if s.status == 0 {
+		s.status = http.StatusOK
+	}
+	return len(b), nil
+}
@@ -0,0 +1,276 @@
+package routes
+
+import (
+	"net/http"
+
+	"golang-firebase-backend/idcards"
+	"golang-firebase-backend/models"
+	"golang-firebase-backend/openapi"
+	"golang-firebase-backend/router"
+)
+
+var info = openapi.Info{
+	Title:       "SkillX API",
+	Version:     "1.0.0",
+	Description: "Marketplace API for student sellers. Authenticated routes expect a Firebase ID token as a bearer token.",
+}
+
+// Spec builds the OpenAPI document of every route registered on r
+func Spec(r *router.Router) (*openapi.Document, error) {
+	return openapi.Build(info, r.Routes(), endpoints)
+}
+
+var (
+	message = openapi.Object{"message": ""}
+	seller  = openapi.Object{"user": models.UserProfile{}, "registerSeller": models.SellerProfile{}}
+)
+
+const profileNote = "email, language and last_sign_in are only sent to the user and admins. Seller requests show status, contact and graduation details the same way."
+
+const mergePatchNote = "Send Content-Type application/merge-patch+json to apply an RFC 7396 merge patch: omitted fields are kept and null clears a field."
+
+// endpoints documents every route, keyed by its pattern. Legacy aliases reuse
+// the entry of their successor.
+var endpoints = map[string]openapi.Endpoint{
+	// docs
+	"GET /api/openapi.json":       {Tag: "docs", Summary: "OpenAPI document of this API", Public: true, Response: map[string]interface{}{}},
+	"GET /api/docs":               {Tag: "docs", Summary: "Swagger UI for the OpenAPI document", Public: true},
+	"GET /api/docs/assets/{file}": {Tag: "docs", Summary: "Scripts and styles of the Swagger UI page", Public: true},
+
+	// auth
+	"POST /api/v1/auth/login": {
+		Tag: "auth", Summary: "Sign in with a Google ID token, creating the user on first login", RateLimited: true,
+		Description: "Records the session of the device. The body is optional. The first sign-in on a new device sends a security email.",
+		Body:        models.LoginInput{},
+		Response:    openapi.Object{"message": "", "loginTime": "", "token": "", "session": models.Session{}, "user": openapi.Object{"uid": "", "name": "", "email": "", "photoURL": "", "organization": "", "major": "", "language": "", "role": ""}},
+	},
+	"POST /api/v1/auth/logout": {
+		Tag: "auth", Summary: "Sign out the current session, or every session of the user",
+		Query:    map[string]string{"scope": "current (default) or all; all also revokes the refresh tokens"},
+		Response: message,
+	},
+
+	// users
+	"GET /api/v1/users": {
+		Tag: "users", Summary: "Search users by name",
+		Query:       map[string]string{"query": "Part of the name to look for"},
+		Description: profileNote,
+		Response:    openapi.Object{"success": true, "users": []models.UserProfile{}},
+	},
+	"GET /api/v1/users/{uid}": {ETag: true, Tag: "users", Summary: "Profile of a user", Description: profileNote, Response: models.UserProfile{}},
+	"PATCH /api/v1/users/me": {
+		ETag: true, Tag: "users", Summary: "Update the signed-in user's profile",
+		Description: "major accepts either a major title or an object with its id. organization accepts an organization ID or a name; names that match no registered organization are kept as free text with a warning. " + mergePatchNote,
+		Body:        openapi.Object{"name": "", "organization": "", "language": "", "major": ""},
+		MergePatch:  true,
+		Response:    openapi.Object{"message": "", "uid": "", "warnings": []string{}},
+	},
+	"PUT /api/v1/users/me/role":   {Tag: "users", Summary: "Switch between the buyer and seller role", Body: models.RoleChangeInput{}, Response: openapi.Object{"message": "", "role": ""}},
+	"GET /api/v1/users/me/seller": {Tag: "sellers", Summary: "User and seller application of the signed-in user", Response: seller},
+	"PUT /api/v1/users/me/about":  {Tag: "sellers", Summary: "Update the seller's about me text", Body: models.AboutMeInput{}, Response: message},
+	"GET /api/v1/sellers/{id}":    {Tag: "sellers", Summary: "User and seller application of a seller", Description: profileNote, Response: seller},
+	"GET /api/v1/search": {
+		Tag: "search", Summary: "Search users and their products", RateLimited: true,
+		Query:       map[string]string{"query": "Search term", "organization": "Only users, and products of sellers, of this organization ID"},
+		Description: "Users also carry their uid as id. " + profileNote,
+		Response:    openapi.Object{"success": true, "users": []models.UserProfile{}, "products": []models.Product{}},
+	},
+
+	// messages
+	"GET /api/v1/conversations":                           {Tag: "messages", Summary: "Conversations of the signed-in user", Response: openapi.Data([]map[string]interface{}{})},
+	"POST /api/v1/conversations":                          {Tag: "messages", Summary: "Open a chat room with another user", Body: models.ChatRoomInput{}, Status: http.StatusCreated, Response: openapi.Data(openapi.Object{"conversationID": ""})},
+	"GET /api/v1/conversations/{conversationID}/messages": {Tag: "messages", Summary: "Messages of a conversation", Response: openapi.Data([]models.Message{})},
+	"POST /api/v1/messages":                               {Tag: "messages", Summary: "Send a message", Body: models.Message{}, Status: http.StatusCreated, Response: openapi.Data(openapi.Object{"id": ""}), RateLimited: true},
+
+	// notifications
+	"GET /api/v1/notifications": {
+		Tag: "notifications", Summary: "Inbox of the signed-in user, newest first",
+		Query:    map[string]string{"cursor": "next_cursor of the previous page", "limit": "Page size, at most 100"},
+		Response: openapi.Object{"success": true, "data": []models.Notification{}, "next_cursor": ""},
+	},
+	"GET /api/v1/notifications/unread-count":     {Tag: "notifications", Summary: "Number of unread notifications", Response: openapi.Data(openapi.Object{"unread": 0})},
+	"GET /api/v1/notifications/stream":           {Tag: "notifications", Summary: "Live stream of new notifications", Stream: true, Response: models.Notification{}},
+	"POST /api/v1/notifications/read-all":        {Tag: "notifications", Summary: "Mark every notification as read", Response: openapi.Data(openapi.Object{"updated": 0})},
+	"POST /api/v1/notifications/{id}/read":       {Tag: "notifications", Summary: "Mark a notification as read", Response: openapi.Message()},
+	"GET /api/v1/users/me/notification-settings": {Tag: "notifications", Summary: "Notification preferences of the signed-in user", Response: openapi.Data(models.NotificationSettings{})},
+	"PUT /api/v1/users/me/notification-settings": {Tag: "notifications", Summary: "Replace the notification preferences", Body: models.NotificationSettings{}, Response: openapi.Data(models.NotificationSettings{})},
+	"POST /api/v1/users/me/devices":              {Tag: "notifications", Summary: "Register a device for push notifications", Body: models.DeviceToken{}, Response: openapi.Data(models.DeviceToken{})},
+	"DELETE /api/v1/users/me/devices/{token}":    {Tag: "notifications", Summary: "Stop push notifications to a device", Response: openapi.Message()},
+
+	// sessions
+	"GET /api/v1/users/me/sessions":                  {Tag: "sessions", Summary: "Active sessions of the signed-in user", Response: openapi.Data([]models.Session{})},
+	"DELETE /api/v1/users/me/sessions/{id}":          {Tag: "sessions", Summary: "Sign out a session", Response: openapi.Message()},
+	"POST /api/v1/users/me/sessions/sign-out-others": {Tag: "sessions", Summary: "Sign out every session except the current one", Response: openapi.Object{"success": true, "signed_out": 0, "message": ""}},
+
+	// skills
+	"GET /api/v1/skills":           {Tag: "skills", Summary: "List skills", Public: true, Response: []models.Skill{}},
+	"GET /api/v1/skills/{id}":      {Tag: "skills", Summary: "Show a skill", Public: true, Response: models.Skill{}},
+	"POST /api/v1/skills":          {Tag: "skills", Summary: "Create a skill", Public: true, Body: models.Skill{}, Status: http.StatusCreated, Response: openapi.Data(models.Skill{})},
+	"PUT /api/v1/skills/{id}":      {Tag: "skills", Summary: "Rename a skill", Public: true, Body: models.Skill{}, Response: openapi.Message()},
+	"DELETE /api/v1/skills/{id}":   {Tag: "skills", Summary: "Delete a skill", Public: true, Response: openapi.Message()},
+	"POST /api/v1/users/me/skills": {Tag: "skills", Summary: "Add a skill to the signed-in user", Body: models.UserSkill{}, Response: message},
+
+	// portfolios
+	"GET /api/v1/users/me/portfolios":      {Tag: "portfolios", Summary: "Portfolios of the signed-in user", Response: []models.Portfolio{}},
+	"POST /api/v1/users/me/portfolios":     {Tag: "portfolios", Summary: "Create a portfolio", Body: models.PortfolioInput{}, Status: http.StatusCreated, Response: models.Portfolio{}},
+	"GET /api/v1/users/me/portfolios/{id}": {ETag: true, Tag: "portfolios", Summary: "Show a portfolio of the signed-in user", Response: models.Portfolio{}},
+	"PUT /api/v1/users/me/portfolios/{id}": {ETag: true, Tag: "portfolios", Summary: "Update a portfolio", Body: models.Portfolio{}, Response: models.Portfolio{}},
+	"PATCH /api/v1/users/me/portfolios/{id}": {
+		ETag: true, Tag: "portfolios", Summary: "Partially update a portfolio", Description: mergePatchNote,
+		Body: models.Portfolio{}, MergePatch: true, Response: models.Portfolio{},
+	},
+	"DELETE /api/v1/users/me/portfolios/{id}": {Tag: "portfolios", Summary: "Delete a portfolio", Response: message},
+	"GET /api/v1/users/{uid}/portfolios":      {Tag: "portfolios", Summary: "Portfolios of a user", Response: []models.Portfolio{}},
+
+	// majors
+	"GET /api/v1/majors":         {Tag: "majors", Summary: "List majors", Response: []models.Major{}},
+	"GET /api/v1/majors/{id}":    {Tag: "majors", Summary: "Show a major with its categories", Response: openapi.Object{"major": models.Major{}, "categories": []map[string]interface{}{}}},
+	"POST /api/v1/majors":        {Tag: "majors", Summary: "Create a major", Body: models.Major{}, Status: http.StatusCreated, Response: openapi.Data(models.Major{})},
+	"PUT /api/v1/majors/{id}":    {Tag: "majors", Summary: "Update a major", Body: models.MajorUpdateInput{}, Response: openapi.Data(map[string]interface{}{})},
+	"DELETE /api/v1/majors/{id}": {Tag: "majors", Summary: "Delete a major", Response: openapi.Message()},
+
+	// services
+	"GET /api/v1/services":         {Tag: "services", Summary: "List services", Response: []models.Service{}},
+	"GET /api/v1/services/{id}":    {Tag: "services", Summary: "Show a service", Response: models.Service{}},
+	"POST /api/v1/services":        {Tag: "services", Summary: "Create a service", Body: models.ServiceInput{}, Status: http.StatusCreated, Response: openapi.Data(models.Service{})},
+	"PUT /api/v1/services/{id}":    {Tag: "services", Summary: "Update a service", Body: models.ServiceUpdateInput{}, Response: openapi.Data(map[string]interface{}{})},
+	"DELETE /api/v1/services/{id}": {Tag: "services", Summary: "Delete a service", Response: openapi.Message()},
+
+	// categories
+	"GET /api/v1/categories":      {Tag: "categories", Summary: "List categories", Response: []models.Category{}},
+	"GET /api/v1/categories/{id}": {ETag: true, Tag: "categories", Summary: "Show a category", Response: models.Category{}},
+	"POST /api/v1/categories":     {Tag: "categories", Summary: "Create a category", Body: models.CategoryInput{}, Status: http.StatusCreated, Response: openapi.Data(models.Category{})},
+	"PUT /api/v1/categories/{id}": {ETag: true, Tag: "categories", Summary: "Update a category", Body: models.CategoryUpdateInput{}, Response: openapi.Data(models.Category{})},
+	"PATCH /api/v1/categories/{id}": {
+		ETag: true, Tag: "categories", Summary: "Partially update a category", Description: mergePatchNote,
+		Body: models.CategoryUpdateInput{}, MergePatch: true, Response: openapi.Data(models.Category{}),
+	},
+	"DELETE /api/v1/categories/{id}": {Tag: "categories", Summary: "Delete a category", Response: openapi.Message()},
+
+	// organizations
+	"GET /api/v1/organizations": {Tag: "organizations", Summary: "List organizations", Response: openapi.Data([]models.Organization{})},
+	"GET /api/v1/organizations/match": {
+		Tag: "organizations", Summary: "Organizations a typed name may refer to, best first", RateLimited: true,
+		Description: "Matches names, short names and aliases while tolerating abbreviations and typos. A score of 1 is an exact match.",
+		Query:       map[string]string{"query": "Name as typed by the user", "limit": "Maximum number of matches (1-20, default 5)"},
+		Response:    openapi.Data([]models.OrganizationMatch{}),
+	},
+	"GET /api/v1/organizations/{id}":    {Tag: "organizations", Summary: "Show an organization", Response: openapi.Data(models.Organization{})},
+	"POST /api/v1/organizations":        {Tag: "organizations", Admin: true, Summary: "Create an organization", Body: models.OrganizationInput{}, Status: http.StatusCreated, Response: openapi.Data(models.Organization{})},
+	"PUT /api/v1/organizations/{id}":    {Tag: "organizations", Admin: true, Summary: "Update an organization", Body: models.OrganizationInput{}, Response: openapi.Data(models.Organization{})},
+	"DELETE /api/v1/organizations/{id}": {Tag: "organizations", Admin: true, Summary: "Delete an organization", Response: openapi.Message()},
+	"POST /api/v1/admin/organizations/migrate": {
+		Tag: "admin", Admin: true, Summary: "Map free-text organizations of users and seller requests onto the registry",
+		Description: "Values that clearly name one organization are mapped automatically. mappings maps the remaining values to organization IDs and adds them as aliases. dry_run reports without writing. The body is optional.",
+		Body:        models.OrganizationMigrationInput{},
+		Response:    openapi.Data(models.OrganizationMigrationReport{}),
+	},
+
+	// products
+	"GET /api/v1/products/search": {
+		Tag: "products", Summary: "Search products by name or seller", Public: true, RateLimited: true,
+		Query:    map[string]string{"query": "Search term", "organization": "Only products of sellers of this organization ID"},
+		Response: openapi.Data([]models.Product{}),
+	},
+	"GET /api/v1/products/lookup": {
+		Tag: "products", Summary: "Find a product by seller name and product name",
+		Query:    map[string]string{"name": "Name of the seller", "product_name": "Name of the product"},
+		Response: openapi.Data(models.Product{}),
+	},
+	"GET /api/v1/products/{id}": {
+		ETag: true, Tag: "products", Summary: "Show a product",
+		Query:    map[string]string{"user_id": "Owner of the product; looked up when omitted"},
+		Response: openapi.Data(models.Product{}),
+	},
+	"POST /api/v1/products":     {Tag: "products", Summary: "Create a product for the signed-in seller", Body: models.ProductInput{}, Status: http.StatusCreated, Response: openapi.Data(models.Product{})},
+	"PUT /api/v1/products/{id}": {ETag: true, Tag: "products", Summary: "Update a product of the signed-in seller", Body: models.ProductUpdateInput{}, Response: openapi.Message()},
+	"PATCH /api/v1/products/{id}": {
+		ETag: true, Tag: "products", Summary: "Partially update a product of the signed-in seller", Description: mergePatchNote,
+		Body: models.ProductUpdateInput{}, MergePatch: true, Response: openapi.Data(models.Product{}),
+	},
+	"DELETE /api/v1/products/{id}":     {Tag: "products", Summary: "Delete a product of the signed-in seller", Response: openapi.Message()},
+	"GET /api/v1/users/me/products":    {Tag: "products", Summary: "Products of the signed-in seller", Response: openapi.Data([]models.Product{})},
+	"GET /api/v1/users/{uid}/products": {Tag: "products", Summary: "Products of a seller", Response: openapi.Data([]models.Product{})},
+
+	// seller applications
+	"POST /api/v1/seller-requests": {
+		Tag: "sellers", Summary: "Apply to become a seller",
+		Description: "Requires a verified campus email, which becomes the email of the application, and an uploaded student ID card. Campus domains mapped to an organization override the organization entered.",
+		Body:        models.SellerRequestInput{},
+		Response:    openapi.Object{"message": "", "register_seller": models.SellerProfile{}},
+	},
+	"GET /api/v1/seller-requests/me": {
+		Tag: "sellers", Summary: "Status of the signed-in user's application",
+		Description: "alumni_status is set once the graduation date has passed: pending_confirmation until the seller answers, then alumni.",
+		Response:    openapi.Object{"status": "", "alumni_status": ""},
+	},
+	"POST /api/v1/seller-requests/me/alumni": {
+		Tag: "sellers", Summary: "Confirm graduating, or move the graduation date",
+		Description: "Sellers past their graduation date are asked by email whether they graduated. graduated marks the seller as alumni, which shows as a badge on the profile; " +
+			"depending on the alumni policy they keep selling or go back to the admin queue and cannot list new products until accepted again. " +
+			"studying requires a new graduation date that has not passed.",
+		Body:     models.AlumniConfirmationInput{},
+		Response: openapi.Object{"message": "", "register_seller": models.SellerProfile{}},
+	},
+	"GET /api/v1/seller-requests/me/email-verification": {
+		Tag: "sellers", Summary: "Verification of the signed-in user's campus email",
+		Response: openapi.Data(models.CampusVerification{}),
+	},
+	"POST /api/v1/seller-requests/me/email-verification": {
+		Tag: "sellers", Summary: "Email a verification code to a campus address", RateLimited: true,
+		Description: "The domain of the address must be on the campus allow-list. A new code replaces the previous one and can only be requested once resend_after has passed.",
+		Body:        models.CampusEmailInput{},
+		Response:    openapi.Object{"success": true, "data": models.CampusVerification{}, "message": ""},
+	},
+	"POST /api/v1/seller-requests/me/email-verification/confirm": {
+		Tag: "sellers", Summary: "Confirm the campus email with the code that was sent", RateLimited: true,
+		Description: "Codes expire and allow a limited number of wrong attempts; then a new code must be requested.",
+		Body:        models.CampusCodeInput{},
+		Response:    openapi.Object{"success": true, "data": models.CampusVerification{}, "message": ""},
+	},
+	"GET /api/v1/seller-requests/me/id-card": {
+		Tag: "sellers", Summary: "Student ID card of the signed-in user",
+		Description: "url is signed for a few minutes and must not be stored. Answers 410 once the card was deleted after the application was decided.",
+		Response:    openapi.Data(models.IDCard{}),
+	},
+	"PUT /api/v1/seller-requests/me/id-card": {
+		Tag: "sellers", Summary: "Upload the student ID card for a seller application", RateLimited: true,
+		Description: "The body is the photo itself, at most 5 MB. It is kept in private storage, replaces any earlier photo and is deleted a while after the application was decided.",
+		Upload:      idcards.AllowedTypes,
+		Response:    openapi.Object{"success": true, "data": models.IDCard{}, "message": ""},
+	},
+	"GET /api/v1/admin/seller-requests": {Tag: "admin", Admin: true, Summary: "List seller applications, leaving out pending ones without a verified campus email", Response: []models.SellerProfile{}},
+	"GET /api/v1/admin/seller-requests/{uid}/id-card": {
+		Tag: "admin", Admin: true, Summary: "Student ID card of an applicant",
+		Description: "Requires the admin role claim. url is signed for a few minutes and must not be stored.",
+		Response:    openapi.Data(models.IDCard{}),
+	},
+	"POST /api/v1/admin/seller-requests/{uid}/decision": {
+		Tag: "admin", Admin: true, Summary: "Accept or deny a seller application",
+		Body:     models.SellerDecisionInput{},
+		Response: openapi.Object{"message": "", "register_seller": models.SellerProfile{}},
+	},
+
+	// transactions
+	"POST /api/v1/transactions": {
+		Tag: "transactions", Summary: "Start a Midtrans payment for a product", RateLimited: true,
+		Body: models.TransactionInput{}, Status: http.StatusCreated,
+		Description: "user_id is the seller of the product; the signed-in user is the buyer.",
+		Response:    openapi.Data(openapi.Object{"transaction": models.Transaction{}, "url": ""}),
+	},
+	"POST /api/v1/transactions/notifications": {
+		Tag: "transactions", Summary: "Payment notification URL for Midtrans", Public: true,
+		Description: "Set as the payment notification URL in the Midtrans dashboard. Requests are checked against signature_key; " +
+			"the buyer is notified of every order status change and the seller once the payment is settled.",
+		Body:     models.PaymentNotification{},
+		Response: openapi.Object{"success": true},
+	},
+
+	// background jobs
+	"GET /api/v1/admin/jobs": {
+		Tag: "admin", Admin: true, Summary: "List background jobs",
+		Query:    map[string]string{"status": "pending or dead (default)"},
+		Response: openapi.Data([]models.Job{}),
+	},
+	"POST /api/v1/admin/jobs/{id}/retry": {Tag: "admin", Admin: true, Summary: "Requeue a dead job", Response: openapi.Data(models.Job{})},
+}
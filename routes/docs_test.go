@@ -0,0 +1,63 @@
+package routes
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSpecDocumentsEveryRoute(t *testing.T) {
+	r := New(Dependencies{})
+	doc, err := Spec(r)
+	if err != nil {
+		t.Fatalf("Spec: %v", err)
+	}
+
+	for _, route := range r.Routes() {
+		item, ok := doc.Paths[route.Path]
+		if !ok {
+			t.Errorf("%s is missing from the document", route.Pattern())
+			continue
+		}
+
+		method := route.Method
+		if route.Deprecated {
+			method, _, _ = strings.Cut(route.Successor, " ")
+		}
+		operation, ok := item[strings.ToLower(method)]
+		if !ok {
+			t.Errorf("%s has no %s operation", route.Path, method)
+			continue
+		}
+		if operation.Summary == "" {
+			t.Errorf("%s %s has no summary", method, route.Path)
+		}
+		if operation.Deprecated != route.Deprecated {
+			t.Errorf("%s %s: deprecated = %v, want %v", method, route.Path, operation.Deprecated, route.Deprecated)
+		}
+	}
+}
+
+func TestSpecMarksAdminRoutes(t *testing.T) {
+	r := New(Dependencies{})
+	doc, err := Spec(r)
+	if err != nil {
+		t.Fatalf("Spec: %v", err)
+	}
+
+	// Admin routes outside /api/v1/admin/
+	admin := map[string]bool{
+		"POST /api/v1/organizations":        true,
+		"PUT /api/v1/organizations/{id}":    true,
+		"DELETE /api/v1/organizations/{id}": true,
+	}
+	for _, route := range r.Routes() {
+		pattern := route.Method + " " + route.Path
+		if route.Deprecated || !(strings.HasPrefix(route.Path, "/api/v1/admin/") || admin[pattern]) {
+			continue
+		}
+		operation := doc.Paths[route.Path][strings.ToLower(route.Method)]
+		if _, ok := operation.Responses["403"]; !ok {
+			t.Errorf("%s requires the admin role but documents no 403 response", pattern)
+		}
+	}
+}
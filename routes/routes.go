@@ -0,0 +1,282 @@
+package routes
+
+import (
+	"net/http"
+	"time"
+
+	"golang-firebase-backend/controllers"
+	"golang-firebase-backend/handlers"
+	"golang-firebase-backend/middleware"
+	"golang-firebase-backend/openapi"
+	"golang-firebase-backend/ratelimit"
+	"golang-firebase-backend/router"
+)
+
+// Dependencies are the services main builds and hands to the handlers
+type Dependencies struct {
+	Payments *controllers.Payments
+}
+
+// New registers the v1 API and the deprecated legacy paths
+func New(deps Dependencies) *router.Router {
+	r := router.New()
+	registerV1(r, deps)
+	registerLegacy(r, deps)
+
+	// API documentation, built from the routes above
+	r.Handle("GET", "/api/openapi.json", openapi.Handler(func() (*openapi.Document, error) { return Spec(r) }))
+	r.Handle("GET", "/api/docs", openapi.UI("/api/openapi.json", "/api/docs/assets"))
+	r.Handle("GET", "/api/docs/assets/{file}", openapi.Assets())
+	return r
+}
+
+// Rate limits of routes that are expensive or easy to abuse. Legacy paths
+// share the bucket of the route that replaces them.
+var (
+	loginLimit             = ratelimit.Policy{Name: "login", Limit: 10, Period: time.Minute, Burst: 5}
+	searchLimit            = ratelimit.Policy{Name: "search", Limit: 10, Period: time.Minute, Burst: 5}
+	productSearchLimit     = ratelimit.Policy{Name: "product-search", Limit: 30, Period: time.Minute, Burst: 10}
+	messageLimit           = ratelimit.Policy{Name: "messages", Limit: 30, Period: time.Minute, Burst: 10}
+	transactionLimit       = ratelimit.Policy{Name: "transactions", Limit: 10, Period: time.Minute, Burst: 3}
+	organizationMatchLimit = ratelimit.Policy{Name: "organization-match", Limit: 60, Period: time.Minute, Burst: 20}
+	campusCodeLimit        = ratelimit.Policy{Name: "campus-code", Limit: 10, Period: time.Hour, Burst: 3}
+	campusConfirmLimit     = ratelimit.Policy{Name: "campus-confirm", Limit: 20, Period: time.Hour, Burst: 5}
+	idCardUploadLimit      = ratelimit.Policy{Name: "id-card-upload", Limit: 10, Period: time.Hour, Burst: 3}
+)
+
+// limit applies a rate limit policy to a handler. Wrapped in auth it
+// limits per user, otherwise per client IP.
+func limit(policy ratelimit.Policy, handler http.HandlerFunc) http.HandlerFunc {
+	return ratelimit.Limit(policy, handler).ServeHTTP
+}
+
+func auth(handler http.HandlerFunc) http.Handler {
+	return middleware.FirebaseAuthMiddleware(handler)
+}
+
+// sensitive is auth for routes that must not be used with the token of a
+// revoked session, even if the token has not expired yet
+func sensitive(handler http.HandlerFunc) http.Handler {
+	return middleware.FirebaseAuthCheckRevokedMiddleware(handler)
+}
+
+// admin is sensitive for routes only administrators may use
+func admin(handler http.HandlerFunc) http.Handler {
+	return middleware.FirebaseAuthCheckRevokedMiddleware(middleware.RequireAdmin(handler))
+}
+
+func public(handler http.HandlerFunc) http.Handler {
+	return handler
+}
+
+func registerV1(r *router.Router, deps Dependencies) {
+	// auth
+	r.Handle("POST", "/api/v1/auth/login", auth(limit(loginLimit, controllers.LoginWithGoogle)))
+	r.Handle("POST", "/api/v1/auth/logout", auth(controllers.Logout))
+
+	// users
+	r.Handle("GET", "/api/v1/users", auth(controllers.SearchUsersByName))
+	r.Handle("GET", "/api/v1/users/{uid}", auth(controllers.FetchUserByUID))
+	r.Handle("PATCH", "/api/v1/users/me", auth(controllers.UpdateUser))
+	r.Handle("PUT", "/api/v1/users/me/role", sensitive(handlers.HandleChangeRole))
+	r.Handle("GET", "/api/v1/users/me/seller", auth(handlers.HandleGetUserAndSellerData))
+	r.Handle("PUT", "/api/v1/users/me/about", auth(handlers.HandleUpdateAboutMe))
+	r.Handle("GET", "/api/v1/sellers/{id}", auth(handlers.HandleGetUserAndSellerDataByQuery))
+	r.Handle("GET", "/api/v1/search", auth(limit(searchLimit, controllers.SearchController)))
+
+	// messages
+	r.Handle("GET", "/api/v1/conversations", auth(controllers.FetchConversations))
+	r.Handle("POST", "/api/v1/conversations", auth(controllers.CreateChatRoom))
+	r.Handle("GET", "/api/v1/conversations/{conversationID}/messages", auth(controllers.FetchMessages))
+	r.Handle("POST", "/api/v1/messages", auth(limit(messageLimit, controllers.SendMessage)))
+
+	// notifications
+	r.Handle("GET", "/api/v1/notifications", auth(controllers.FetchNotifications))
+	r.Handle("GET", "/api/v1/notifications/unread-count", auth(controllers.FetchUnreadNotificationCount))
+	r.Handle("GET", "/api/v1/notifications/stream", auth(controllers.StreamNotifications))
+	r.Handle("POST", "/api/v1/notifications/read-all", auth(controllers.MarkAllNotificationsRead))
+	r.Handle("POST", "/api/v1/notifications/{id}/read", auth(controllers.MarkNotificationRead))
+	r.Handle("GET", "/api/v1/users/me/notification-settings", auth(controllers.NotificationSettings))
+	r.Handle("PUT", "/api/v1/users/me/notification-settings", auth(controllers.NotificationSettings))
+	r.Handle("POST", "/api/v1/users/me/devices", auth(controllers.RegisterDevice))
+	r.Handle("DELETE", "/api/v1/users/me/devices/{token}", auth(controllers.UnregisterDevice))
+	r.Handle("GET", "/api/v1/users/me/sessions", auth(controllers.ListSessions))
+	r.Handle("DELETE", "/api/v1/users/me/sessions/{id}", sensitive(controllers.SignOutSession))
+	r.Handle("POST", "/api/v1/users/me/sessions/sign-out-others", sensitive(controllers.SignOutOtherSessions))
+
+	// skills
+	r.Handle("GET", "/api/v1/skills", public(controllers.FetchSkills))
+	r.Handle("GET", "/api/v1/skills/{id}", public(controllers.ShowSkill))
+	r.Handle("POST", "/api/v1/skills", public(controllers.CreateSkill))
+	r.Handle("PUT", "/api/v1/skills/{id}", public(controllers.UpdateSkill))
+	r.Handle("DELETE", "/api/v1/skills/{id}", public(controllers.DeleteSkill))
+	r.Handle("POST", "/api/v1/users/me/skills", auth(controllers.AddUserSkill))
+
+	// portfolios
+	r.Handle("GET", "/api/v1/users/me/portfolios", auth(controllers.ViewUserPortfolios))
+	r.Handle("POST", "/api/v1/users/me/portfolios", auth(controllers.CreatePortfolio))
+	r.Handle("GET", "/api/v1/users/me/portfolios/{id}", auth(controllers.ViewPortfolio))
+	r.Handle("PUT", "/api/v1/users/me/portfolios/{id}", auth(controllers.UpdatePortfolio))
+	r.Handle("PATCH", "/api/v1/users/me/portfolios/{id}", auth(controllers.UpdatePortfolio))
+	r.Handle("DELETE", "/api/v1/users/me/portfolios/{id}", auth(controllers.DeletePortfolio))
+	r.Handle("GET", "/api/v1/users/{uid}/portfolios", auth(controllers.ViewPortfoliosByUID))
+
+	// majors
+	r.Handle("GET", "/api/v1/majors", auth(controllers.FetchMajors))
+	r.Handle("GET", "/api/v1/majors/{id}", auth(controllers.ShowMajor))
+	r.Handle("POST", "/api/v1/majors", auth(controllers.CreateMajor))
+	r.Handle("PUT", "/api/v1/majors/{id}", auth(controllers.UpdateMajor))
+	r.Handle("DELETE", "/api/v1/majors/{id}", auth(controllers.DeleteMajor))
+
+	// services
+	r.Handle("GET", "/api/v1/services", auth(controllers.FetchServices))
+	r.Handle("GET", "/api/v1/services/{id}", auth(controllers.ShowService))
+	r.Handle("POST", "/api/v1/services", auth(controllers.CreateService))
+	r.Handle("PUT", "/api/v1/services/{id}", auth(controllers.UpdateService))
+	r.Handle("DELETE", "/api/v1/services/{id}", auth(controllers.DeleteService))
+
+	// categories
+	r.Handle("GET", "/api/v1/categories", auth(controllers.FetchCategories))
+	r.Handle("GET", "/api/v1/categories/{id}", auth(controllers.ShowCategory))
+	r.Handle("POST", "/api/v1/categories", auth(controllers.CreateCategory))
+	r.Handle("PUT", "/api/v1/categories/{id}", auth(controllers.UpdateCategory))
+	r.Handle("PATCH", "/api/v1/categories/{id}", auth(controllers.UpdateCategory))
+	r.Handle("DELETE", "/api/v1/categories/{id}", auth(controllers.DeleteCategory))
+
+	// organizations
+	r.Handle("GET", "/api/v1/organizations", auth(controllers.FetchOrganizations))
+	r.Handle("GET", "/api/v1/organizations/match", auth(limit(organizationMatchLimit, controllers.MatchOrganizations)))
+	r.Handle("GET", "/api/v1/organizations/{id}", auth(controllers.ShowOrganization))
+	r.Handle("POST", "/api/v1/organizations", admin(controllers.CreateOrganization))
+	r.Handle("PUT", "/api/v1/organizations/{id}", admin(controllers.UpdateOrganization))
+	r.Handle("DELETE", "/api/v1/organizations/{id}", admin(controllers.DeleteOrganization))
+	r.Handle("POST", "/api/v1/admin/organizations/migrate", admin(controllers.MigrateOrganizations))
+
+	// products
+	r.Handle("GET", "/api/v1/products/search", public(limit(productSearchLimit, controllers.SearchProducts)))
+	r.Handle("GET", "/api/v1/products/lookup", auth(controllers.ViewProduct))
+	r.Handle("GET", "/api/v1/products/{id}", auth(controllers.ViewProductByID))
+	r.Handle("POST", "/api/v1/products", auth(controllers.CreateProduct))
+	r.Handle("PUT", "/api/v1/products/{id}", auth(controllers.UpdateProduct))
+	r.Handle("PATCH", "/api/v1/products/{id}", auth(controllers.UpdateProduct))
+	r.Handle("DELETE", "/api/v1/products/{id}", auth(controllers.DeleteProduct))
+	r.Handle("GET", "/api/v1/users/me/products", auth(controllers.FetchProducts))
+	r.Handle("GET", "/api/v1/users/{uid}/products", auth(controllers.FetchProductsByUserID))
+
+	// seller applications
+	r.Handle("POST", "/api/v1/seller-requests", auth(handlers.HandleRequestSeller))
+	r.Handle("GET", "/api/v1/seller-requests/me", auth(handlers.GetRegisterSellerStatus))
+	r.Handle("POST", "/api/v1/seller-requests/me/alumni", auth(handlers.HandleConfirmAlumni))
+	r.Handle("GET", "/api/v1/seller-requests/me/id-card", auth(handlers.HandleGetIDCard))
+	r.Handle("PUT", "/api/v1/seller-requests/me/id-card", auth(limit(idCardUploadLimit, handlers.HandleUploadIDCard)))
+	r.Handle("GET", "/api/v1/seller-requests/me/email-verification", auth(handlers.HandleCampusEmailStatus))
+	r.Handle("POST", "/api/v1/seller-requests/me/email-verification", auth(limit(campusCodeLimit, handlers.HandleSendCampusCode)))
+	r.Handle("POST", "/api/v1/seller-requests/me/email-verification/confirm", auth(limit(campusConfirmLimit, handlers.HandleConfirmCampusCode)))
+	r.Handle("GET", "/api/v1/admin/seller-requests", admin(handlers.HandleGetAllSellers))
+	r.Handle("POST", "/api/v1/admin/seller-requests/{uid}/decision", admin(handlers.HandleAdminVerifySeller))
+	r.Handle("GET", "/api/v1/admin/seller-requests/{uid}/id-card", admin(handlers.HandleAdminGetIDCard))
+
+	// transactions
+	r.Handle("POST", "/api/v1/transactions", sensitive(limit(transactionLimit, deps.Payments.CreateTransaction)))
+	r.Handle("POST", "/api/v1/transactions/notifications", public(deps.Payments.HandlePaymentNotification))
+
+	// background jobs for admin
+	r.Handle("GET", "/api/v1/admin/jobs", admin(controllers.FetchJobs))
+	r.Handle("POST", "/api/v1/admin/jobs/{id}/retry", admin(controllers.RetryJob))
+}
+
+// registerLegacy keeps the paths used before /api/v1 working for existing
+// clients. They answer every method like before and point to their successor.
+func registerLegacy(r *router.Router, deps Dependencies) {
+	r.Legacy("/login/google", "POST /api/v1/auth/login", auth(limit(loginLimit, controllers.LoginWithGoogle)))
+	r.Legacy("/logout", "POST /api/v1/auth/logout", auth(controllers.Logout))
+
+	//user update
+	r.Legacy("/user/update", "PATCH /api/v1/users/me", auth(controllers.UpdateUser))
+	r.Legacy("/user", "GET /api/v1/users/{uid}", auth(controllers.FetchUserByUID))
+	r.Legacy("/user-search", "GET /api/v1/users", auth(controllers.SearchUsersByName))
+
+	r.Legacy("/update-aboutme", "PUT /api/v1/users/me/about", auth(handlers.HandleUpdateAboutMe))
+	r.Legacy("/seller-byid", "GET /api/v1/sellers/{id}", auth(handlers.HandleGetUserAndSellerDataByQuery))
+
+	// message route
+	r.Legacy("/searchAll", "GET /api/v1/search", auth(limit(searchLimit, controllers.SearchController)))
+
+	r.Legacy("/messages", "GET /api/v1/conversations/{conversationID}/messages", auth(controllers.FetchMessages))
+	r.Legacy("/messages-send", "POST /api/v1/messages", auth(limit(messageLimit, controllers.SendMessage)))
+	r.Legacy("/conversations", "GET /api/v1/conversations", auth(controllers.FetchConversations))
+	r.Legacy("/new-chatroom", "POST /api/v1/conversations", auth(controllers.CreateChatRoom))
+
+	// notification routes
+	r.Legacy("/notifications", "GET /api/v1/notifications", auth(controllers.FetchNotifications))
+	r.Legacy("/notifications/unread-count", "GET /api/v1/notifications/unread-count", auth(controllers.FetchUnreadNotificationCount))
+	r.Legacy("/notifications/read", "POST /api/v1/notifications/{id}/read", auth(controllers.MarkNotificationRead))
+	r.Legacy("/notifications/read-all", "POST /api/v1/notifications/read-all", auth(controllers.MarkAllNotificationsRead))
+	r.Legacy("/notifications/stream", "GET /api/v1/notifications/stream", auth(controllers.StreamNotifications))
+	r.Legacy("/user/notification-settings", "PUT /api/v1/users/me/notification-settings", auth(controllers.NotificationSettings))
+	r.Legacy("/user/devices/register", "POST /api/v1/users/me/devices", auth(controllers.RegisterDevice))
+	r.Legacy("/user/devices/unregister", "DELETE /api/v1/users/me/devices/{token}", auth(controllers.UnregisterDevice))
+
+	//skill route
+	r.Legacy("/skills/fetch", "GET /api/v1/skills", public(controllers.FetchSkills))
+	r.Legacy("/skills/view", "GET /api/v1/skills/{id}", public(controllers.ShowSkill))
+	//skillroute for admin
+	r.Legacy("/skills/admincreate", "POST /api/v1/skills", public(controllers.CreateSkill))
+	r.Legacy("/skills/adminupdate", "PUT /api/v1/skills/{id}", public(controllers.UpdateSkill))
+	r.Legacy("/skills/admindelete", "DELETE /api/v1/skills/{id}", public(controllers.DeleteSkill))
+	//userskill routes
+	r.Legacy("/skills/add", "POST /api/v1/users/me/skills", auth(controllers.AddUserSkill))
+	r.Legacy("/user/portfolios/view", "GET /api/v1/users/me/portfolios", auth(controllers.ViewUserPortfolios))
+	r.Legacy("/user/portfolios/view-specific", "GET /api/v1/users/{uid}/portfolios", auth(controllers.ViewSpecificUserPortfolios))
+	r.Legacy("/user/portfolios/create", "POST /api/v1/users/me/portfolios", auth(controllers.CreatePortfolio))
+	r.Legacy("/user/portfolios/update", "PUT /api/v1/users/me/portfolios/{id}", auth(controllers.UpdatePortfolio))
+	r.Legacy("/user/portfolios/delete", "DELETE /api/v1/users/me/portfolios/{id}", auth(controllers.DeletePortfolio))
+	r.Legacy("/user/portfolios/view-uid", "GET /api/v1/users/{uid}/portfolios", auth(controllers.ViewPortfoliosByUID))
+
+	// Major routes
+	r.Legacy("/majors", "GET /api/v1/majors", auth(controllers.FetchMajors))
+	r.Legacy("/majors/admincreate", "POST /api/v1/majors", auth(controllers.CreateMajor))
+	r.Legacy("/majors/adminshow", "GET /api/v1/majors/{id}", auth(controllers.ShowMajor))
+	r.Legacy("/majors/admindelete", "DELETE /api/v1/majors/{id}", auth(controllers.DeleteMajor))
+	r.Legacy("/majors/adminupdate", "PUT /api/v1/majors/{id}", auth(controllers.UpdateMajor))
+
+	// Service routes
+	r.Legacy("/services", "GET /api/v1/services", auth(controllers.FetchServices))
+	r.Legacy("/services/adminshow", "GET /api/v1/services/{id}", auth(controllers.ShowService))
+	r.Legacy("/services/admincreate", "POST /api/v1/services", auth(controllers.CreateService))
+	r.Legacy("/services/admindelete", "DELETE /api/v1/services/{id}", auth(controllers.DeleteService))
+	r.Legacy("/services/adminupdate", "PUT /api/v1/services/{id}", auth(controllers.UpdateService))
+
+	// Category routes
+	r.Legacy("/category", "GET /api/v1/categories", auth(controllers.FetchCategories))
+	r.Legacy("/category/adminshow", "GET /api/v1/categories/{id}", auth(controllers.ShowCategory))
+	r.Legacy("/category/admincreate", "POST /api/v1/categories", auth(controllers.CreateCategory))
+	r.Legacy("/category/admindelete", "DELETE /api/v1/categories/{id}", auth(controllers.DeleteCategory))
+	r.Legacy("/categories/adminupdate", "PUT /api/v1/categories/{id}", auth(controllers.UpdateCategory))
+
+	r.Legacy("/products", "GET /api/v1/users/me/products", auth(controllers.FetchProducts))
+	r.Legacy("/products/view", "GET /api/v1/products/lookup", auth(controllers.ViewProduct))
+	r.Legacy("/products/viewid", "GET /api/v1/products/{id}", auth(controllers.ViewProductByID))
+	r.Legacy("/products/view-seller-product", "GET /api/v1/users/{uid}/products", auth(controllers.FetchProductsByUserID))
+	r.Legacy("/products/create", "POST /api/v1/products", auth(controllers.CreateProduct))
+	r.Legacy("/products/update", "PUT /api/v1/products/{id}", auth(controllers.UpdateProduct))
+	r.Legacy("/products/delete", "DELETE /api/v1/products/{id}", auth(controllers.DeleteProduct))
+
+	//search
+	r.Legacy("/products/search", "GET /api/v1/products/search", public(limit(productSearchLimit, controllers.SearchProducts)))
+
+	r.Legacy("/user/request-seller", "POST /api/v1/seller-requests", auth(handlers.HandleRequestSeller))
+	r.Legacy("/admin/verify-seller", "POST /api/v1/admin/seller-requests/{uid}/decision", admin(handlers.HandleAdminVerifySeller))
+	r.Legacy("/user/request-seller-status", "GET /api/v1/seller-requests/me", auth(handlers.GetRegisterSellerStatus))
+	r.Legacy("/user/change-role", "PUT /api/v1/users/me/role", sensitive(handlers.HandleChangeRole))
+
+	r.Legacy("/user/user-seller-data", "GET /api/v1/users/me/seller", auth(handlers.HandleGetUserAndSellerData))
+	r.Legacy("/admin/regsiterSeller", "GET /api/v1/admin/seller-requests", admin(handlers.HandleGetAllSellers))
+
+	//transaction
+	r.Legacy("/api/transactions", "POST /api/v1/transactions", sensitive(limit(transactionLimit, deps.Payments.CreateTransaction)))
+
+	// background jobs for admin
+	r.Legacy("/admin/jobs", "GET /api/v1/admin/jobs", admin(controllers.FetchJobs))
+	r.Legacy("/admin/jobs/retry", "POST /api/v1/admin/jobs/{id}/retry", admin(controllers.RetryJob))
+}
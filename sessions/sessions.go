@@ -0,0 +1,291 @@
+// Package sessions keeps a registry of the devices a user signed in on, so
+// that single sessions can be listed and signed out. A session is identified
+// by the uid and the sign-in time of its ID tokens (see auth.Principal), which
+// tokens refreshed from the same sign-in keep.
+package sessions
+
+import (
+	"context"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html"
+	"sort"
+	"time"
+
+	"golang-firebase-backend/auth"
+	"golang-firebase-backend/config"
+	"golang-firebase-backend/jobs"
+	"golang-firebase-backend/logging"
+	"golang-firebase-backend/models"
+)
+
+var ErrNotFound = errors.New("session not found")
+
+const (
+	// lastSeenInterval limits how often last_seen_at is written
+	lastSeenInterval = 5 * time.Minute
+	// retention is how long signed out and idle sessions are kept
+	retention = 30 * 24 * time.Hour
+)
+
+func sessionsPath(uid string) string {
+	return "sessions/" + uid
+}
+
+func sessionPath(uid, id string) string {
+	return sessionsPath(uid) + "/" + id
+}
+
+// validID rejects path values that are not session IDs before they are used
+// in a database path
+func validID(id string) bool {
+	_, err := hex.DecodeString(id)
+	return err == nil && id != ""
+}
+
+// Device describes where a sign-in came from
+type Device struct {
+	Name      string
+	Platform  string
+	IP        string
+	UserAgent string
+}
+
+// Check is the auth.SessionCheck of the registry. Tokens of sessions that
+// were never recorded are allowed, since clients that signed in before the
+// registry existed have none.
+func Check(ctx context.Context, p *auth.Principal) error {
+	client, err := config.Database(ctx)
+	if err != nil {
+		return err
+	}
+
+	ref := client.NewRef(sessionPath(p.UID, p.SessionID))
+	var session models.Session
+	if err := ref.Get(ctx, &session); err != nil {
+		return fmt.Errorf("error fetching session: %v", err)
+	}
+	if session.ID == "" {
+		return nil
+	}
+	if session.SignedOutAt != nil {
+		return auth.ErrSessionSignedOut
+	}
+
+	if now := time.Now(); now.Sub(session.LastSeenAt) > lastSeenInterval {
+		// The session is valid even if last_seen_at cannot be written
+		if err := ref.Update(ctx, map[string]interface{}{"last_seen_at": now}); err != nil {
+			logging.From(ctx).Warn("failed to update session", "uid", p.UID, "error", err)
+		}
+	}
+	return nil
+}
+
+// Record registers the session of p at sign-in and reports whether it is
+// new. The first sign-in on a device the user has not used before sends a
+// security email, unless it is the user's first session ever.
+func Record(ctx context.Context, p *auth.Principal, device Device) (*models.Session, bool, error) {
+	client, err := config.Database(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var existing map[string]models.Session
+	if err := client.NewRef(sessionsPath(p.UID)).Get(ctx, &existing); err != nil {
+		return nil, false, fmt.Errorf("error fetching sessions: %v", err)
+	}
+
+	now := time.Now()
+	session, known := existing[p.SessionID]
+	if known && session.SignedOutAt != nil {
+		return nil, false, auth.ErrSessionSignedOut
+	}
+	if !known {
+		session = models.Session{ID: p.SessionID, CreatedAt: now}
+	}
+	session.DeviceName = device.Name
+	session.Platform = device.Platform
+	session.IP = device.IP
+	session.UserAgent = device.UserAgent
+	session.LastSeenAt = now
+
+	updates := map[string]interface{}{p.SessionID: session}
+	for id, other := range existing {
+		if id != p.SessionID && expired(other, now) {
+			updates[id] = nil
+		}
+	}
+	if err := client.NewRef(sessionsPath(p.UID)).Update(ctx, updates); err != nil {
+		return nil, false, fmt.Errorf("error saving session: %v", err)
+	}
+
+	if newDevice(existing, session) && p.Email != "" {
+		// The sign-in itself succeeded
+		if err := alert(ctx, p.Email, session); err != nil {
+			logging.From(ctx).Warn("failed to send security email", "uid", p.UID, "error", err)
+		}
+	}
+	return &session, !known, nil
+}
+
+// newDevice reports whether session is the first sign-in on a device the
+// user has not signed in on before. The user's first session ever is not,
+// as there is nothing to warn about.
+func newDevice(existing map[string]models.Session, session models.Session) bool {
+	if _, known := existing[session.ID]; known || len(existing) == 0 {
+		return false
+	}
+	for _, other := range existing {
+		if sameDevice(other, session) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameDevice compares what the client told about the device, or the user
+// agent when it told nothing
+func sameDevice(a, b models.Session) bool {
+	if a.DeviceName != "" || b.DeviceName != "" {
+		return a.DeviceName == b.DeviceName && a.Platform == b.Platform
+	}
+	return a.UserAgent == b.UserAgent
+}
+
+func expired(session models.Session, now time.Time) bool {
+	if session.SignedOutAt != nil {
+		return now.Sub(*session.SignedOutAt) > retention
+	}
+	return now.Sub(session.LastSeenAt) > retention
+}
+
+// alert emails the user about a sign-in from a new device
+func alert(ctx context.Context, address string, session models.Session) error {
+	device := session.DeviceName
+	if device == "" {
+		device = session.UserAgent
+	}
+	if device == "" {
+		device = "Unknown device"
+	}
+	if session.Platform != "" {
+		device += " (" + session.Platform + ")"
+	}
+
+	body := fmt.Sprintf("<p>Your SkillX account was signed in on a new device.</p>"+
+		"<ul><li>Device: %s</li><li>IP address: %s</li><li>Time: %s</li></ul>"+
+		"<p>If this was not you, sign out the session in your account settings and secure your Google account.</p>",
+		html.EscapeString(device), html.EscapeString(session.IP),
+		session.CreatedAt.UTC().Format("2 Jan 2006 15:04 MST"))
+
+	_, err := jobs.Enqueue(ctx, jobs.TypeSendEmail, jobs.EmailPayload{
+		To:      address,
+		Subject: "New sign-in to your SkillX account",
+		Body:    body,
+	})
+	return err
+}
+
+// List returns the active sessions of the user, most recently used first.
+// The session with currentID is marked as the current one.
+func List(ctx context.Context, uid, currentID string) ([]models.Session, error) {
+	client, err := config.Database(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var stored map[string]models.Session
+	if err := client.NewRef(sessionsPath(uid)).Get(ctx, &stored); err != nil {
+		return nil, fmt.Errorf("error fetching sessions: %v", err)
+	}
+
+	active := []models.Session{}
+	for _, session := range stored {
+		if session.SignedOutAt != nil {
+			continue
+		}
+		session.Current = session.ID == currentID
+		active = append(active, session)
+	}
+	sort.Slice(active, func(i, j int) bool {
+		return active[i].LastSeenAt.After(active[j].LastSeenAt)
+	})
+	return active, nil
+}
+
+// SignOut marks a session of the user as signed out. Signing out a session
+// twice is not an error.
+func SignOut(ctx context.Context, uid, id string) error {
+	if !validID(id) {
+		return ErrNotFound
+	}
+	client, err := config.Database(ctx)
+	if err != nil {
+		return err
+	}
+
+	ref := client.NewRef(sessionPath(uid, id))
+	var session models.Session
+	if err := ref.Get(ctx, &session); err != nil {
+		return fmt.Errorf("error fetching session: %v", err)
+	}
+	if session.ID == "" {
+		return ErrNotFound
+	}
+	if session.SignedOutAt != nil {
+		return nil
+	}
+	return ref.Update(ctx, map[string]interface{}{"signed_out_at": time.Now()})
+}
+
+// End signs out the session of p, recording it if it never was so that its
+// tokens are rejected from now on
+func End(ctx context.Context, p *auth.Principal) error {
+	client, err := config.Database(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = client.NewRef(sessionPath(p.UID, p.SessionID)).Update(ctx, map[string]interface{}{
+		"id":            p.SessionID,
+		"signed_out_at": time.Now(),
+	})
+	if err != nil {
+		return fmt.Errorf("error signing out session: %v", err)
+	}
+	return nil
+}
+
+// SignOutOthers signs out every active session of the user except keepID
+// and returns the IDs of the sessions it signed out
+func SignOutOthers(ctx context.Context, uid, keepID string) ([]string, error) {
+	client, err := config.Database(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var stored map[string]models.Session
+	if err := client.NewRef(sessionsPath(uid)).Get(ctx, &stored); err != nil {
+		return nil, fmt.Errorf("error fetching sessions: %v", err)
+	}
+
+	now := time.Now()
+	updates := map[string]interface{}{}
+	signedOut := []string{}
+	for id, session := range stored {
+		if id == keepID || session.SignedOutAt != nil {
+			continue
+		}
+		updates[id+"/signed_out_at"] = now
+		signedOut = append(signedOut, id)
+	}
+	if len(updates) == 0 {
+		return signedOut, nil
+	}
+	if err := client.NewRef(sessionsPath(uid)).Update(ctx, updates); err != nil {
+		return nil, fmt.Errorf("error signing out sessions: %v", err)
+	}
+	sort.Strings(signedOut)
+	return signedOut, nil
+}
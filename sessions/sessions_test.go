@@ -0,0 +1,114 @@
+package sessions
+
+import (
+	"testing"
+	"time"
+
+	"golang-firebase-backend/models"
+)
+
+var now = time.Date(2025, 3, 10, 12, 0, 0, 0, time.UTC)
+
+func TestSameDevice(t *testing.T) {
+	pixel := models.Session{DeviceName: "Pixel 8", Platform: "android", UserAgent: "okhttp/4.12"}
+
+	tests := []struct {
+		name string
+		a, b models.Session
+		want bool
+	}{
+		{"same device", pixel, models.Session{DeviceName: "Pixel 8", Platform: "android", UserAgent: "okhttp/5.0"}, true},
+		{"other device name", pixel, models.Session{DeviceName: "Galaxy S24", Platform: "android", UserAgent: "okhttp/4.12"}, false},
+		{"other platform", pixel, models.Session{DeviceName: "Pixel 8", Platform: "web", UserAgent: "okhttp/4.12"}, false},
+		{"only one names the device", pixel, models.Session{UserAgent: "okhttp/4.12"}, false},
+		{"same user agent", models.Session{UserAgent: "Mozilla/5.0 Firefox/124.0"}, models.Session{UserAgent: "Mozilla/5.0 Firefox/124.0"}, true},
+		{"other user agent", models.Session{UserAgent: "Mozilla/5.0 Firefox/124.0"}, models.Session{UserAgent: "Mozilla/5.0 Chrome/123.0"}, false},
+		{"nothing told", models.Session{}, models.Session{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameDevice(tt.a, tt.b); got != tt.want {
+				t.Errorf("sameDevice = %t, want %t", got, tt.want)
+			}
+			if got := sameDevice(tt.b, tt.a); got != tt.want {
+				t.Errorf("sameDevice with the sessions swapped = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpired(t *testing.T) {
+	at := func(ago time.Duration) *time.Time {
+		t := now.Add(-ago)
+		return &t
+	}
+
+	tests := []struct {
+		name    string
+		session models.Session
+		want    bool
+	}{
+		{"recently seen", models.Session{LastSeenAt: now.Add(-time.Hour)}, false},
+		{"idle within retention", models.Session{LastSeenAt: now.Add(-retention + time.Minute)}, false},
+		{"idle past retention", models.Session{LastSeenAt: now.Add(-retention - time.Minute)}, true},
+		{"recently signed out", models.Session{LastSeenAt: now.Add(-retention - time.Hour), SignedOutAt: at(time.Hour)}, false},
+		{"signed out past retention", models.Session{LastSeenAt: now.Add(-retention - time.Hour), SignedOutAt: at(retention + time.Minute)}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expired(tt.session, now); got != tt.want {
+				t.Errorf("expired = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewDevice(t *testing.T) {
+	laptop := models.Session{ID: "aa01", DeviceName: "MacBook", Platform: "web"}
+	phone := models.Session{ID: "bb02", DeviceName: "Pixel 8", Platform: "android"}
+	phoneAgain := models.Session{ID: "cc03", DeviceName: "Pixel 8", Platform: "android"}
+
+	tests := []struct {
+		name     string
+		existing []models.Session
+		session  models.Session
+		want     bool
+	}{
+		{"first session ever", nil, phone, false},
+		{"new device", []models.Session{laptop}, phone, true},
+		{"device signed in before", []models.Session{laptop, phone}, phoneAgain, false},
+		{"session seen before", []models.Session{laptop, phone}, phone, false},
+		{"the only session signs in again", []models.Session{phone}, phone, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			existing := map[string]models.Session{}
+			for _, session := range tt.existing {
+				existing[session.ID] = session
+			}
+			if got := newDevice(existing, tt.session); got != tt.want {
+				t.Errorf("newDevice = %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want bool
+	}{
+		{"0a1b2c3d4e5f60718293", true},
+		{"", false},
+		{"../u2", false},
+		{"abc", false},
+		{"0a1b/2c", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			if got := validID(tt.id); got != tt.want {
+				t.Errorf("validID(%q) = %t, want %t", tt.id, got, tt.want)
+			}
+		})
+	}
+}
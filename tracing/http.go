@@ -0,0 +1,81 @@
+package tracing
+
+import (
+	"net/http"
+	"strings"
+
+	"golang-firebase-backend/logging"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Middleware starts a server span for every request, continuing the trace
+// from the traceparent header, and adds the trace ID to the request's logs.
+// Spans are named after the route pattern returned by route.
+func Middleware(route func(*http.Request) string, next http.Handler) http.Handler {
+	withTraceID := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		span := trace.SpanFromContext(r.Context())
+		if pattern := route(r); pattern != "" {
+			span.SetAttributes(semconv.HTTPRoute(pattern))
+		}
+		if sc := span.SpanContext(); sc.IsValid() {
+			r = r.WithContext(logging.With(r.Context(), "trace_id", sc.TraceID().String()))
+		}
+		next.ServeHTTP(w, r)
+	})
+
+	return otelhttp.NewHandler(withTraceID, "http.server",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string {
+			if pattern := route(r); pattern != "" {
+				return pattern
+			}
+			return r.Method + " unmatched"
+		}),
+	)
+}
+
+// Transport starts a client span for every call made through it. Calls to
+// the Realtime Database REST API carry the database path they touch.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{base: base}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	host := req.URL.Hostname()
+	name := "HTTP " + req.Method
+	attrs := []attribute.KeyValue{
+		semconv.HTTPRequestMethodKey.String(req.Method),
+		semconv.ServerAddress(host),
+	}
+	if strings.HasSuffix(host, ".firebaseio.com") || strings.HasSuffix(host, ".firebasedatabase.app") {
+		path := "/" + strings.TrimSuffix(strings.TrimPrefix(req.URL.Path, "/"), ".json")
+		name = "firebase.rtdb " + req.Method
+		attrs = append(attrs, attribute.String("db.system", "firebase_rtdb"), attribute.String("db.firebase.ref", path))
+	}
+
+	ctx, span := Start(req.Context(), name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+	defer span.End()
+
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return resp, err
+	}
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= 400 {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	return resp, nil
+}
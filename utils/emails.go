@@ -1,27 +1,53 @@
 package utils
 
 import (
+	"context"
+	"errors"
 	"log"
-	"os"
+	"sync"
 
+	"golang-firebase-backend/config"
+	"golang-firebase-backend/metrics"
+	"golang-firebase-backend/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 	"gopkg.in/gomail.v2"
 )
 
-func SendEmail(to string, subject string, body string) error {
-	host := os.Getenv("SMTP_HOST")
-	port := 587
-	username := os.Getenv("SMTP_USERNAME")
-	password := os.Getenv("SMTP_PASSWORD")
-	from := os.Getenv("SMTP_FROM")
+var (
+	smtpMu     sync.RWMutex
+	smtpConfig config.SMTPConfig
+)
+
+// SetupEmail sets the mail server SendEmail uses
+func SetupEmail(cfg config.SMTPConfig) {
+	smtpMu.Lock()
+	defer smtpMu.Unlock()
+	smtpConfig = cfg
+}
+
+func SendEmail(ctx context.Context, to string, subject string, body string) error {
+	smtpMu.RLock()
+	cfg := smtpConfig
+	smtpMu.RUnlock()
+	if cfg.Host == "" {
+		return errors.New("SMTP is not configured")
+	}
 
 	m := gomail.NewMessage()
-	m.SetHeader("From", from)
+	m.SetHeader("From", cfg.From)
 	m.SetHeader("To", to)
 	m.SetHeader("Subject", subject)
 	m.SetBody("text/html", body)
 
-	d := gomail.NewDialer(host, port, username, password)
-	if err := d.DialAndSend(m); err != nil {
+	d := gomail.NewDialer(cfg.Host, cfg.Port, cfg.Username, cfg.Password.Value())
+	_, span := tracing.Start(ctx, "smtp.send", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("server.address", cfg.Host)))
+	err := d.DialAndSend(m)
+	tracing.End(span, err)
+	metrics.ObserveEmail(err)
+	if err != nil {
 		log.Printf("Failed to send email: %v", err)
 		return err
 	}
@@ -0,0 +1,12 @@
+package utils
+
+import "net/http"
+
+// Param returns the named path parameter of a v1 route, falling back to the
+// query parameter the legacy route used for the same value
+func Param(r *http.Request, name, legacyQuery string) string {
+	if value := r.PathValue(name); value != "" {
+		return value
+	}
+	return r.URL.Query().Get(legacyQuery)
+}
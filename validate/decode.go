@@ -0,0 +1,86 @@
+package validate
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang-firebase-backend/apierror"
+)
+
+// MaxBodyBytes caps the size of JSON request bodies
+const MaxBodyBytes = 1 << 20
+
+// Decode strictly decodes the JSON body of r into v and validates it. Unknown
+// fields, trailing data and bodies over MaxBodyBytes are rejected. The
+// returned error is an *apierror.Error ready for apierror.Write.
+func Decode(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	return decode(w, r, v, true)
+}
+
+// DecodeLoose is Decode for bodies sent by third parties, such as payment
+// notifications, which may gain fields at any time. Unknown fields are
+// ignored.
+func DecodeLoose(w http.ResponseWriter, r *http.Request, v interface{}) error {
+	return decode(w, r, v, false)
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v interface{}, strict bool) error {
+	r.Body = http.MaxBytesReader(w, r.Body, MaxBodyBytes)
+
+	decoder := json.NewDecoder(r.Body)
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(v); err != nil {
+		return decodeError(err)
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return apierror.BadRequest("Request body must contain a single JSON object")
+	}
+
+	return Struct(v)
+}
+
+func decodeError(err error) error {
+	var (
+		syntaxErr   *json.SyntaxError
+		typeErr     *json.UnmarshalTypeError
+		tooLargeErr *http.MaxBytesError
+	)
+
+	switch {
+	case errors.Is(err, io.EOF):
+		return apierror.BadRequest("Request body is empty")
+	case errors.As(err, &tooLargeErr):
+		return apierror.FromStatus(http.StatusRequestEntityTooLarge, "Request body is too large")
+	case errors.As(err, &syntaxErr), errors.Is(err, io.ErrUnexpectedEOF):
+		return apierror.BadRequest("Request body is not valid JSON")
+	case errors.As(err, &typeErr):
+		field := typeErr.Field
+		if field == "" {
+			return apierror.BadRequest("Request body must be a JSON object")
+		}
+		return apierror.Validation("Invalid input").WithField(field, "must be a "+jsonType(typeErr.Type.Kind().String()))
+	case strings.HasPrefix(err.Error(), "json: unknown field "):
+		field := strings.Trim(strings.TrimPrefix(err.Error(), "json: unknown field "), `"`)
+		return apierror.Validation("Invalid input").WithField(field, "is not a known field")
+	}
+	return apierror.BadRequest("Invalid request payload")
+}
+
+func jsonType(kind string) string {
+	switch {
+	case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"), strings.HasPrefix(kind, "float"):
+		return "number"
+	case kind == "bool":
+		return "boolean"
+	case kind == "slice", kind == "array":
+		return "list"
+	case kind == "map", kind == "struct":
+		return "object"
+	}
+	return kind
+}
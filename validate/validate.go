@@ -0,0 +1,283 @@
+// Package validate checks request bodies against rules declared in struct
+// tags, e.g. `validate:"required,max=100"`. Every rule except required
+// accepts an empty value, so optional fields only need to be well-formed.
+//
+// Rules:
+//
+//	required       value must not be empty
+//	min=N, max=N   length of a string in characters, or value of a number
+//	minitems=N     minimum number of elements of a slice
+//	maxitems=N     maximum number of elements of a slice
+//	email          a plain email address
+//	url            an absolute http or https URL
+//	numeric        a string of digits
+//	price          a whole rupiah amount, optionally grouped by thousands
+//	               as in "150000", "150.000" or "150,000"
+//	oneof=a b c    one of the listed values
+//	month          a month number (1-12) or English month name
+//	date           a date such as "2024-01-31", "2024-01" or RFC 3339
+//	after=field    a date not before the date in the named (json) field
+//
+// Rules on a []string apply to each element, except minitems and maxitems.
+package validate
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang-firebase-backend/apierror"
+)
+
+var dateLayouts = []string{"2006-01-02", time.RFC3339, "2006-01"}
+
+// maxPriceDigits bounds prices to under a trillion rupiah
+const maxPriceDigits = 12
+
+// Struct validates v, a struct or pointer to a struct, and returns a 422
+// *apierror.Error listing every invalid field, or nil
+func Struct(v interface{}) error {
+	value := reflect.Indirect(reflect.ValueOf(v))
+	if value.Kind() != reflect.Struct {
+		return nil
+	}
+
+	fields := check(value)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	err := apierror.Validation("Invalid input")
+	err.Fields = fields
+	return err
+}
+
+func check(value reflect.Value) []apierror.FieldError {
+	var problems []apierror.FieldError
+	t := value.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := jsonName(field)
+		fieldValue := value.Field(i)
+
+		if field.Type.Kind() == reflect.Struct && field.Type != reflect.TypeOf(time.Time{}) {
+			for _, problem := range check(fieldValue) {
+				problem.Field = name + "." + problem.Field
+				problems = append(problems, problem)
+			}
+		}
+
+		tag := field.Tag.Get("validate")
+		if tag == "" {
+			continue
+		}
+		for _, rule := range strings.Split(tag, ",") {
+			if message := apply(rule, fieldValue, value); message != "" {
+				problems = append(problems, apierror.FieldError{Field: name, Message: message})
+				break
+			}
+		}
+	}
+	return problems
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+func apply(rule string, value, parent reflect.Value) string {
+	name, arg, _ := strings.Cut(rule, "=")
+
+	switch name {
+	case "required":
+		if empty(value) {
+			return "is required"
+		}
+		return ""
+	case "minitems", "maxitems":
+		if value.Kind() != reflect.Slice {
+			return ""
+		}
+		limit, _ := strconv.Atoi(arg)
+		if name == "minitems" && value.Len() < limit {
+			return fmt.Sprintf("must have at least %d items", limit)
+		}
+		if name == "maxitems" && value.Len() > limit {
+			return fmt.Sprintf("must have at most %d items", limit)
+		}
+		return ""
+	}
+
+	// Other rules check every element of a string slice
+	if value.Kind() == reflect.Slice && value.Type().Elem().Kind() == reflect.String {
+		for i := 0; i < value.Len(); i++ {
+			if message := applyValue(name, arg, value.Index(i), parent); message != "" {
+				return fmt.Sprintf("item %d %s", i, message)
+			}
+		}
+		return ""
+	}
+	return applyValue(name, arg, value, parent)
+}
+
+func applyValue(name, arg string, value, parent reflect.Value) string {
+	if empty(value) {
+		return ""
+	}
+
+	switch name {
+	case "min", "max":
+		limit, _ := strconv.Atoi(arg)
+		switch value.Kind() {
+		case reflect.String:
+			length := utf8.RuneCountInString(value.String())
+			if name == "min" && length < limit {
+				return fmt.Sprintf("must be at least %d characters", limit)
+			}
+			if name == "max" && length > limit {
+				return fmt.Sprintf("must be at most %d characters", limit)
+			}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			if name == "min" && value.Int() < int64(limit) {
+				return fmt.Sprintf("must be at least %d", limit)
+			}
+			if name == "max" && value.Int() > int64(limit) {
+				return fmt.Sprintf("must be at most %d", limit)
+			}
+		}
+	case "email":
+		address, err := mail.ParseAddress(value.String())
+		if err != nil || address.Address != value.String() {
+			return "must be a valid email address"
+		}
+	case "url":
+		parsed, err := url.Parse(value.String())
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return "must be an http or https URL"
+		}
+	case "numeric":
+		if _, err := strconv.ParseUint(value.String(), 10, 64); err != nil {
+			return "must be a whole number"
+		}
+	case "price":
+		if _, ok := ParsePrice(value.String()); !ok {
+			return fmt.Sprintf("must be a whole amount of at most %d digits, such as 150000 or 150.000", maxPriceDigits)
+		}
+	case "oneof":
+		options := strings.Fields(arg)
+		for _, option := range options {
+			if fmt.Sprint(value.Interface()) == option {
+				return ""
+			}
+		}
+		return "must be one of: " + strings.Join(options, ", ")
+	case "month":
+		if _, ok := ParseMonth(value.String()); !ok {
+			return "must be a month number or name"
+		}
+	case "date":
+		if _, ok := parseDate(value.String()); !ok {
+			return "must be a date formatted as YYYY-MM-DD"
+		}
+	case "after":
+		other, ok := fieldByJSONName(parent, arg)
+		if !ok {
+			return ""
+		}
+		start, okStart := parseDate(other.String())
+		end, okEnd := parseDate(value.String())
+		if okStart && okEnd && end.Before(start) {
+			return "must not be before " + arg
+		}
+	}
+	return ""
+}
+
+func empty(value reflect.Value) bool {
+	switch value.Kind() {
+	case reflect.String:
+		return strings.TrimSpace(value.String()) == ""
+	case reflect.Slice, reflect.Map:
+		return value.Len() == 0
+	}
+	return value.IsZero()
+}
+
+func parseDate(value string) (time.Time, bool) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ParseMonth accepts "3", "03", "Mar" or "march"; month names are matched case-insensitively
+func ParseMonth(value string) (time.Month, bool) {
+	if n, err := strconv.Atoi(value); err == nil {
+		return time.Month(n), n >= 1 && n <= 12
+	}
+	for _, layout := range []string{"January", "Jan"} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t.Month(), true
+		}
+	}
+	return 0, false
+}
+
+// ParsePrice accepts a whole amount written with or without thousand
+// separators, such as "150000", "150.000" or "150,000". A separator must
+// group every three digits, so "150.50" is rejected instead of being read
+// as fifteen thousand.
+func ParsePrice(value string) (int64, bool) {
+	value = strings.TrimSpace(value)
+	separator := strings.IndexFunc(value, func(r rune) bool { return r < '0' || r > '9' })
+	digits := value
+	if separator >= 0 {
+		if value[separator] != '.' && value[separator] != ',' {
+			return 0, false
+		}
+		groups := strings.Split(value, value[separator:separator+1])
+		if len(groups[0]) == 0 || len(groups[0]) > 3 {
+			return 0, false
+		}
+		for _, group := range groups[1:] {
+			if len(group) != 3 {
+				return 0, false
+			}
+		}
+		digits = strings.Join(groups, "")
+	}
+	if digits == "" || len(digits) > maxPriceDigits {
+		return 0, false
+	}
+	for _, r := range digits {
+		if r < '0' || r > '9' {
+			return 0, false
+		}
+	}
+	price, err := strconv.ParseInt(digits, 10, 64)
+	return price, err == nil
+}
+
+func fieldByJSONName(parent reflect.Value, name string) (reflect.Value, bool) {
+	t := parent.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if jsonName(t.Field(i)) == name {
+			return parent.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
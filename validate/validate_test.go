@@ -0,0 +1,221 @@
+package validate
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"golang-firebase-backend/apierror"
+)
+
+// fieldsOf returns the fields reported by a validation error, or nil
+func fieldsOf(t *testing.T, err error) []apierror.FieldError {
+	t.Helper()
+	if err == nil {
+		return nil
+	}
+	var apiErr *apierror.Error
+	if !errors.As(err, &apiErr) {
+		t.Fatalf("error = %v, want an *apierror.Error", err)
+	}
+	return apiErr.Fields
+}
+
+func TestStruct(t *testing.T) {
+	type period struct {
+		Start string `json:"start" validate:"date"`
+		End   string `json:"end" validate:"date,after=start"`
+	}
+	type input struct {
+		Name   string   `json:"name" validate:"required,max=5"`
+		Email  string   `json:"email" validate:"email"`
+		Links  []string `json:"links" validate:"maxitems=2,url"`
+		Code   string   `json:"code" validate:"numeric,min=6,max=6"`
+		Price  string   `json:"price" validate:"price"`
+		Kind   string   `json:"kind" validate:"oneof=product service"`
+		Month  string   `json:"month" validate:"month"`
+		Rating int      `json:"rating" validate:"min=1,max=5"`
+		Period period   `json:"period"`
+	}
+	valid := input{Name: "Budi"}
+
+	tests := []struct {
+		name  string
+		edit  func(*input)
+		field string // the only invalid field, empty when valid
+	}{
+		{"valid", func(in *input) {}, ""},
+		{"every optional field set", func(in *input) {
+			*in = input{
+				Name: "Budi", Email: "budi@ui.ac.id", Links: []string{"https://example.com"}, Code: "012345",
+				Price: "150.000", Kind: "service", Month: "March", Rating: 5,
+				Period: period{Start: "2024-01-01", End: "2024-06"},
+			}
+		}, ""},
+		{"required", func(in *input) { in.Name = "  " }, "name"},
+		{"max characters", func(in *input) { in.Name = "Budiman" }, "name"},
+		{"max counts characters not bytes", func(in *input) { in.Name = "ÄÖÜßé" }, ""},
+		{"email", func(in *input) { in.Email = "Budi <budi@ui.ac.id>" }, "email"},
+		{"url scheme", func(in *input) { in.Links = []string{"https://example.com", "javascript:alert(1)"} }, "links"},
+		{"maxitems", func(in *input) { in.Links = []string{"https://a.com", "https://b.com", "https://c.com"} }, "links"},
+		{"numeric", func(in *input) { in.Code = "12a456" }, "code"},
+		{"min characters", func(in *input) { in.Code = "12345" }, "code"},
+		{"price with separators", func(in *input) { in.Price = "1,500,000" }, ""},
+		{"price with decimals", func(in *input) { in.Price = "150.50" }, "price"},
+		{"oneof", func(in *input) { in.Kind = "job" }, "kind"},
+		{"month number", func(in *input) { in.Month = "12" }, ""},
+		{"month", func(in *input) { in.Month = "13" }, "month"},
+		{"min number", func(in *input) { in.Rating = -1 }, "rating"},
+		{"max number", func(in *input) { in.Rating = 6 }, "rating"},
+		{"date", func(in *input) { in.Period.Start = "01/02/2024" }, "period.start"},
+		{"after", func(in *input) { in.Period = period{Start: "2024-06-01", End: "2024-01-01"} }, "period.end"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := valid
+			tt.edit(&in)
+			fields := fieldsOf(t, Struct(&in))
+			if tt.field == "" {
+				if len(fields) != 0 {
+					t.Errorf("fields = %v, want valid", fields)
+				}
+				return
+			}
+			if len(fields) != 1 || fields[0].Field != tt.field {
+				t.Errorf("fields = %v, want only %s", fields, tt.field)
+			}
+		})
+	}
+}
+
+func TestStructReportsEveryField(t *testing.T) {
+	in := struct {
+		Name  string `json:"name" validate:"required"`
+		Email string `json:"email" validate:"required,email"`
+	}{}
+	var got []string
+	for _, field := range fieldsOf(t, Struct(in)) {
+		got = append(got, field.Field)
+	}
+	if want := []string{"name", "email"}; !slices.Equal(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+}
+
+func TestParsePrice(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   int64
+		wantOK bool
+	}{
+		{"150000", 150000, true},
+		{"150.000", 150000, true},
+		{"150,000", 150000, true},
+		{"1.500.000", 1500000, true},
+		{" 25.000 ", 25000, true},
+		{"0", 0, true},
+		{"999.999.999.999", 999999999999, true},
+		{"1.000.000.000.000", 0, false},
+		{"1000000000000", 0, false},
+		{"150.50", 0, false},
+		{"150.0000", 0, false},
+		{"1500.000", 0, false},
+		{".150", 0, false},
+		{"150.", 0, false},
+		{"1.500,000", 0, false},
+		{"1 500", 0, false},
+		{"-150", 0, false},
+		{"Rp150.000", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := ParsePrice(tt.value)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ParsePrice(%q) = %d, %t, want %d, %t", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseMonth(t *testing.T) {
+	tests := []struct {
+		value  string
+		want   time.Month
+		wantOK bool
+	}{
+		{"3", time.March, true},
+		{"03", time.March, true},
+		{"Mar", time.March, true},
+		{"march", time.March, true},
+		{"DECEMBER", time.December, true},
+		{"0", 0, false},
+		{"13", 0, false},
+		{"Maret", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := ParseMonth(tt.value)
+			if ok != tt.wantOK || (ok && got != tt.want) {
+				t.Errorf("ParseMonth(%q) = %s, %t, want %s, %t", tt.value, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestDecode(t *testing.T) {
+	type input struct {
+		Name     string `json:"name" validate:"required"`
+		Quantity int    `json:"quantity"`
+	}
+
+	tests := []struct {
+		name       string
+		body       string
+		strict     bool
+		wantStatus int // 0 when the body is accepted
+		wantField  string
+	}{
+		{"valid", `{"name": "Budi", "quantity": 2}`, true, 0, ""},
+		{"empty", ``, true, http.StatusBadRequest, ""},
+		{"not json", `{"name": `, true, http.StatusBadRequest, ""},
+		{"not an object", `["Budi"]`, true, http.StatusBadRequest, ""},
+		{"trailing data", `{"name": "Budi"} {}`, true, http.StatusBadRequest, ""},
+		{"wrong type", `{"name": "Budi", "quantity": "2"}`, true, http.StatusUnprocessableEntity, "quantity"},
+		{"unknown field", `{"name": "Budi", "admin": true}`, true, http.StatusUnprocessableEntity, "admin"},
+		{"unknown field in a loose body", `{"name": "Budi", "admin": true}`, false, 0, ""},
+		{"invalid field", `{"quantity": 2}`, true, http.StatusUnprocessableEntity, "name"},
+		{"too large", `{"name": "` + strings.Repeat("a", MaxBodyBytes) + `"}`, true, http.StatusRequestEntityTooLarge, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body))
+			w := httptest.NewRecorder()
+			var in input
+			var err error
+			if tt.strict {
+				err = Decode(w, r, &in)
+			} else {
+				err = DecodeLoose(w, r, &in)
+			}
+
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("Decode = %v, want it accepted", err)
+				}
+				return
+			}
+			var apiErr *apierror.Error
+			if !errors.As(err, &apiErr) || apiErr.Status != tt.wantStatus {
+				t.Fatalf("Decode = %v, want status %d", err, tt.wantStatus)
+			}
+			if tt.wantField != "" && (len(apiErr.Fields) != 1 || apiErr.Fields[0].Field != tt.wantField) {
+				t.Errorf("fields = %v, want %s", apiErr.Fields, tt.wantField)
+			}
+		})
+	}
+}